@@ -0,0 +1,172 @@
+// Package client は GitHub API のクライアントを作る
+// プロキシ・独自 CA・User-Agent・フック・RoundTripper のミドルウェアをオプションで差し込める
+//
+//	c, err := client.NewClient(ctx, token,
+//		client.WithUserAgent("my-tool"),
+//		client.WithMiddleware(func(next http.RoundTripper) http.RoundTripper { ... }),
+//	)
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+	"golang.org/x/oauth2"
+)
+
+// HTTPOptions はネットワーク設定（社内プロキシ / TLS インスペクション環境向け）
+type HTTPOptions struct {
+	CACertFile         string
+	InsecureSkipVerify bool
+}
+
+// NewTransport は HTTP(S)_PROXY / NO_PROXY を尊重し、必要に応じて独自 CA を信頼する Transport を作る
+func NewTransport(opts HTTPOptions) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CACertFile != "" {
+		pem, err := os.ReadFile(opts.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	t.TLSClientConfig = tlsConfig
+	return t, nil
+}
+
+// DefaultUserAgent は WithUserAgent を指定しないときの User-Agent
+const DefaultUserAgent = "dora-metrics"
+
+// Option は NewClient のオプション（独自 RoundTripper / User-Agent / フック）
+type Option func(*config)
+
+type config struct {
+	HTTPOptions
+	transport     http.RoundTripper
+	userAgent     string
+	baseURL       string
+	requestHooks  []func(*http.Request)
+	responseHooks []func(*http.Response, error)
+	middlewares   []func(http.RoundTripper) http.RoundTripper
+}
+
+// WithTransport は下位の RoundTripper を差し替える（指定時は CA / プロキシ設定より優先）
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *config) { c.transport = rt }
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *config) { c.userAgent = ua }
+}
+
+// WithBaseURL は REST API のベース URL を差し替える（GitHub Enterprise Server の https://HOST/api/v3 など。空なら github.com）
+func WithBaseURL(u string) Option {
+	return func(c *config) { c.baseURL = u }
+}
+
+// WithRequestHook は送信直前のリクエストを受け取る（ヘッダー追加などに使える）
+func WithRequestHook(fn func(*http.Request)) Option {
+	return func(c *config) { c.requestHooks = append(c.requestHooks, fn) }
+}
+
+// WithResponseHook はレスポンス（またはエラー）を受け取る
+func WithResponseHook(fn func(*http.Response, error)) Option {
+	return func(c *config) { c.responseHooks = append(c.responseHooks, fn) }
+}
+
+// WithMiddleware は RoundTripper を包むミドルウェアを追加する（後に追加したものほど外側）
+func WithMiddleware(mw func(http.RoundTripper) http.RoundTripper) Option {
+	return func(c *config) { c.middlewares = append(c.middlewares, mw) }
+}
+
+func WithCACert(path string) Option {
+	return func(c *config) { c.CACertFile = path }
+}
+
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(c *config) { c.InsecureSkipVerify = skip }
+}
+
+// hookTransport はフックを呼び出してから下位の RoundTripper に委譲する
+type hookTransport struct {
+	base          http.RoundTripper
+	requestHooks  []func(*http.Request)
+	responseHooks []func(*http.Response, error)
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.requestHooks) > 0 {
+		// RoundTripper は元のリクエストを変更してはいけないので複製する
+		req = req.Clone(req.Context())
+		for _, fn := range t.requestHooks {
+			fn(req)
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	for _, fn := range t.responseHooks {
+		fn(resp, err)
+	}
+	return resp, err
+}
+
+// NewClient は token で認証する GitHub API のクライアントを作る
+func NewClient(ctx context.Context, token string, opts ...Option) (*github.Client, error) {
+	cfg := &config{userAgent: DefaultUserAgent}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	base := cfg.transport
+	if base == nil {
+		t, err := NewTransport(cfg.HTTPOptions)
+		if err != nil {
+			return nil, err
+		}
+		base = t
+	}
+	if len(cfg.requestHooks) > 0 || len(cfg.responseHooks) > 0 {
+		base = &hookTransport{base: base, requestHooks: cfg.requestHooks, responseHooks: cfg.responseHooks}
+	}
+	for _, mw := range cfg.middlewares {
+		base = mw(base)
+	}
+
+	// oauth2 は context 経由で下位の HTTP クライアントを受け取る
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: base})
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	client := github.NewClient(oauth2.NewClient(ctx, ts))
+	client.UserAgent = cfg.userAgent
+	if cfg.baseURL != "" {
+		// ホスト名だけなら /api/v3/ を補う。アップロード用の URL は使わないが同じホストに合わせておく
+		base := strings.TrimRight(cfg.baseURL, "/")
+		if u, err := url.Parse(base); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("invalid GitHub API URL %q (want e.g. https://github.mycorp.com/api/v3)", cfg.baseURL)
+		}
+		upload := strings.TrimSuffix(base, "/api/v3")
+		var err error
+		if client, err = client.WithEnterpriseURLs(base, upload); err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL %q: %w", cfg.baseURL, err)
+		}
+	}
+	return client, nil
+}
@@ -0,0 +1,44 @@
+package dora
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 別名（旧ユーザー名・bot 経由のアカウント・コミットメールなど）を正規の人物に寄せる
+//
+//	alice: [alice-old, alice-deploy-bot, alice@example.com]
+type aliasMap map[string]string // 小文字の別名 -> 正規名
+
+func loadAliasesFile(path string) (aliasMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string][]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	aliases := make(aliasMap)
+	for canonical, names := range raw {
+		for _, name := range names {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if prev, ok := aliases[key]; ok && prev != canonical {
+				return nil, fmt.Errorf("%s: %q is mapped to both %s and %s", path, name, prev, canonical)
+			}
+			aliases[key] = canonical
+		}
+	}
+	return aliases, nil
+}
+
+// 正規名を返す（マッピングが無ければそのまま）
+func (m aliasMap) canonical(identity string) string {
+	if c, ok := m[strings.ToLower(identity)]; ok {
+		return c
+	}
+	return identity
+}
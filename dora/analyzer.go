@@ -0,0 +1,888 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// リポジトリ横断の集計状態
+type analyzer struct {
+	client   *github.Client
+	tracer   *tracer
+	owner    string
+	from, to string
+	members  map[string]bool
+	maxPRs   int
+	deploys  deploymentSource // nil の場合はマージをデプロイとみなす
+	env      string           // リードタイム・デプロイ数の対象とする環境（カンマ区切り・glob、空なら全環境）
+	// PR を数えるブランチ（空ならリポジトリの既定ブランチ、anyBranch ならすべて）
+	deployBranch string
+	branchMu     sync.Mutex
+	branches     map[string]string // リポジトリ -> 既定ブランチ（baseBranch が引いたもの）
+
+	conventional    bool                 // Conventional Commits で変更種別を分類する
+	conventionalCFR bool                 // CFR を fix:/revert の PR で数える
+	fixWindow       time.Duration        // conventionalCFR 時、デプロイ後この期間内の fix のみ失敗とみなす
+	failureMarkers  []string             // PR 本文で作成者が明示する失敗マーカー
+	markersOnly     bool                 // マーカーのみで失敗を判定する（ブランチ名等の推測を使わない）
+	governance      bool                 // レビュー状況を取得してガバナンス指標を求める
+	hours           *businessHours       // 営業時間外・週末のマージ／デプロイを数える（nil なら数えない）
+	hygiene         bool                 // PR 説明の衛生スコアを求める
+	timeline        bool                 // PR のタイムラインを取得して記録に含める（collect）
+	insights        bool                 // 集計結果から次の一手を提案する
+	excludeBots     bool                 // bot の PR・レビューを数えない
+	excludeUsers    map[string]bool      // 数えないユーザー（小文字）
+	shadow          *analyzer            // --shadow-definitions: 同じ PR を別の定義で集計する analyzer
+	shadowOverrides map[string]string    // shadow の定義で上書きしたフラグ
+	memberView      string               // memberViewRelative ならメンバーをチームとの差で出す
+	hygieneMaxLines int                  // 衛生スコアで「小さい PR」とみなす変更行数
+	labelReport     bool                 // マージされた PR のラベルの分布を集計する
+	requiredLabels  []labelGroup         // すべての PR に求めるラベルのグループ
+	includeLabels   labelGroup           // いずれかが付いた PR だけを数える（空なら絞らない）
+	excludeLabels   labelGroup           // いずれかが付いた PR は数えない
+	newMembers      map[string]time.Time // 新メンバーの参加日（--new-members）
+	benchmark       bool                 // 4 指標を DORA のパフォーマンス区分に当てはめる（--benchmark）
+	histogram       bool                 // リードタイム・最初のレビューまでの分布を出す（--histogram）
+	revertWindow    time.Duration        // マージ（デプロイ）後この期間内の取り消しを「即時の取り消し」とみなす
+	incidents       []incident           // インシデント記録（--incidents-file）
+	incidentWindow  time.Duration        // デプロイ後この期間内に始まったインシデントをそのデプロイの失敗とみなす
+	incidentDeploys int                  // インシデントを帰属させる直前のデプロイの数（新しいほど重く分ける）
+	reviewSLA       time.Duration        // 最初のレビューまでの目標（営業時間で数える。0 なら評価しない）
+	slaHours        *businessHours       // reviewSLA を数える営業時間
+	commitLeadTime  bool                 // リードタイムを PR 単位ではなくコミット単位（作成→デプロイ）で求める
+	maxCommitAge    time.Duration        // PR 作成よりこれ以上前に作られたコミットは日付が不正とみなす（0 なら見ない）
+	clampSkew       bool                 // 不正な日付のコミットを除かずに範囲内に丸める
+	funnel          bool                 // 期間内に作成された PR のファネル（作成→レビュー可→レビュー→承認→マージ）を求める
+	reviewMatrix    bool                 // 作成者×レビュアーの件数と応答時間を求める
+	graphql         bool                 // PR・レビュー・コミットを GraphQL でまとめて取得する（--api graphql）
+	carryoverMode   string               // 期間前に作成された PR の扱い（include / exclude / separate）
+	carryoverAge    time.Duration        // 期間の開始よりこれ以上前に作成された PR を持ち越しとみなす
+
+	mu         sync.Mutex
+	team       *Stats
+	repos      map[string]*Stats
+	users      map[string]*Stats
+	mergeSHAs  map[string]bool // ミラー/フォーク間で重複した PR の検出用
+	duplicates int
+	breaches   []slaBreach // レビュー SLA を超えた PR
+	carryover  *Stats      // 持ち越しの PR（include でも件数は数える）
+	pairs      map[reviewPairKey]*reviewPair
+	onboarding map[string]*onboardingStats // 新メンバーごとの立ち上がり（--new-members）
+	links      prLinks                     // 指標に効いた PR へのリンク
+
+	aliases    aliasMap            // 別名 -> 正規のメンバー名
+	membership map[string][]string // メンバー -> 所属チーム
+	teamStats  map[string]*Stats   // チームごとの集計
+
+	prefetch    prefetchCache    // --api graphql で取得済みの PR
+	workers     int              // PR の詳細を並列に取得する数（--concurrency）
+	forges      map[string]forge // GitHub 以外のサービス（"gitlab" など。--repos の接頭辞）
+	repoWorkers int              // 並列に解析するリポジトリの数（--repo-concurrency）
+
+	onRecord func(PRRecord)     // export 用。設定時は PR ごとの生データを渡す（a.mu を保持して呼ぶ）
+	onRepo   func(snapshotRepo) // collect 用。リポジトリごとの母数とデプロイを渡す
+}
+
+// 1 PR 分の集計結果
+type prResult struct {
+	Repo            string
+	Number          int
+	Title           string
+	URL             string
+	CreatedAt       time.Time
+	MergedAt        time.Time
+	Author          string
+	LeadTime        time.Duration
+	HasLeadTime     bool // デプロイが見つからない PR は false
+	IsFix           bool
+	Additions       int
+	Deletions       int
+	ChangeType      string                   // Conventional Commits の種別（分類しない場合は空）
+	DeployLags      map[string]time.Duration // 環境ごとのマージ→デプロイの遅延
+	Reviews         *reviewSummary           // レビュー状況（取得しない・失敗した場合は nil）
+	SelfMerged      bool                     // 作成者自身がマージした
+	AfterHours      bool                     // 平日の営業時間外にマージされた
+	Weekend         bool                     // 週末にマージされた
+	Hygiene         int                      // PR 説明の衛生スコア（0〜100、求めない場合は -1）
+	IsRevert        bool                     // 他の PR を取り消す PR
+	RevertedBy      string                   // 即時に取り消された PR の作成者（即時の取り消しでなければ空）
+	Reverts         int                      // 取り消した PR 番号（分からなければ 0）
+	RevertedAuthor  string                   // 取り消された PR の作成者（失敗はこちらに数える）
+	RevertedShipped time.Time                // 取り消された PR の出荷日時（分からなければゼロ値。復旧時間の起点）
+	IsReland        bool                     // 取り消された PR を再マージする PR
+	RelandOf        int                      // 再マージした元の PR 番号（分からなければ 0）
+	CommitTimes     []time.Time              // PR に含まれるコミットの作成日時（取得しない場合は nil）
+	CommitLeadTimes []time.Duration          // コミット単位のリードタイム（コミット単位で求めない場合は nil）
+	ClockSkewed     bool                     // 極端な未来・過去の日付のコミットを含む
+	LabelsChecked   bool                     // ラベルを集計する（--labels-report）
+	Labels          []string                 // PR のラベル
+	MissingLabels   []string                 // 満たしていない必須ラベルのグループ
+	Incidents       []IncidentAttribution    // 出荷したデプロイに帰属したインシデント（--incidents-file）
+	Sampled         bool                     // 抽出したリポジトリの PR（--max-prs）
+}
+
+func newAnalyzer(client *github.Client, tr *tracer, owner, from, to string) *analyzer {
+	return &analyzer{
+		client:      client,
+		tracer:      tr,
+		owner:       owner,
+		from:        from,
+		to:          to,
+		team:        &Stats{},
+		carryover:   &Stats{},
+		repos:       make(map[string]*Stats),
+		users:       make(map[string]*Stats),
+		mergeSHAs:   make(map[string]bool),
+		teamStats:   make(map[string]*Stats),
+		workers:     defaultPRWorkers,
+		repoWorkers: defaultRepoWorkers,
+		excludeBots: true,
+	}
+}
+
+// 集計期間（終了日はその日の終わりまで含む）
+func (a *analyzer) window() (time.Time, time.Time) {
+	from, _ := parseDay(a.from)
+	to, _ := parseDay(a.to)
+	return from, to.AddDate(0, 0, 1)
+}
+
+// リポジトリを並列に解析する。取得は並列でも、集計は repos の順に行うので結果は逐次のときと変わらない
+// （ミラー間の重複判定や onRecord の順序もリポジトリの順になる）
+// レート制限はクライアントを共有しているので、全リポジトリで同じ残り回数を使う
+func (a *analyzer) analyzeRepos(ctx context.Context, repos []string, progress io.Writer) {
+	sem := make(chan struct{}, max(a.repoWorkers, 1))
+	var turn chan struct{} // 前のリポジトリの集計が終わると閉じる（最初のリポジトリは nil ですぐ集計する）
+	var wg sync.WaitGroup
+	for i, repoName := range repos {
+		done := make(chan struct{})
+		// 枠は repos の順に取る（後のリポジトリが枠を埋めて前のリポジトリを待つと進まなくなる）
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, repoName string, turn <-chan struct{}) {
+			defer wg.Done()
+			defer close(done)
+			defer func() { <-sem }()
+			start := time.Now()
+			found := a.analyzeRepo(ctx, repoName, turn)
+			if progress != nil {
+				fmt.Fprintf(progress, "  ✅ [%d/%d] %s: %d merged PRs (%s)\n", i+1, len(repos), repoName, found, time.Since(start).Round(100*time.Millisecond))
+			}
+		}(i, repoName, turn)
+		turn = done
+	}
+	wg.Wait()
+}
+
+// turn が閉じるまで集計を待つ（nil ならすぐ集計する）。マージされた PR の件数を返す
+func (a *analyzer) analyzeRepo(ctx context.Context, repoName string, turn <-chan struct{}) int {
+	if f, project, ok := a.forgeFor(repoName); ok {
+		return a.analyzeForgeRepo(ctx, f, repoName, project, turn)
+	}
+	repoStats := &Stats{}
+	repoCtx, repoSpan := a.tracer.Start(ctx, "dora.repo", map[string]any{"dora.repo": repoName})
+	defer repoSpan.End()
+
+	var index *deployIndex
+	var deployments []deployment
+	envIndexes := make(map[string]*deployIndex)
+	if a.deploys != nil {
+		var err error
+		deployments, err = a.deploys.Deployments(repoCtx, repoName)
+		if err != nil {
+			log.Printf("⚠️  %s: failed to load deployments from %s: %s", repoName, a.deploys.Name(), describeAPIError(err, a.owner))
+			repoSpan.SetError(err)
+		}
+		index, envIndexes = a.deployStats(repoName, repoStats, deployments)
+	}
+	// --shadow-definitions: 同じ PR を別の定義でも集計する
+	shadowStats := &Stats{}
+	var shadowIndex *deployIndex
+	if a.shadow != nil && a.shadow.deploys != nil {
+		shadowDeployments, err := a.shadow.deploys.Deployments(repoCtx, repoName)
+		if err != nil {
+			log.Printf("⚠️  %s: failed to load shadow deployments from %s: %s", repoName, a.shadow.deploys.Name(), describeAPIError(err, a.owner))
+			repoSpan.SetError(err)
+		}
+		shadowIndex, _ = a.shadow.deployStats(repoName, shadowStats, shadowDeployments)
+	}
+
+	// PR 番号は検索結果のページ単位でワーカーに流し、全件をメモリに溜めない
+	// 取得は並列でも、集計は検索結果の順に行う（t-digest などの結果が実行ごとに変わらないように）
+	prChan := make(chan prJob, 100)
+	results := make(chan prDone, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < a.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range prChan {
+				results <- prDone{job.seq, a.processPR(repoCtx, repoName, index, envIndexes, shadowIndex, job.num)}
+				a.prefetch.remove(repoName, job.num)
+			}
+		}()
+	}
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		pending := make(map[int]*prOutcome)
+		next := 0
+		in := results
+		ready := turn == nil
+		// 順番が来るまでは取得した結果を溜めておく（取得は止めない）
+		for in != nil || !ready {
+			select {
+			case done, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				pending[done.seq] = done.outcome
+			case <-turn:
+				ready, turn = true, nil
+			}
+			for ready {
+				o, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				if o != nil {
+					a.recordPR(repoName, repoStats, o)
+					if o.shadow != nil {
+						a.shadow.record(shadowStats, o.mergeSHA, *o.shadow)
+					}
+				}
+			}
+		}
+	}()
+
+	var sample *reservoir
+	if a.maxPRs > 0 {
+		sample = newReservoir(a.maxPRs, time.Now().UnixNano())
+	}
+	query, queryErr := a.mergedQuery(repoCtx, repoName)
+	seq := 0
+	send := func(num int) {
+		prChan <- prJob{seq, num}
+		seq++
+	}
+	// メンバー・bot・ラベルで除く PR は抽出の前に除き、残った数を母集団にする（信頼区間の有限母集団修正に使う）
+	eligible := 0
+	// 取得済みの PR（--api graphql）は標本に入ったものだけを残し、外れたら捨てる
+	offer := func(user *github.User, labels []*github.Label, num int, p *prefetchedPR) {
+		if !a.prSelected(user, labels) {
+			return
+		}
+		eligible++
+		if sample == nil {
+			if p != nil {
+				a.prefetch.put(repoName, p)
+			}
+			send(num)
+			return
+		}
+		kept, evicted := sample.Offer(num)
+		if kept && p != nil {
+			a.prefetch.put(repoName, p)
+		}
+		if evicted != 0 {
+			a.prefetch.remove(repoName, evicted)
+		}
+	}
+	var found int
+	err := queryErr
+	switch {
+	case err != nil:
+		// 既定ブランチが分からなければ PR を数えない（すべてのブランチを数えるとデプロイ数が水増しされる）
+	case a.graphql:
+		found, err = streamMergedPRsGraphQL(repoCtx, a.client, query, a.from, a.to, func(p *prefetchedPR) {
+			offer(p.pr.GetUser(), p.pr.Labels, p.pr.GetNumber(), p)
+		})
+	default:
+		found, err = streamMergedPRs(repoCtx, a.client, query, a.from, a.to, func(issue *github.Issue) {
+			offer(issue.GetUser(), issue.Labels, issue.GetNumber(), nil)
+		})
+	}
+	if err != nil {
+		log.Printf("⚠️  %s: search failed: %s", repoName, describeAPIError(err, a.owner))
+		repoSpan.SetError(err)
+	}
+	if sample != nil {
+		// 母集団が上限を超えたリポジトリは、マージ日時も標本だけになる
+		repoStats.Sampled = eligible > a.maxPRs
+		shadowStats.Sampled = repoStats.Sampled
+		for _, num := range sample.Items() {
+			send(num)
+		}
+	}
+	repoSpan.SetAttr("dora.merged_prs", found)
+	close(prChan)
+	wg.Wait()
+	close(results)
+	a.prefetch.drop(repoName)
+
+	var funnel *funnelStats
+	if a.funnel {
+		funnel = a.repoFunnel(repoCtx, repoName)
+	}
+	<-collected
+	repoStats.Funnel = funnel
+
+	a.addRepo(repoName, repoStats, eligible)
+	if a.shadow != nil {
+		a.shadow.addRepo(repoName, shadowStats, eligible)
+	}
+	if a.onRepo != nil {
+		a.onRepo(snapshotRepo{Name: repoName, Population: eligible, Deployments: deployments})
+	}
+	return found
+}
+
+// デプロイのインデックスを作り、リポジトリ単位のデプロイ集計を repoStats に入れる
+func (a *analyzer) deployStats(repoName string, repoStats *Stats, deployments []deployment) (*deployIndex, map[string]*deployIndex) {
+	from, to := a.window()
+	index := newDeployIndex(a.client, a.owner, repoName, deployments)
+	envIndexes := make(map[string]*deployIndex)
+	for _, env := range index.Environments() {
+		envIndexes[env] = index.ForEnvironment(env)
+		repoStats.env(env).Deployments = envIndexes[env].CountBetween(from, to)
+		repoStats.env(env).Rollbacks = envIndexes[env].RollbacksBetween(from, to)
+	}
+	if a.env != "" {
+		index = index.ForEnvironments(a.env)
+	}
+	repoStats.Deployments = index.CountBetween(from, to)
+	repoStats.FailedDeployments = index.FailedBetween(from, to)
+	repoStats.Rollbacks = index.RollbacksBetween(from, to)
+	repoStats.DeployTracked = true
+	repoStats.ReleaseTypes = index.CountByReleaseType(from, to)
+	for _, d := range index.ok {
+		if !d.Time.Before(from) && d.Time.Before(to) {
+			repoStats.DeployTimes.add(d.Time)
+		}
+	}
+	if a.incidents != nil {
+		// 期間末のデプロイの直後に起きたインシデントも拾えるよう、期間で絞らずに渡す
+		var incidents []incident
+		for _, in := range a.incidents {
+			if in.matches(repoName) {
+				incidents = append(incidents, in)
+			}
+		}
+		index.AttributeIncidents(incidents, a.incidentWindow, a.incidentDeploys)
+		repoStats.IncidentDeploys, repoStats.IncidentFailures = index.IncidentFailuresBetween(from, to)
+		repoStats.IncidentsLinked = true
+	}
+	if a.hours != nil {
+		repoStats.AfterHoursDeploys, repoStats.WeekendDeploys = index.CountOffHours(from, to, a.hours)
+	}
+	return index, envIndexes
+}
+
+// リポジトリの集計を全体に加える
+func (a *analyzer) addRepo(repoName string, repoStats *Stats, found int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	repoStats.Population = found
+	if a.incidents != nil {
+		repoStats.addIncidents(a.incidentsFor(repoName))
+	}
+	a.team.Population += found
+	a.team.Deployments += repoStats.Deployments
+	a.team.FailedDeployments += repoStats.FailedDeployments
+	a.team.Rollbacks += repoStats.Rollbacks
+	a.team.IncidentDeploys += repoStats.IncidentDeploys
+	a.team.IncidentFailures += repoStats.IncidentFailures
+	if repoStats.Funnel != nil {
+		if a.team.Funnel == nil {
+			a.team.Funnel = &funnelStats{}
+		}
+		a.team.Funnel.add(repoStats.Funnel)
+	}
+	a.team.IncidentsLinked = a.team.IncidentsLinked || repoStats.IncidentsLinked
+	a.team.DeployTracked = a.team.DeployTracked || repoStats.DeployTracked
+	a.team.Sampled = a.team.Sampled || repoStats.Sampled
+	a.team.AfterHoursDeploys += repoStats.AfterHoursDeploys
+	a.team.WeekendDeploys += repoStats.WeekendDeploys
+	for env, es := range repoStats.Environments {
+		a.team.env(env).Deployments += es.Deployments
+		a.team.env(env).Rollbacks += es.Rollbacks
+	}
+	a.team.DeployTimes.merge(repoStats.DeployTimes)
+	for kind, n := range repoStats.ReleaseTypes {
+		if a.team.ReleaseTypes == nil {
+			a.team.ReleaseTypes = make(map[string]int)
+		}
+		a.team.ReleaseTypes[kind] += n
+	}
+	a.repos[repoName] = repoStats
+}
+
+// ワーカーに渡す PR と、検索結果での順番
+type prJob struct {
+	seq, num int
+}
+
+type prDone struct {
+	seq     int
+	outcome *prOutcome // 集計しない PR（取得失敗・メンバー外）は nil
+}
+
+// 1 PR 分の取得結果。集計（recordPR）は検索結果の順に行う
+type prOutcome struct {
+	result   prResult
+	title    string
+	url      string
+	mergeSHA string
+	rec      PRRecord  // export 用（onRecord が無ければ空）
+	shadow   *prResult // --shadow-definitions の定義での結果
+}
+
+func (a *analyzer) processPR(ctx context.Context, repoName string, index *deployIndex, envIndexes map[string]*deployIndex, shadowIndex *deployIndex, num int) *prOutcome {
+	prCtx, prSpan := a.tracer.Start(ctx, "dora.pr", map[string]any{"dora.repo": repoName, "dora.pr": num})
+	defer prSpan.End()
+
+	var pr *github.PullRequest
+	if p := a.prefetch.get(repoName, num); p != nil {
+		pr = p.pr
+	} else {
+		var err error
+		if pr, _, err = a.client.PullRequests.Get(prCtx, a.owner, repoName, num); err != nil {
+			prSpan.SetError(err)
+			return nil
+		}
+	}
+
+	author := a.aliases.canonical(pr.GetUser().GetLogin())
+	if !a.prSelected(pr.GetUser(), pr.Labels) {
+		return nil
+	}
+
+	var changeType string
+	if a.conventional || a.conventionalCFR {
+		changeType = a.changeType(prCtx, repoName, pr)
+	}
+	// 失敗判定の根拠（export 用）
+	r, reasons := a.classifyPR(repoName, index, pr, author, changeType)
+
+	if a.needsReviews() {
+		rs, err := a.fetchReviews(prCtx, repoName, pr)
+		if err != nil {
+			prSpan.SetError(err)
+		} else {
+			r.Reviews = &rs
+		}
+	}
+
+	// 元の PR → 取り消し → 再マージの連鎖をたどる
+	if target, ok := revertedPRNumber(pr); ok {
+		// 本文に PR 番号が無ければ、取り消したコミットから元の PR を探す
+		if sha := revertedCommitSHA(pr); target == 0 && sha != "" {
+			n, err := a.prForCommit(prCtx, repoName, sha)
+			if err != nil {
+				prSpan.SetError(err)
+			}
+			target = n
+		}
+		info, err := a.inspectRevert(prCtx, repoName, index, pr, target)
+		if err != nil {
+			prSpan.SetError(err)
+		}
+		if info.Reland {
+			r.IsReland, r.RelandOf = true, info.Original
+		} else {
+			r.IsRevert, r.Reverts, r.RevertedAuthor, r.RevertedShipped = true, target, info.Author, info.Shipped
+			if info.Quick {
+				r.RevertedBy = info.Author
+			}
+		}
+	} else if orig, ok := relandedPRNumber(pr); ok {
+		r.IsReland, r.RelandOf = true, orig
+	}
+	// 再マージは元の変更を出し直すだけなので、明示的なマーカーが無ければ失敗に数えない
+	if r.IsReland && !slices.Contains(reasons, "marker") {
+		r.IsFix = false
+		reasons = nil
+	}
+
+	// デプロイソースがある場合、リードタイムは PR 作成から最初のデプロイまで
+	var deployedAt *time.Time
+	if index != nil {
+		d, err := index.FirstContaining(prCtx, pr.GetMergeCommitSHA(), pr.GetMergedAt().Time)
+		if err != nil {
+			prSpan.SetError(err)
+		}
+		if d != nil {
+			r.LeadTime = d.Time.Sub(pr.GetCreatedAt().Time)
+			deployedAt = &d.Time
+			r.Incidents = index.IncidentsFor(d)
+		} else {
+			r.HasLeadTime = false
+		}
+	}
+
+	// 環境ごとのマージ→デプロイの遅延（複数環境を追跡している場合）
+	if len(envIndexes) > 0 {
+		r.DeployLags = make(map[string]time.Duration)
+		for env, idx := range envIndexes {
+			d, err := idx.FirstContaining(prCtx, pr.GetMergeCommitSHA(), pr.GetMergedAt().Time)
+			if err != nil {
+				prSpan.SetError(err)
+			}
+			if d != nil {
+				r.DeployLags[env] = d.Time.Sub(pr.GetMergedAt().Time)
+			}
+		}
+	}
+
+	// コミット単位のリードタイムと export 用に、PR のコミットの作成日時を取る
+	// 新メンバーの PR は最初のコミットを立ち上がりの始点にする
+	_, newMember := a.newMembers[author]
+	if a.commitLeadTime || a.onRecord != nil || newMember {
+		times, err := a.fetchCommitTimes(prCtx, repoName, num)
+		if err != nil {
+			prSpan.SetError(err)
+		}
+		r.CommitTimes = times
+	}
+
+	o := &prOutcome{result: r, title: pr.GetTitle(), url: pr.GetHTMLURL(), mergeSHA: pr.GetMergeCommitSHA()}
+	if a.shadow != nil {
+		sr := a.shadow.shadowResult(prCtx, repoName, shadowIndex, pr, author, changeType, r)
+		o.shadow = &sr
+	}
+	if a.onRecord != nil {
+		o.rec = a.prRecord(prCtx, repoName, pr, r, deployedAt, reasons)
+	}
+	return o
+}
+
+// API を呼ばずに PR の内容だけで決まる判定（失敗・変更種別・営業時間外・衛生スコア・ラベル）
+// changeType は Conventional Commits の種別（分類しない場合は空）。失敗判定の根拠も返す
+func (a *analyzer) classifyPR(repoName string, index *deployIndex, pr *github.PullRequest, author, changeType string) (prResult, []string) {
+	var reasons []string
+	r := prResult{
+		Repo:      repoName,
+		Number:    pr.GetNumber(),
+		Title:     pr.GetTitle(),
+		URL:       pr.GetHTMLURL(),
+		Author:    author,
+		CreatedAt: pr.GetCreatedAt().Time,
+		MergedAt:  pr.GetMergedAt().Time,
+		// Bug判定（タイトル、ラベル、ブランチ、セキュリティパッチ含む）
+		IsFix:       isBugFix(pr),
+		LeadTime:    pr.GetMergedAt().Sub(pr.GetCreatedAt().Time),
+		HasLeadTime: true,
+		Additions:   pr.GetAdditions(),
+		Deletions:   pr.GetDeletions(),
+		SelfMerged:  a.aliases.canonical(pr.GetMergedBy().GetLogin()) == author,
+		Hygiene:     -1,
+	}
+
+	if r.IsFix {
+		reasons = append(reasons, "keyword")
+	}
+
+	r.ChangeType = changeType
+	if a.conventionalCFR {
+		r.IsFix = r.ChangeType == "fix" || r.ChangeType == "revert"
+		// 直近のリリースを直す fix だけを失敗とみなす
+		if r.IsFix && index != nil {
+			r.IsFix = index.DeployedWithin(pr.GetMergedAt().Add(-a.fixWindow), pr.GetMergedAt().Time)
+		}
+		reasons = nil
+		if r.IsFix {
+			reasons = append(reasons, "conventional")
+		}
+	}
+
+	if len(a.failureMarkers) > 0 {
+		marked := hasFailureMarker(pr, a.failureMarkers)
+		if a.markersOnly {
+			r.IsFix = marked
+			reasons = nil
+		} else {
+			r.IsFix = r.IsFix || marked
+		}
+		if marked {
+			reasons = append(reasons, "marker")
+		}
+	}
+
+	if a.hours != nil {
+		r.AfterHours, r.Weekend = a.hours.classify(pr.GetMergedAt().Time)
+	}
+
+	if a.hygiene {
+		r.Hygiene = hygieneScore(pr, a.hygieneMaxLines)
+	}
+
+	if a.labelReport {
+		r.LabelsChecked = true
+		for _, l := range pr.Labels {
+			r.Labels = append(r.Labels, l.GetName())
+		}
+		r.MissingLabels = missingLabels(r.Labels, a.requiredLabels)
+	}
+	return r, reasons
+}
+
+func (a *analyzer) recordPR(repoName string, repoStats *Stats, o *prOutcome) {
+	a.checkReviewSLA(repoName, o.title, o.url, o.result)
+	duplicate := a.record(repoStats, o.mergeSHA, o.result)
+	if a.onRecord != nil {
+		o.rec.Duplicate = duplicate
+		a.mu.Lock()
+		a.onRecord(o.rec)
+		a.mu.Unlock()
+	}
+}
+
+// PR ごとにレビューを取得する指標があるか
+func (a *analyzer) needsReviews() bool {
+	return a.governance || a.hygiene || a.reviewSLA > 0 || a.reviewMatrix || len(a.newMembers) > 0
+}
+
+func (a *analyzer) leadTimeDefinition() string {
+	end := "merge"
+	if a.deploys != nil {
+		end = "first deployment"
+	}
+	desc := "PR opened → " + end
+	if a.commitLeadTime {
+		desc = "each commit authored → " + end + " (PRs without commit data count once)"
+	}
+	if leadTimeWeighted {
+		desc += ", weighted by lines changed"
+	}
+	if workingHours != nil {
+		desc += ", business hours only (" + workingHours.String() + ")"
+	}
+	return desc
+}
+
+// PR のコミットの作成日時（API の上限で最大 250 件）
+func (a *analyzer) fetchCommitTimes(ctx context.Context, repoName string, num int) ([]time.Time, error) {
+	if p := a.prefetch.get(repoName, num); p != nil && p.commitTimes != nil {
+		return p.commitTimes, nil
+	}
+	var times []time.Time
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		commits, resp, err := a.client.PullRequests.ListCommits(ctx, a.owner, repoName, num, opts)
+		if err != nil {
+			return times, err
+		}
+		for _, c := range commits {
+			times = append(times, c.GetCommit().GetAuthor().GetDate().Time)
+		}
+		if resp.NextPage == 0 {
+			return times, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// コミットごとの作成→デプロイ（デプロイソースが無ければマージ）の時間。
+// リベースなどで作成日時が PR より新しく見えるコミットは 0 とする
+func commitLeadTimes(times []time.Time, end time.Time) []time.Duration {
+	lts := make([]time.Duration, 0, len(times))
+	for _, t := range times {
+		lts = append(lts, max(end.Sub(t), 0))
+	}
+	return lts
+}
+
+// PR のリードタイム（--business-hours を指定した場合は営業時間だけ）
+func (r prResult) leadTime() time.Duration {
+	if workingHours == nil {
+		return r.LeadTime
+	}
+	return workingHours.Duration(r.CreatedAt, r.CreatedAt.Add(r.LeadTime))
+}
+
+// 集計に使うリードタイム（コミット単位ならコミットごと）
+func (r prResult) leadTimeSamples() []time.Duration {
+	if len(r.CommitLeadTimes) == 0 {
+		return []time.Duration{r.leadTime()}
+	}
+	if workingHours == nil {
+		return r.CommitLeadTimes
+	}
+	end := r.CreatedAt.Add(r.LeadTime)
+	lts := make([]time.Duration, 0, len(r.CommitLeadTimes))
+	for _, lt := range r.CommitLeadTimes {
+		lts = append(lts, workingHours.Duration(end.Add(-lt), end))
+	}
+	return lts
+}
+
+// PR タイトル、無ければスカッシュコミットのメッセージから変更種別を求める
+func (a *analyzer) changeType(ctx context.Context, repoName string, pr *github.PullRequest) string {
+	if t := conventionalType(pr.GetTitle()); t != "" {
+		return t
+	}
+	if p := a.prefetch.get(repoName, pr.GetNumber()); p != nil && p.commitMessage != "" {
+		subject, _, _ := strings.Cut(p.commitMessage, "\n")
+		if t := conventionalType(subject); t != "" {
+			return t
+		}
+		return "other"
+	}
+	if sha := pr.GetMergeCommitSHA(); sha != "" {
+		commit, _, err := a.client.Git.GetCommit(ctx, a.owner, repoName, sha)
+		if err == nil {
+			subject, _, _ := strings.Cut(commit.GetMessage(), "\n")
+			if t := conventionalType(subject); t != "" {
+				return t
+			}
+		}
+	}
+	return "other"
+}
+
+// 集計に加える。他リポジトリと重複したマージコミットの場合は true を返す
+func (a *analyzer) record(repoStats *Stats, mergeSHA string, r prResult) bool {
+	// コミット単位ではリードタイムの終点（デプロイまたはマージ）を各コミットに当てる
+	// 時計のずれたコミットは除く（すべて除かれた PR は PR 単位の 1 件として数える）
+	if a.commitLeadTime && r.HasLeadTime && len(r.CommitTimes) > 0 {
+		end := r.CreatedAt.Add(r.LeadTime)
+		times, skewed := a.sanitizeCommitTimes(r.CommitTimes, r.CreatedAt, end)
+		r.ClockSkewed = skewed
+		r.CommitLeadTimes = commitLeadTimes(times, end)
+	}
+	r.Sampled = repoStats.Sampled
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.isCarryover(r) {
+		update(a.carryover, r)
+		if a.carryoverMode == carryoverExclude || a.carryoverMode == carryoverSeparate {
+			return false
+		}
+	}
+	update(repoStats, r)
+	if r.RevertedBy != "" {
+		repoStats.QuickReverts++
+	}
+	// 同じマージコミットが複数リポジトリにある場合、全体集計では 1 回だけ数える
+	if mergeSHA != "" && a.mergeSHAs[mergeSHA] {
+		a.duplicates++
+		return true
+	}
+	if mergeSHA != "" {
+		a.mergeSHAs[mergeSHA] = true
+	}
+	if a.users[r.Author] == nil {
+		a.users[r.Author] = &Stats{}
+	}
+	update(a.team, r)
+	a.addLinks(r)
+	// 取り消しが失敗に数えられる場合、失敗は取り消した側ではなく元の変更（作成者・チーム）に数える
+	own := r
+	moved := r.IsRevert && r.IsFix && r.RevertedAuthor != "" && r.RevertedAuthor != r.Author
+	if moved {
+		own.IsFix = false
+	}
+	update(a.users[r.Author], own)
+	if a.reviewMatrix {
+		a.addReviewPairs(r)
+	}
+	a.addOnboarding(r)
+	for _, t := range a.membership[r.Author] {
+		if a.teamStats[t] == nil {
+			a.teamStats[t] = &Stats{}
+		}
+		update(a.teamStats[t], own)
+	}
+	if moved {
+		if s := a.users[r.RevertedAuthor]; s != nil {
+			s.BugFixPRs++
+		}
+		for _, t := range a.membership[r.RevertedAuthor] {
+			if a.teamStats[t] == nil {
+				a.teamStats[t] = &Stats{}
+			}
+			a.teamStats[t].BugFixPRs++
+		}
+	}
+	// 即時の取り消しは取り消された側（元の PR の作成者・チーム）に数える
+	if r.RevertedBy != "" {
+		a.team.QuickReverts++
+		if s := a.users[r.RevertedBy]; s != nil {
+			s.QuickReverts++
+		}
+		for _, t := range a.membership[r.RevertedBy] {
+			if a.teamStats[t] == nil {
+				a.teamStats[t] = &Stats{}
+			}
+			a.teamStats[t].QuickReverts++
+		}
+	}
+	return false
+}
+
+// リポジトリごとに PR を並列で処理するワーカー数（--concurrency の既定値）
+const defaultPRWorkers = 10
+
+// 並列に解析するリポジトリの数（--repo-concurrency の既定値）。同時リクエストは最大でこれ × ワーカー数
+const defaultRepoWorkers = 4
+
+// GitHub の検索 API は 1 クエリあたり最大 1000 件までしか返さないため、
+// 件数が多い場合はマージ日の範囲を半分に分割して取得する
+const searchResultLimit = 1000
+
+func streamMergedPRs(ctx context.Context, client *github.Client, baseQuery, from, to string, fn func(*github.Issue)) (int, error) {
+	return streamPRs(ctx, client, baseQuery, "merged", from, to, fn)
+}
+
+// qualifier（merged / created）の日付範囲で検索する
+func streamPRs(ctx context.Context, client *github.Client, baseQuery, qualifier, from, to string, fn func(*github.Issue)) (int, error) {
+	query := fmt.Sprintf("%s %s:%s", baseQuery, qualifier, searchRange(from, to))
+	opts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	result, resp, err := client.Search.Issues(ctx, query, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.GetTotal() > searchResultLimit {
+		start, err1 := time.Parse("2006-01-02", from)
+		end, err2 := time.Parse("2006-01-02", to)
+		if err1 == nil && err2 == nil && end.After(start) {
+			mid := start.Add(end.Sub(start) / 2).Truncate(24 * time.Hour)
+			n1, err := streamPRs(ctx, client, baseQuery, qualifier, from, mid.Format("2006-01-02"), fn)
+			if err != nil {
+				return n1, err
+			}
+			n2, err := streamPRs(ctx, client, baseQuery, qualifier, mid.AddDate(0, 0, 1).Format("2006-01-02"), to, fn)
+			return n1 + n2, err
+		}
+		log.Printf("⚠️  More than %d PRs %s on %s; only the first %d are analyzed", searchResultLimit, qualifier, from, searchResultLimit)
+	}
+
+	found := 0
+	for {
+		for _, issue := range result.Issues {
+			fn(issue)
+			found++
+		}
+		if resp.NextPage == 0 {
+			return found, nil
+		}
+		opts.Page = resp.NextPage
+		result, resp, err = client.Search.Issues(ctx, query, opts)
+		if err != nil {
+			return found, err
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// GitHub Enterprise Cloud の組織の監査ログ（workflows.completed_workflow_run）からデプロイを求める
+// Deployments API を使っていないリポジトリでも、手動実行（workflow_dispatch）や環境を指定したジョブのある実行がデプロイとして残る
+// workflow を指定した場合はその名前の実行だけをデプロイとみなす
+// 監査ログは組織全体で 1 回だけ読み、リポジトリごとに分ける
+type auditLogSource struct {
+	client        *github.Client
+	owner         string
+	workflow      string
+	countFailures bool
+	from          time.Time
+
+	once   sync.Once
+	byRepo map[string][]deployment
+	err    error
+}
+
+func newAuditLogSource(client *github.Client, owner, workflow string, countFailures bool, from time.Time) *auditLogSource {
+	return &auditLogSource{client: client, owner: owner, workflow: workflow, countFailures: countFailures, from: from}
+}
+
+func (s *auditLogSource) Name() string {
+	return "audit-log"
+}
+
+func (s *auditLogSource) Deployments(ctx context.Context, repo string) ([]deployment, error) {
+	s.once.Do(func() { s.err = s.load(ctx) })
+	if s.err != nil {
+		return nil, s.err
+	}
+	return append([]deployment(nil), s.byRepo[repo]...), nil
+}
+
+func (s *auditLogSource) load(ctx context.Context) error {
+	s.byRepo = make(map[string][]deployment)
+	// ロールバック判定の履歴として、期間の 30 日前から読む
+	since := s.from.AddDate(0, 0, -30).Format("2006-01-02")
+	opts := &github.GetAuditLogOptions{
+		Phrase:            github.String("action:workflows.completed_workflow_run created:>=" + since),
+		ListCursorOptions: github.ListCursorOptions{PerPage: 100},
+	}
+	for {
+		entries, resp, err := s.client.Organizations.GetAuditLog(ctx, s.owner, opts)
+		if err != nil {
+			return fmt.Errorf("audit log for %s (GitHub Enterprise Cloud and the read:audit_log scope are required): %w", s.owner, err)
+		}
+		for _, e := range entries {
+			repo, d, ok := s.deployment(e)
+			if ok {
+				s.byRepo[repo] = append(s.byRepo[repo], d)
+			}
+		}
+		if resp.After == "" {
+			break
+		}
+		opts.After = resp.After
+	}
+	for _, deployments := range s.byRepo {
+		markRollbacks(deployments)
+	}
+	return nil
+}
+
+// 監査ログの 1 件をデプロイに直す。デプロイでない実行は ok=false
+func (s *auditLogSource) deployment(e *github.AuditEntry) (repo string, d deployment, ok bool) {
+	owner, repo, found := strings.Cut(auditField(e, "repo"), "/")
+	if !found || !strings.EqualFold(owner, s.owner) {
+		return "", deployment{}, false
+	}
+	env := auditField(e, "environment_name")
+	if env == "" {
+		env = auditField(e, "environment")
+	}
+	if s.workflow != "" {
+		if name := auditField(e, "name"); name != s.workflow && !strings.HasSuffix(auditField(e, "workflow_path"), "/"+s.workflow) {
+			return "", deployment{}, false
+		}
+	} else if auditField(e, "event") != "workflow_dispatch" && env == "" {
+		return "", deployment{}, false
+	}
+
+	d = deployment{SHA: auditField(e, "head_sha"), Ref: auditField(e, "head_branch"), Environment: env, Time: e.GetCreatedAt().Time}
+	if t, err := time.Parse(time.RFC3339, auditField(e, "completed_at")); err == nil {
+		d.Time = t
+	} else if d.Time.IsZero() {
+		d.Time = e.GetTimestamp().Time
+	}
+	switch auditField(e, "conclusion") {
+	case "success":
+		return repo, d, true
+	case "failure", "timed_out":
+		d.Failed = true
+		return repo, d, s.countFailures
+	}
+	return "", deployment{}, false
+}
+
+// 監査ログの項目は操作ごとに違い、go-github の構造体に無いものは AdditionalFields（古い形式では data）に入る
+func auditField(e *github.AuditEntry, key string) string {
+	for _, m := range []map[string]interface{}{e.AdditionalFields, e.Data} {
+		if v, ok := m[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,393 @@
+package dora
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// Bitbucket Cloud の REST API 2.0。リポジトリは "bitbucket:workspace/repo_slug"
+// PR にマージ日時が無いので、アクティビティの MERGED の更新から求める（アクティビティはレビューにも使う）
+type bitbucketForge struct {
+	baseURL  string // https://api.bitbucket.org/2.0
+	token    string // リポジトリ・ワークスペースのアクセストークン
+	username string // トークンが無ければユーザー名とアプリパスワード
+	password string
+	client   *http.Client
+
+	mu       sync.Mutex
+	activity map[string][]bitbucketActivity // "workspace/repo#id" -> アクティビティ
+}
+
+func newBitbucketForge(baseURL, token, username, password string, client *http.Client) *bitbucketForge {
+	return &bitbucketForge{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		username: username,
+		password: password,
+		client:   client,
+		activity: make(map[string][]bitbucketActivity),
+	}
+}
+
+func (b *bitbucketForge) Name() string { return "bitbucket" }
+
+type bitbucketUser struct {
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+}
+
+// ユーザー名は公開されなくなったので nickname をログイン名として使う
+func (u *bitbucketUser) login() string {
+	if u == nil {
+		return ""
+	}
+	if u.Nickname != "" {
+		return u.Nickname
+	}
+	return u.DisplayName
+}
+
+type bitbucketPR struct {
+	ID          int            `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Author      bitbucketUser  `json:"author"`
+	ClosedBy    *bitbucketUser `json:"closed_by"`
+	CreatedOn   time.Time      `json:"created_on"`
+	UpdatedOn   time.Time      `json:"updated_on"`
+	MergeCommit *struct {
+		Hash string `json:"hash"`
+	} `json:"merge_commit"`
+	Source struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"source"`
+	Destination struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"destination"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+	Reviewers []bitbucketUser `json:"reviewers"`
+}
+
+type bitbucketActivity struct {
+	Update *struct {
+		State string    `json:"state"`
+		Date  time.Time `json:"date"`
+	} `json:"update"`
+	Approval *struct {
+		Date time.Time     `json:"date"`
+		User bitbucketUser `json:"user"`
+	} `json:"approval"`
+	Comment *struct {
+		CreatedOn time.Time     `json:"created_on"`
+		User      bitbucketUser `json:"user"`
+	} `json:"comment"`
+}
+
+type bitbucketDeployment struct {
+	State struct {
+		Name   string `json:"name"`
+		Status struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		CompletedOn *time.Time `json:"completed_on"`
+	} `json:"state"`
+	Environment struct {
+		UUID string `json:"uuid"`
+	} `json:"environment"`
+	Release struct {
+		Name   string `json:"name"`
+		Commit struct {
+			Hash string `json:"hash"`
+		} `json:"commit"`
+	} `json:"release"`
+}
+
+// 一覧を next がなくなるまで読み、ページの values ごとに fn に渡す。fn が false を返したら打ち切る
+func (b *bitbucketForge) paginate(ctx context.Context, path string, query url.Values, fn func(values json.RawMessage) (bool, error)) error {
+	query.Set("pagelen", "50")
+	next := b.baseURL + path + "?" + query.Encode()
+	for next != "" {
+		body, err := b.get(ctx, path, next)
+		if err != nil {
+			return err
+		}
+		var page struct {
+			Values json.RawMessage `json:"values"`
+			Next   string          `json:"next"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		more, err := fn(page.Values)
+		if err != nil || !more {
+			return err
+		}
+		next = page.Next
+	}
+	return nil
+}
+
+// rawURL を GET する（path はエラーの表示用）
+func (b *bitbucketForge) get(ctx context.Context, path, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	} else if b.username != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bitbucket: GET %s: %s", path, resp.Status)
+	}
+	return body, nil
+}
+
+func (b *bitbucketForge) DefaultBranch(ctx context.Context, repo string) (string, error) {
+	body, err := b.get(ctx, bitbucketRepoPath(repo), b.baseURL+bitbucketRepoPath(repo))
+	if err != nil {
+		return "", err
+	}
+	var r struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", err
+	}
+	return r.MainBranch.Name, nil
+}
+
+func bitbucketRepoPath(repo string) string {
+	workspace, slug, _ := strings.Cut(repo, "/")
+	return "/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(slug)
+}
+
+// マージすると更新日時も変わるので、更新日時の新しい順に from まで読み、アクティビティでマージ日時を確かめる
+func (b *bitbucketForge) MergedPRs(ctx context.Context, repo, branch string, from, to time.Time, fn func(*github.PullRequest)) (int, error) {
+	filter := fmt.Sprintf("updated_on >= %s", from.UTC().Format(time.RFC3339))
+	if branch != "" {
+		filter += fmt.Sprintf(" AND destination.branch.name = %q", branch)
+	}
+	q := url.Values{
+		"state": {"MERGED"},
+		"q":     {filter},
+		"sort":  {"-updated_on"},
+	}
+	var candidates []bitbucketPR
+	err := b.paginate(ctx, bitbucketRepoPath(repo)+"/pullrequests", q, func(values json.RawMessage) (bool, error) {
+		var prs []bitbucketPR
+		if err := json.Unmarshal(values, &prs); err != nil {
+			return false, err
+		}
+		for _, pr := range prs {
+			if pr.UpdatedOn.Before(from) {
+				return false, nil
+			}
+			candidates = append(candidates, pr)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, pr := range candidates {
+		activity, err := b.loadActivity(ctx, repo, pr.ID)
+		if err != nil {
+			return n, err
+		}
+		mergedAt := pr.UpdatedOn
+		for _, act := range activity {
+			if act.Update != nil && act.Update.State == "MERGED" {
+				mergedAt = act.Update.Date
+			}
+		}
+		if mergedAt.Before(from) || !mergedAt.Before(to) {
+			continue
+		}
+		n++
+		fn(pr.pullRequest(mergedAt))
+	}
+	return n, nil
+}
+
+// 失敗判定などの規則をそのまま使えるよう、GitHub の PR の形に直す
+func (pr bitbucketPR) pullRequest(mergedAt time.Time) *github.PullRequest {
+	out := &github.PullRequest{
+		Number:    github.Int(pr.ID),
+		Title:     github.String(pr.Title),
+		Body:      github.String(pr.Description),
+		User:      &github.User{Login: github.String(pr.Author.login())},
+		CreatedAt: &github.Timestamp{Time: pr.CreatedOn},
+		MergedAt:  &github.Timestamp{Time: mergedAt},
+		Head:      &github.PullRequestBranch{Ref: github.String(pr.Source.Branch.Name)},
+		Base:      &github.PullRequestBranch{Ref: github.String(pr.Destination.Branch.Name)},
+		HTMLURL:   github.String(pr.Links.HTML.Href),
+	}
+	if pr.MergeCommit != nil {
+		out.MergeCommitSHA = github.String(pr.MergeCommit.Hash)
+	}
+	if pr.ClosedBy != nil {
+		out.MergedBy = &github.User{Login: github.String(pr.ClosedBy.login())}
+	}
+	for _, r := range pr.Reviewers {
+		out.RequestedReviewers = append(out.RequestedReviewers, &github.User{Login: github.String(r.login())})
+	}
+	return out
+}
+
+// アクティビティは MergedPRs と Reviews の両方で使うので、1 回だけ取得する
+func (b *bitbucketForge) loadActivity(ctx context.Context, repo string, id int) ([]bitbucketActivity, error) {
+	key := fmt.Sprintf("%s#%d", repo, id)
+	b.mu.Lock()
+	cached, ok := b.activity[key]
+	b.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+	var activity []bitbucketActivity
+	path := fmt.Sprintf("%s/pullrequests/%d/activity", bitbucketRepoPath(repo), id)
+	err := b.paginate(ctx, path, url.Values{}, func(values json.RawMessage) (bool, error) {
+		var page []bitbucketActivity
+		if err := json.Unmarshal(values, &page); err != nil {
+			return false, err
+		}
+		activity = append(activity, page...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	b.activity[key] = activity
+	b.mu.Unlock()
+	return activity, nil
+}
+
+// 承認は APPROVED、作成者以外のコメントは COMMENTED（アクティビティは新しい順なので古い順に並べ直す）
+func (b *bitbucketForge) Reviews(ctx context.Context, repo string, pr *github.PullRequest) ([]*github.PullRequestReview, error) {
+	activity, err := b.loadActivity(ctx, repo, pr.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+	var out []*github.PullRequestReview
+	for i := len(activity) - 1; i >= 0; i-- {
+		act := activity[i]
+		var user *bitbucketUser
+		var at time.Time
+		state := "COMMENTED"
+		switch {
+		case act.Approval != nil:
+			user, at, state = &act.Approval.User, act.Approval.Date, "APPROVED"
+		case act.Comment != nil:
+			user, at = &act.Comment.User, act.Comment.CreatedOn
+		default:
+			continue
+		}
+		out = append(out, &github.PullRequestReview{
+			User:        &github.User{Login: github.String(user.login())},
+			State:       github.String(state),
+			SubmittedAt: &github.Timestamp{Time: at},
+		})
+	}
+	dropReviewed(pr, out)
+	return out, nil
+}
+
+func (b *bitbucketForge) Commits(ctx context.Context, repo string, pr *github.PullRequest) ([]time.Time, error) {
+	var times []time.Time
+	path := fmt.Sprintf("%s/pullrequests/%d/commits", bitbucketRepoPath(repo), pr.GetNumber())
+	err := b.paginate(ctx, path, url.Values{}, func(values json.RawMessage) (bool, error) {
+		var commits []struct {
+			Date time.Time `json:"date"`
+		}
+		if err := json.Unmarshal(values, &commits); err != nil {
+			return false, err
+		}
+		for _, c := range commits {
+			times = append(times, c.Date)
+		}
+		return true, nil
+	})
+	return times, err
+}
+
+// Bitbucket Pipelines のデプロイ。出荷した PR は分からないので PRs は nil（マージ後最初の成功デプロイを当てる）
+func (b *bitbucketForge) Deployments(ctx context.Context, repo string, from time.Time) ([]forgeDeployment, error) {
+	envs := make(map[string]string) // UUID -> 環境名
+	err := b.paginate(ctx, bitbucketRepoPath(repo)+"/environments/", url.Values{}, func(values json.RawMessage) (bool, error) {
+		var page []struct {
+			UUID string `json:"uuid"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(values, &page); err != nil {
+			return false, err
+		}
+		for _, e := range page {
+			envs[e.UUID] = e.Name
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var deployments []deployment
+	err = b.paginate(ctx, bitbucketRepoPath(repo)+"/deployments/", url.Values{}, func(values json.RawMessage) (bool, error) {
+		var page []bitbucketDeployment
+		if err := json.Unmarshal(values, &page); err != nil {
+			return false, err
+		}
+		for _, dep := range page {
+			status := dep.State.Status.Name
+			if dep.State.Name != "COMPLETED" || dep.State.CompletedOn == nil || dep.State.CompletedOn.Before(from) {
+				continue
+			}
+			if status != "SUCCESSFUL" && status != "FAILED" {
+				continue
+			}
+			deployments = append(deployments, deployment{
+				SHA:         dep.Release.Commit.Hash,
+				Time:        *dep.State.CompletedOn,
+				Environment: envs[dep.Environment.UUID],
+				Failed:      status == "FAILED",
+				Ref:         dep.Release.Name,
+			})
+		}
+		return true, nil
+	})
+	markRollbacks(deployments)
+	out := make([]forgeDeployment, len(deployments))
+	for i, d := range deployments {
+		out[i] = forgeDeployment{deployment: d}
+	}
+	return out, err
+}
@@ -0,0 +1,53 @@
+package dora
+
+import (
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// "[bot]" の付かない bot のアカウント（GitHub App 以前のものや、他のサービスの個人アカウント）
+var knownBotLogins = map[string]bool{
+	"dependabot":         true,
+	"dependabot-preview": true,
+	"renovate":           true,
+	"renovate-bot":       true,
+	"github-actions":     true,
+	"greenkeeper":        true,
+	"snyk-bot":           true,
+	"pre-commit-ci":      true,
+	"mergify":            true,
+	"imgbot":             true,
+}
+
+// GitHub App のアカウント（dependabot[bot]、github-actions[bot] など）と既知の bot
+func isBotLogin(login string) bool {
+	login = strings.ToLower(login)
+	return strings.HasSuffix(login, "[bot]") || knownBotLogins[login]
+}
+
+// PR の作成者・レビュアーとして数えないアカウント（--exclude-bots・--exclude-users）
+// 別名解決の前後どちらの名前でも照合する
+func (a *analyzer) excludedUser(login string) bool {
+	if login == "" {
+		return false
+	}
+	if a.excludeBots && isBotLogin(login) {
+		return true
+	}
+	return a.excludeUsers[strings.ToLower(login)] || a.excludeUsers[strings.ToLower(a.aliases.canonical(login))]
+}
+
+// excludedUser に加えて、GitHub が Bot 型と返すアカウント（knownBotLogins に無い App も含む）を除く
+// REST は user.type、GraphQL は author.__typename から Type を埋める
+func (a *analyzer) excludedAccount(u *github.User) bool {
+	return a.excludedUser(u.GetLogin()) || (a.excludeBots && u.GetType() == "Bot")
+}
+
+func parseExcludeUsers(list string) map[string]bool {
+	users := make(map[string]bool)
+	for _, u := range splitList(list) {
+		users[strings.ToLower(u)] = true
+	}
+	return users
+}
@@ -0,0 +1,67 @@
+package dora
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 期間内にマージされたが、期間の開始よりかなり前に作成された PR（持ち越し）の扱い
+// 長く寝かされた PR が月の境目でどちらに入るかでリードタイムが大きく揺れるため、
+// include（従来どおり数える）、exclude（数えない）、separate（別枠で集計する）から選ぶ
+const (
+	carryoverInclude  = "include"
+	carryoverExclude  = "exclude"
+	carryoverSeparate = "separate"
+)
+
+// 期間の開始から carryoverAge 以上前に作成された PR
+func (a *analyzer) isCarryover(r prResult) bool {
+	from, _ := a.window()
+	return r.CreatedAt.Before(from.Add(-a.carryoverAge))
+}
+
+// 持ち越しの基準日（これより前に作成された PR が持ち越し）
+func (a *analyzer) carryoverCutoff() string {
+	from, _ := a.window()
+	return from.Add(-a.carryoverAge).Format("2006-01-02")
+}
+
+func printCarryoverSummary(a *analyzer) {
+	n := a.carryover.TotalPRs
+	if n == 0 {
+		return
+	}
+	switch a.carryoverMode {
+	case carryoverSeparate:
+	case carryoverExclude:
+		fmt.Printf("ℹ️  %d PR(s) opened before %s were excluded (--carryover exclude)\n", n, a.carryoverCutoff())
+		return
+	default:
+		fmt.Printf("ℹ️  %d PR(s) opened before %s are included; use --carryover separate to report them apart\n", n, a.carryoverCutoff())
+		return
+	}
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🧳 Carried-over PRs (opened before %s, merged in the period)\n%s\n", line, a.carryoverCutoff(), line)
+	fmt.Printf("%-25s | %-8s | %-10s | %-10s | %-10s | %-10s | %-10s | %-10s\n", "ENTITY", "PRs", "AvgLT", "MedianLT", "P90LT", "CFR", "MTTR", "AvgSize")
+	printRow("CARRIED OVER", a.carryover, true)
+}
+
+// 持ち越しの PR 数と、separate の場合はその集計（JSON 用）
+type carryoverSummary struct {
+	Mode   string        `json:"mode"`
+	Cutoff string        `json:"opened_before"`
+	Stats  *statsSummary `json:"stats,omitempty"`
+	PRs    int           `json:"prs"`
+}
+
+func (a *analyzer) summarizeCarryover() *carryoverSummary {
+	if a.carryover.TotalPRs == 0 {
+		return nil
+	}
+	out := &carryoverSummary{Mode: a.carryoverMode, Cutoff: a.carryoverCutoff(), PRs: a.carryover.TotalPRs}
+	if a.carryoverMode == carryoverSeparate {
+		s := summarizeStats(a.carryover, a.from, a.to, a.deploys == nil)
+		out.Stats = &s
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+)
+
+// --deploy-branch でこの値を指定すると、ブランチを問わずマージ済み PR を数える
+const anyBranch = "*"
+
+// PR を数えるブランチ（--deploy-branch、無ければリポジトリの既定ブランチ）。空ならブランチで絞らない
+// 既定ブランチはリポジトリごとに 1 回だけ引く。引けなければエラー（すべてのブランチを数えるとデプロイ数が水増しされる）
+func (a *analyzer) baseBranch(ctx context.Context, repoName string) (string, error) {
+	switch a.deployBranch {
+	case anyBranch:
+		return "", nil
+	case "":
+	default:
+		return a.deployBranch, nil
+	}
+	a.branchMu.Lock()
+	defer a.branchMu.Unlock()
+	if branch, ok := a.branches[repoName]; ok {
+		return branch, nil
+	}
+	var branch string
+	if f, project, ok := a.forgeFor(repoName); ok {
+		var err error
+		if branch, err = f.DefaultBranch(ctx, project); err != nil {
+			return "", fmt.Errorf("read the default branch (set --deploy-branch to skip): %w", err)
+		}
+	} else {
+		repo, _, err := a.client.Repositories.Get(ctx, a.owner, repoName)
+		if err != nil {
+			return "", fmt.Errorf("read the default branch (set --deploy-branch to skip): %s", describeAPIError(err, a.owner))
+		}
+		branch = repo.GetDefaultBranch()
+	}
+	if branch == "" {
+		return "", fmt.Errorf("%s has no default branch; set --deploy-branch", repoName)
+	}
+	if a.branches == nil {
+		a.branches = make(map[string]string)
+	}
+	a.branches[repoName] = branch
+	return branch, nil
+}
+
+// マージ済み PR の検索条件（既定ブランチへのマージに絞る）
+func (a *analyzer) mergedQuery(ctx context.Context, repoName string) (string, error) {
+	query := "repo:" + a.owner + "/" + repoName + " is:pr is:merged"
+	branch, err := a.baseBranch(ctx, repoName)
+	if err != nil {
+		return "", err
+	}
+	if branch != "" {
+		query += " base:" + branch
+	}
+	return query, nil
+}
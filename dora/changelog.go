@@ -0,0 +1,79 @@
+package dora
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// CHANGELOG.md にリリース見出しが追加されたコミットをデプロイとして扱う
+// API から見えるデプロイの仕組みが無いリポジトリ向けの簡易ソース
+type changelogSource struct {
+	client *github.Client
+	owner  string
+	path   string
+	from   time.Time
+	branch string // 空ならリポジトリの既定ブランチ
+}
+
+// keep-a-changelog 形式のリリース見出し（"## [1.2.0] - 2024-05-01" / "## v1.2.0" など）
+var releaseHeadingPattern = regexp.MustCompile(`^\+#{1,3}\s*\[?v?(\d+\.\d+(?:\.\d+)?[^\]\s]*)\]?`)
+
+func newChangelogSource(client *github.Client, owner, path string, from time.Time) *changelogSource {
+	return &changelogSource{client: client, owner: owner, path: path, from: from}
+}
+
+func (c *changelogSource) Name() string {
+	return "changelog:" + c.path
+}
+
+func (c *changelogSource) Deployments(ctx context.Context, repo string) ([]deployment, error) {
+	var out []deployment
+	opts := &github.CommitsListOptions{SHA: c.branch, Path: c.path, Since: c.from, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		commits, resp, err := c.client.Repositories.ListCommits(ctx, c.owner, repo, opts)
+		if err != nil {
+			return out, err
+		}
+		for _, commit := range commits {
+			version, err := c.releasedVersion(ctx, repo, commit.GetSHA())
+			if err != nil {
+				return out, err
+			}
+			if version == "" {
+				continue // Unreleased セクションの編集などはリリースではない
+			}
+			out = append(out, deployment{
+				SHA:  commit.GetSHA(),
+				Time: commit.GetCommit().GetCommitter().GetDate().Time,
+				Ref:  version,
+			})
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// コミットで CHANGELOG に追加されたリリース見出しのバージョンを返す
+func (c *changelogSource) releasedVersion(ctx context.Context, repo, sha string) (string, error) {
+	commit, _, err := c.client.Repositories.GetCommit(ctx, c.owner, repo, sha, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range commit.Files {
+		if f.GetFilename() != c.path {
+			continue
+		}
+		for _, line := range strings.Split(f.GetPatch(), "\n") {
+			if m := releaseHeadingPattern.FindStringSubmatch(line); m != nil {
+				return m[1], nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,43 @@
+package dora
+
+import (
+	"fmt"
+	"time"
+)
+
+// コミットの作成日時は手元の時計のまま記録されるため、極端な未来・過去の日付が混ざる
+// 終点（デプロイまたはマージ）より skewTolerance 以上後、または PR 作成より maxCommitAge 以上前のものを不正とみなす
+const skewTolerance = time.Hour
+
+// 不正な日付のコミットを除く（clamp なら範囲内に丸める）。不正なコミットがあれば true
+func (a *analyzer) sanitizeCommitTimes(times []time.Time, created, end time.Time) ([]time.Time, bool) {
+	earliest := created.Add(-a.maxCommitAge)
+	latest := end.Add(skewTolerance)
+	out := make([]time.Time, 0, len(times))
+	skewed := false
+	for _, t := range times {
+		switch {
+		case a.maxCommitAge > 0 && t.Before(earliest):
+			skewed = true
+			if a.clampSkew {
+				out = append(out, earliest)
+			}
+		case t.After(latest):
+			skewed = true
+			if a.clampSkew {
+				out = append(out, end)
+			}
+		default:
+			out = append(out, t)
+		}
+	}
+	return out, skewed
+}
+
+func clockSkewNote(s *Stats, clamp bool) string {
+	action := "excluded"
+	if clamp {
+		action = "clamped"
+	}
+	return fmt.Sprintf("⚠️  %d PR(s) had commit dates in the far past or future; those commits were %s", s.ClockSkewedPRs, action)
+}
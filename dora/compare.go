@@ -0,0 +1,108 @@
+package dora
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// 比較する指標。better は値が増えたときに改善か（+1）悪化か（-1）、0 はどちらでもない
+type compareMetric struct {
+	Name   string
+	Value  func(s *Stats, days float64) float64
+	Format string
+	Better int
+}
+
+var compareMetrics = []compareMetric{
+	{"Merged PRs", func(s *Stats, _ float64) float64 { return float64(s.TotalPRs) }, "%.0f", 0},
+	{"Avg lead time (h)", func(s *Stats, _ float64) float64 { return s.AvgLeadTimeHours() }, "%.1f", -1},
+	{"Median lead time (h)", func(s *Stats, _ float64) float64 { return s.LeadTimeQuantile(0.5) }, "%.1f", -1},
+	{"P90 lead time (h)", func(s *Stats, _ float64) float64 { return s.LeadTimeQuantile(0.9) }, "%.1f", -1},
+	{"CFR (%)", func(s *Stats, _ float64) float64 { return s.CFR() }, "%.1f", -1},
+	{"Deploys per day", func(s *Stats, days float64) float64 { return float64(s.Deployments) / days }, "%.2f", 1},
+	{"Rollbacks", func(s *Stats, _ float64) float64 { return float64(s.Rollbacks) }, "%.0f", -1},
+	{"Quick rollback (%)", func(s *Stats, _ float64) float64 { return s.QuickRevertRate() }, "%.1f", -1},
+	{"Unreviewed (%)", func(s *Stats, _ float64) float64 { return s.UnreviewedRate() }, "%.1f", -1},
+	{"Self-merged (%)", func(s *Stats, _ float64) float64 { return s.SelfMergeRate() }, "%.1f", -1},
+	{"Avg hygiene score", func(s *Stats, _ float64) float64 { return s.AvgHygiene() }, "%.1f", 1},
+	{"Avg size (lines)", func(s *Stats, _ float64) float64 {
+		if s.TotalPRs == 0 {
+			return 0
+		}
+		return float64(s.TotalAdditions) / float64(s.TotalPRs)
+	}, "%.0f", -1},
+}
+
+// 2 つの集計結果を指標ごとに並べ、差分と改善・悪化を表示する
+func printComparison(a, b *analyzer) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("\n%s\n🔀 Comparison: A (%s - %s) vs B (%s - %s)\n%s\n", line, a.from, a.to, b.from, b.to, line)
+	fmt.Printf("CFR (A): %s\nCFR (B): %s\n", cfrDefinition(a.team), cfrDefinition(b.team))
+	printComparedEntities(a, b, "A", "B")
+}
+
+// 全体・リポジトリ・チームごとに、2 つの集計結果の指標を並べる
+func printComparedEntities(a, b *analyzer, labelA, labelB string) {
+	line := strings.Repeat("-", 100)
+	daysA, daysB := periodDays(a.from, a.to), periodDays(b.from, b.to)
+	printEntity := func(name string, sa, sb *Stats) {
+		fmt.Printf("%s\n%s\n", line, name)
+		fmt.Printf("  %-25s | %12s | %12s | %12s | %9s\n", "METRIC", labelA, labelB, "Δ", "Δ%")
+		for _, m := range compareMetrics {
+			va, vb := m.Value(sa, daysA), m.Value(sb, daysB)
+			if va == 0 && vb == 0 {
+				continue // 双方で測っていない指標は出さない
+			}
+			pct := "-"
+			if va != 0 {
+				pct = fmt.Sprintf("%+.1f%%", (vb-va)/math.Abs(va)*100)
+			}
+			fmt.Printf("  %-25s | %12s | %12s | %12s | %9s%s\n",
+				m.Name, fmt.Sprintf(m.Format, va), fmt.Sprintf(m.Format, vb), fmt.Sprintf("%+"+m.Format[1:], vb-va), pct, trendMark(m.Better, vb-va))
+		}
+	}
+
+	printEntity("OVERALL TEAM", a.team, b.team)
+	for _, name := range unionKeys(a.repos, b.repos) {
+		printEntity(name, statsOrEmpty(a.repos, name), statsOrEmpty(b.repos, name))
+	}
+	for _, name := range unionKeys(a.teamStats, b.teamStats) {
+		printEntity("team: "+name, statsOrEmpty(a.teamStats, name), statsOrEmpty(b.teamStats, name))
+	}
+}
+
+func trendMark(better int, delta float64) string {
+	switch {
+	case better == 0 || delta == 0:
+		return ""
+	case (delta > 0) == (better > 0):
+		return " ✅"
+	default:
+		return " ⚠️"
+	}
+}
+
+func unionKeys(a, b map[string]*Stats) []string {
+	seen := make(map[string]bool)
+	for k := range a {
+		seen[k] = true
+	}
+	for k := range b {
+		seen[k] = true
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func statsOrEmpty(m map[string]*Stats, name string) *Stats {
+	if s := m[name]; s != nil {
+		return s
+	}
+	return &Stats{}
+}
@@ -0,0 +1,217 @@
+package dora
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --config で読む設定ファイル（YAML または TOML）。キーはフラグ名で、入れ子のキーは "-" でつなぐ
+//
+//	owner: your-org
+//	repos: [api, web]
+//	deploy:
+//	  source: workflow      # --deploy-source
+//	  workflow: deploy.yml  # --deploy-workflow
+//
+// コマンドラインで指定したフラグはファイルより優先する
+func loadConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		raw, err = parseTOML(string(data))
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	values := make(map[string]string)
+	if err := flattenConfig("", raw, values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// フラグ名と違う、設定ファイルで分かりやすい別名
+var configKeyAliases = map[string]string{
+	"from":          "start",
+	"to":            "end",
+	"period-start":  "start",
+	"period-end":    "end",
+	"period-from":   "start",
+	"period-to":     "end",
+	"output-format": "format",
+}
+
+func flattenConfig(prefix string, raw map[string]any, out map[string]string) error {
+	for key, v := range raw {
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		if prefix != "" {
+			name = prefix + "-" + name
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flattenConfig(name, v, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if _, nested := item.(map[string]any); nested {
+					return fmt.Errorf("%s: lists may only hold plain values", name)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			out[name] = strings.Join(items, ",")
+		case time.Time:
+			// YAML は引用符の無い日付を日時として読む
+			out[name] = v.Format("2006-01-02")
+		case nil:
+		default:
+			out[name] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// コマンドラインで指定されていないフラグに設定ファイルの値を入れる
+func applyConfig(fs *flag.FlagSet, values map[string]string) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := key
+		if alias, ok := configKeyAliases[key]; ok {
+			name = alias
+		}
+		if fs.Lookup(name) == nil || name == "config" {
+			return fmt.Errorf("unknown setting %q in the config file", key)
+		}
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, values[key]); err != nil {
+			return fmt.Errorf("config %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// TOML のうち設定ファイルで使う範囲（[table]、key = 文字列・数値・真偽値・1 行の配列、# コメント）
+func parseTOML(src string) (map[string]any, error) {
+	root := make(map[string]any)
+	table := root
+	for n, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			table = root
+			for _, part := range strings.Split(strings.Trim(line, "[]"), ".") {
+				part = strings.TrimSpace(part)
+				next, ok := table[part].(map[string]any)
+				if !ok {
+					next = make(map[string]any)
+					table[part] = next
+				}
+				table = next
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want key = value", n+1)
+		}
+		v, err := parseTOMLValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		table[strings.Trim(strings.TrimSpace(key), `"`)] = v
+	}
+	return root, nil
+}
+
+func parseTOMLValue(s string) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("arrays must be on one line")
+		}
+		var items []any
+		for _, item := range splitTOMLArray(strings.TrimSpace(s[1 : len(s)-1])) {
+			v, err := parseTOMLValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		return strings.Trim(s, "'"), nil
+	case s == "true" || s == "false":
+		return s == "true", nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return strings.ReplaceAll(s, "_", ""), nil
+	}
+	return nil, fmt.Errorf("unsupported value %q (quote strings)", s)
+}
+
+// 引用符の中のカンマでは区切らない
+func splitTOMLArray(s string) []string {
+	var items []string
+	var quote rune
+	start := 0
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,74 @@
+package dora
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Conventional Commits のプレフィックス（feat: / fix(api): / refactor!: など）
+var conventionalPattern = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?!?:\s`)
+
+var conventionalTypes = map[string]bool{
+	"feat": true, "fix": true, "chore": true, "docs": true, "refactor": true, "perf": true,
+	"test": true, "build": true, "ci": true, "style": true, "revert": true,
+}
+
+// タイトルから変更種別を返す。判別できなければ空文字
+func conventionalType(title string) string {
+	// 取り消しの取り消し（Revert "Revert "feat: ...""）は元の変更の再マージ
+	if inner, ok := strings.CutPrefix(title, `Revert "Revert "`); ok {
+		return conventionalType(strings.TrimSuffix(inner, `""`))
+	}
+	// GitHub の Revert ボタンで作られる PR（Revert "feat: ..."）
+	if strings.HasPrefix(title, `Revert "`) {
+		return "revert"
+	}
+	m := conventionalPattern.FindStringSubmatch(title)
+	if m == nil {
+		return ""
+	}
+	t := strings.ToLower(m[1])
+	if !conventionalTypes[t] {
+		return ""
+	}
+	return t
+}
+
+func printChangeTypeSummary(team *Stats, repos map[string]*Stats) {
+	// 件数の多い種別から列に並べる
+	types := make([]string, 0, len(team.ChangeTypes))
+	for t := range team.ChangeTypes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if team.ChangeTypes[types[i]] != team.ChangeTypes[types[j]] {
+			return team.ChangeTypes[types[i]] > team.ChangeTypes[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🏷️  Change Types (Conventional Commits)\n%s\n", line, line)
+	fmt.Printf("%-25s", "ENTITY")
+	for _, t := range types {
+		fmt.Printf(" | %8s", t)
+	}
+	fmt.Println()
+	printTypeRow := func(name string, s *Stats) {
+		fmt.Printf("%-25s", name)
+		for _, t := range types {
+			pct := 0.0
+			if s.TotalPRs > 0 {
+				pct = float64(s.ChangeTypes[t]) / float64(s.TotalPRs) * 100
+			}
+			fmt.Printf(" | %7.1f%%", pct)
+		}
+		fmt.Println()
+	}
+	printTypeRow("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		printTypeRow(name, repos[name])
+	}
+}
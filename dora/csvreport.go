@@ -0,0 +1,108 @@
+package dora
+
+import (
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// --format csv / tsv 用。表計算ソフトでそのまま開けるよう、単位ごとの指標とメンバー別の表に分ける
+var csvMetricColumns = []string{
+	"merged_prs", "feature_prs", "failure_prs", "avg_lead_time_hours", "median_lead_time_hours", "p90_lead_time_hours",
+	"cfr_percent", "deployments", "deployments_per_day", "deploy_frequency_bucket", "deploy_gap_median_hours", "deploy_gap_p90_hours", "longest_deploy_gap_hours", "mttr_hours", "avg_additions",
+}
+
+// 固定の列の後に --percentiles の列を並べる
+func csvColumns() []string {
+	return append(slices.Clone(csvMetricColumns), csvPercentileColumns()...)
+}
+
+func csvMetricValues(s statsSummary) []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	longest := 0.0
+	if s.LongestDeployGap != nil {
+		longest = s.LongestDeployGap.Hours
+	}
+	return append([]string{
+		strconv.Itoa(s.MergedPRs), strconv.Itoa(s.FeaturePRs), strconv.Itoa(s.FailurePRs),
+		f(s.AvgLeadTimeHours), f(s.MedianLeadTimeHours), f(s.P90LeadTimeHours),
+		f(s.CFRPercent), strconv.Itoa(s.Deployments), f(s.DeploymentsPerDay), s.DeployFrequency, f(s.DeployGapMedianHours), f(s.DeployGapP90Hours), f(longest), f(s.MTTRHours), f(s.AvgAdditions),
+	}, csvPercentileValues(s)...)
+}
+
+// 求まらない値は空欄
+func csvOptional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 2, 64)
+}
+
+// 全体・リポジトリ・チームの行
+func writeMetricsCSV(w io.Writer, sum reportSummary, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	cw.Write(append([]string{"kind", "name", "from", "to"}, csvColumns()...))
+	row := func(kind, name string, s statsSummary) {
+		cw.Write(append([]string{kind, name, sum.From, sum.To}, csvMetricValues(s)...))
+	}
+	row("overall", "OVERALL TEAM", sum.Overall)
+	for _, name := range sortedKeys(sum.Repos) {
+		row("repo", name, sum.Repos[name])
+	}
+	for _, name := range sortedKeys(sum.Teams) {
+		row("team", name, sum.Teams[name])
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// メンバー別の行
+func writeMembersCSV(w io.Writer, sum reportSummary, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if sum.MembersRel != nil {
+		cw.Write([]string{"member", "from", "to", "merged_prs", "median_lead_time_vs_team_percent", "p90_lead_time_vs_team_percent", "avg_additions_vs_team_percent", "cfr_vs_team_points"})
+		for _, name := range sortedKeys(sum.MembersRel) {
+			m := sum.MembersRel[name]
+			cw.Write([]string{name, sum.From, sum.To, strconv.Itoa(m.MergedPRs), csvOptional(m.MedianLeadTimeVsTeam), csvOptional(m.P90LeadTimeVsTeam), csvOptional(m.AvgAdditionsVsTeam), csvOptional(m.CFRVsTeamPoints)})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	cw.Write(append([]string{"member", "from", "to"}, csvColumns()...))
+	for _, name := range sortedKeys(sum.Members) {
+		cw.Write(append([]string{name, sum.From, sum.To}, csvMetricValues(sum.Members[name])...))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// metrics.csv に対するメンバー別のファイル名（metrics-members.csv）
+func membersPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-members" + ext
+}
+
+// path が標準出力なら 2 つの表を空行で区切って続けて出す。ファイルならメンバー別の表は別ファイルにする
+func writeCSVReport(path string, a *analyzer, comma rune) error {
+	sum := summarize(a)
+	if path == "-" || path == "" {
+		return writeReportFile(path, func(w io.Writer) error {
+			if err := writeMetricsCSV(w, sum, comma); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+			return writeMembersCSV(w, sum, comma)
+		})
+	}
+	if err := writeReportFile(path, func(w io.Writer) error { return writeMetricsCSV(w, sum, comma) }); err != nil {
+		return err
+	}
+	return writeReportFile(membersPath(path), func(w io.Writer) error { return writeMembersCSV(w, sum, comma) })
+}
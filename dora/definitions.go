@@ -0,0 +1,118 @@
+package dora
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// その実行で各指標をどう求めたか（アーカイブされたレポートを後から解釈できるよう出力に含める）
+type reportDefinitions struct {
+	LeadTime            string   `json:"lead_time"`
+	LeadTimeUnit        string   `json:"lead_time_unit"`   // pr / commit
+	LeadTimeWeight      string   `json:"lead_time_weight"` // none / lines
+	DeploySource        string   `json:"deploy_source"`
+	DeployBranch        string   `json:"deploy_branch"`          // default（各リポジトリの既定ブランチ）/ ブランチ名 / *
+	Environments        string   `json:"environments,omitempty"` // --deploy-environment
+	DeploymentFrequency string   `json:"deployment_frequency"`
+	CFR                 string   `json:"cfr"`
+	FailureRules        []string `json:"failure_rules"`
+	RevertWindowHours   float64  `json:"revert_window_hours"`
+	MTTR                string   `json:"mttr"`
+	Carryover           string   `json:"carryover"` // 期間前に作成された PR の扱い
+	Members             []string `json:"members,omitempty"`
+	IncludeLabels       []string `json:"include_labels,omitempty"` // これらのラベルが付いた PR だけを数えた
+	ExcludeLabels       []string `json:"exclude_labels,omitempty"` // これらのラベルが付いた PR は数えていない
+	MaxPRsPerRepo       int      `json:"max_prs_per_repo,omitempty"`
+	HolidaysExcluded    int      `json:"holidays_excluded,omitempty"`
+	FreezeDaysExcluded  int      `json:"freeze_days_excluded,omitempty"`
+	Freezes             []string `json:"freezes,omitempty"`
+	IncidentAttribution string   `json:"incident_attribution,omitempty"` // インシデントをどのデプロイの失敗に数えたか
+	BusinessHours       string   `json:"business_hours,omitempty"`       // リードタイム・最初のレビューまでの時間を数える営業時間
+	Timezone            string   `json:"timezone"`                       // 期間の日付を区切ったタイムゾーン
+}
+
+func (a *analyzer) definitions() reportDefinitions {
+	d := reportDefinitions{
+		LeadTime:            a.leadTimeDefinition(),
+		LeadTimeUnit:        "pr",
+		LeadTimeWeight:      "none",
+		DeploySource:        "merge",
+		DeployBranch:        "default",
+		Environments:        a.env,
+		DeploymentFrequency: "merged PRs (re-lands excluded) ÷ days in the period",
+		CFR:                 cfrDefinition(a.team),
+		FailureRules:        a.failureRules(),
+		RevertWindowHours:   a.revertWindow.Hours(),
+		MTTR:                mttrDefinition(),
+		Carryover:           carryoverInclude,
+		MaxPRsPerRepo:       a.maxPRs,
+		HolidaysExcluded:    holidays.countBetween(a.from, a.to),
+		IncludeLabels:       a.includeLabels,
+		ExcludeLabels:       a.excludeLabels,
+		Timezone:            reportLocation.String(),
+	}
+	if a.deployBranch != "" {
+		d.DeployBranch = a.deployBranch
+	}
+	if a.commitLeadTime {
+		d.LeadTimeUnit = "commit"
+	}
+	if leadTimeWeighted {
+		d.LeadTimeWeight = "lines"
+	}
+	if workingHours != nil {
+		d.BusinessHours = workingHours.String()
+	}
+	if a.deploys != nil {
+		d.DeploySource = a.deploys.Name()
+		d.DeploymentFrequency = "successful deployments ÷ days in the period"
+		if a.incidents != nil {
+			d.IncidentAttribution = fmt.Sprintf("each incident → the last successful deployment within %s before it", a.incidentWindow)
+			if a.incidentDeploys > 1 {
+				d.IncidentAttribution = fmt.Sprintf("each incident → split across up to %d successful deployments within %s before it, weighted toward the most recent", a.incidentDeploys, a.incidentWindow)
+			}
+		}
+	}
+	_, d.FreezeDaysExcluded = excludedDays(a.from, a.to)
+	for _, f := range freezesBetween(a.from, a.to) {
+		d.Freezes = append(d.Freezes, f.String())
+	}
+	switch {
+	case d.HolidaysExcluded > 0 && d.FreezeDaysExcluded > 0:
+		d.DeploymentFrequency += " (holidays and freezes excluded)"
+	case d.HolidaysExcluded > 0:
+		d.DeploymentFrequency += " (holidays excluded)"
+	case d.FreezeDaysExcluded > 0:
+		d.DeploymentFrequency += " (freezes excluded)"
+	}
+	if a.carryoverMode != "" && a.carryoverMode != carryoverInclude {
+		d.Carryover = a.carryoverMode + ": PRs opened before " + a.carryoverCutoff()
+	}
+	for m := range a.members {
+		d.Members = append(d.Members, m)
+	}
+	sort.Strings(d.Members)
+	return d
+}
+
+// 失敗 PR の判定ルール（processPR の判定順）
+func (a *analyzer) failureRules() []string {
+	var rules []string
+	switch {
+	case a.markersOnly:
+	case a.conventionalCFR:
+		rule := "conventional: fix: / revert PRs"
+		if a.deploys != nil {
+			rule += fmt.Sprintf(" merged within %s after a deployment", a.fixWindow)
+		}
+		rules = append(rules, rule)
+	default:
+		rules = append(rules, "keyword: title, branch or label contains "+strings.Join(bugFixKeywords, ", "))
+	}
+	if len(a.failureMarkers) > 0 {
+		rules = append(rules, "marker: "+strings.Join(a.failureMarkers, ", "))
+	}
+	rules = append(rules, "revert: failures are attributed to the reverted PR; re-lands only count with a marker")
+	return rules
+}
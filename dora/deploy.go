@@ -0,0 +1,271 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// デプロイイベント（SHA は対象リポジトリのコミット）
+type deployment struct {
+	SHA         string
+	Time        time.Time
+	Environment string
+	Failed      bool
+	Ref         string // 由来（GitOps の PR、タグ名など）
+	ReleaseType string // semver の major / minor / patch など
+	Rollback    bool   // 以前のバージョンへの戻し
+}
+
+// デプロイ情報の取得元
+type deploymentSource interface {
+	Name() string
+	Deployments(ctx context.Context, repo string) ([]deployment, error)
+}
+
+// トークンだけで使えるデプロイの取得元（serve のテナント・ライブラリの Collect 用）
+// "" と "merge" は nil（マージをデプロイとみなす）
+func simpleDeploySource(client *github.Client, owner, name string, from time.Time) (deploymentSource, error) {
+	switch name {
+	case "", "merge":
+		return nil, nil
+	case "semver":
+		return newSemverSource(client, owner, from), nil
+	case "deployments":
+		return newGitHubDeploymentsSource(client, owner, from), nil
+	case "releases":
+		return newReleaseSource(client, owner, "", false, from), nil
+	}
+	return nil, fmt.Errorf("unsupported deploy source %q (want merge, semver, deployments or releases)", name)
+}
+
+// 「PR のマージコミットを最初に含んだデプロイ」を探すためのインデックス
+// デプロイは時刻順に並んでおり、あるデプロイがコミットを含めば以降も含む前提で二分探索する
+type deployIndex struct {
+	client  *github.Client
+	owner   string
+	repo    string
+	all     []deployment
+	ok      []deployment // 成功したデプロイのみ
+	shipped []deployment // 成功かつ SHA が分かるデプロイ（リードタイム用）
+	cache   *containsCache
+
+	attributions map[string][]IncidentAttribution // デプロイ -> 帰属したインシデント（AttributeIncidents）
+}
+
+// コミットの包含関係のキャッシュ（環境ごとのインデックスで共有する）
+type containsCache struct {
+	mu sync.Mutex
+	m  map[string]bool // "deploySHA...commitSHA" -> 含むか
+}
+
+func newDeployIndex(client *github.Client, owner, repo string, deployments []deployment) *deployIndex {
+	return buildDeployIndex(client, owner, repo, deployments, &containsCache{m: make(map[string]bool)})
+}
+
+func buildDeployIndex(client *github.Client, owner, repo string, deployments []deployment, cache *containsCache) *deployIndex {
+	all := append([]deployment(nil), deployments...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Time.Before(all[j].Time) })
+	x := &deployIndex{client: client, owner: owner, repo: repo, all: all, cache: cache}
+	for _, d := range all {
+		if d.Failed {
+			continue
+		}
+		x.ok = append(x.ok, d)
+		// ロールバックは古いコミットに戻るため、リードタイムの探索には使わない
+		if d.SHA != "" && !d.Rollback {
+			x.shipped = append(x.shipped, d)
+		}
+	}
+	return x
+}
+
+// 指定した環境のデプロイだけに絞ったインデックス
+func (x *deployIndex) ForEnvironment(env string) *deployIndex {
+	var filtered []deployment
+	for _, d := range x.all {
+		if d.Environment == env {
+			filtered = append(filtered, d)
+		}
+	}
+	return buildDeployIndex(x.client, x.owner, x.repo, filtered, x.cache)
+}
+
+// --deploy-environment に一致する環境のデプロイだけに絞ったインデックス
+func (x *deployIndex) ForEnvironments(filter string) *deployIndex {
+	var filtered []deployment
+	for _, d := range x.all {
+		if environmentMatches(filter, d.Environment) {
+			filtered = append(filtered, d)
+		}
+	}
+	return buildDeployIndex(x.client, x.owner, x.repo, filtered, x.cache)
+}
+
+// filter はカンマ区切りの環境名。glob（production-*）も使える
+func environmentMatches(filter, env string) bool {
+	for _, p := range splitList(filter) {
+		if p == env {
+			return true
+		}
+		if ok, _ := path.Match(p, env); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// デプロイに含まれる環境名（空は除く）
+func (x *deployIndex) Environments() []string {
+	seen := make(map[string]bool)
+	var envs []string
+	for _, d := range x.all {
+		if d.Environment != "" && !seen[d.Environment] {
+			seen[d.Environment] = true
+			envs = append(envs, d.Environment)
+		}
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+// 期間内の成功したデプロイ数
+func (x *deployIndex) CountBetween(from, to time.Time) int {
+	return countBetween(x.ok, from, to)
+}
+
+// 期間内の失敗したデプロイ数
+func (x *deployIndex) FailedBetween(from, to time.Time) int {
+	n := 0
+	for _, d := range x.all {
+		if d.Failed && !d.Time.Before(from) && d.Time.Before(to) {
+			n++
+		}
+	}
+	return n
+}
+
+// 期間内の成功したデプロイのリリース種別ごとの数
+func (x *deployIndex) CountByReleaseType(from, to time.Time) map[string]int {
+	counts := make(map[string]int)
+	for _, d := range x.ok {
+		if d.ReleaseType != "" && !d.Time.Before(from) && d.Time.Before(to) {
+			counts[d.ReleaseType]++
+		}
+	}
+	return counts
+}
+
+// 期間内のロールバック数
+func (x *deployIndex) RollbacksBetween(from, to time.Time) int {
+	n := 0
+	for _, d := range x.ok {
+		if d.Rollback && !d.Time.Before(from) && d.Time.Before(to) {
+			n++
+		}
+	}
+	return n
+}
+
+// 環境ごとに、以前デプロイした（直前のものではない）SHA の再デプロイをロールバックとみなす
+func markRollbacks(deployments []deployment) {
+	order := make([]int, len(deployments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return deployments[order[i]].Time.Before(deployments[order[j]].Time) })
+	seen := make(map[string]map[string]bool) // 環境 -> デプロイ済み SHA
+	current := make(map[string]string)       // 環境 -> 現在の SHA
+	for _, i := range order {
+		d := &deployments[i]
+		if d.Failed || d.SHA == "" {
+			continue
+		}
+		if seen[d.Environment] == nil {
+			seen[d.Environment] = make(map[string]bool)
+		}
+		if seen[d.Environment][d.SHA] && current[d.Environment] != d.SHA {
+			d.Rollback = true
+		}
+		seen[d.Environment][d.SHA] = true
+		current[d.Environment] = d.SHA
+	}
+}
+
+// [from, to] の間に成功したデプロイがあったか
+func (x *deployIndex) DeployedWithin(from, to time.Time) bool {
+	i := sort.Search(len(x.ok), func(i int) bool { return !x.ok[i].Time.Before(from) })
+	return i < len(x.ok) && !x.ok[i].Time.After(to)
+}
+
+func countBetween(deployments []deployment, from, to time.Time) int {
+	n := 0
+	for _, d := range deployments {
+		if !d.Time.Before(from) && d.Time.Before(to) {
+			n++
+		}
+	}
+	return n
+}
+
+func (x *deployIndex) FirstContaining(ctx context.Context, sha string, mergedAt time.Time) (*deployment, error) {
+	if sha == "" {
+		return nil, nil
+	}
+	// マージより前のデプロイには含まれ得ない
+	lo := sort.Search(len(x.shipped), func(i int) bool { return !x.shipped[i].Time.Before(mergedAt) })
+	hi := len(x.shipped)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		c, err := x.containsCommit(ctx, x.shipped[mid].SHA, sha)
+		if err != nil {
+			return nil, err
+		}
+		if c {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	if lo == len(x.shipped) {
+		return nil, nil
+	}
+	return &x.shipped[lo], nil
+}
+
+func (x *deployIndex) containsCommit(ctx context.Context, deploySHA, commitSHA string) (bool, error) {
+	if strings.HasPrefix(deploySHA, commitSHA) || strings.HasPrefix(commitSHA, deploySHA) {
+		return true, nil
+	}
+	key := deploySHA + "..." + commitSHA
+	x.cache.mu.Lock()
+	c, cached := x.cache.m[key]
+	x.cache.mu.Unlock()
+	if cached {
+		return c, nil
+	}
+
+	cmp, resp, err := x.client.Repositories.CompareCommits(ctx, x.owner, x.repo, commitSHA, deploySHA, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		// 対象リポジトリに存在しない SHA は「含まない」として扱う
+		if resp != nil && resp.StatusCode == 404 {
+			c = false
+		} else {
+			return false, fmt.Errorf("compare %s...%s: %w", commitSHA, deploySHA, err)
+		}
+	} else {
+		status := cmp.GetStatus()
+		c = status == "ahead" || status == "identical"
+	}
+
+	x.cache.mu.Lock()
+	x.cache.m[key] = c
+	x.cache.mu.Unlock()
+	return c, nil
+}
@@ -0,0 +1,206 @@
+package dora
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DORA のデプロイ頻度の区分。平均ではなく、デプロイ間隔（営業日）の p90 で判定する
+// 月曜にまとめて 20 回出して残りは出さないチームが「毎日」にならないようにするため
+// on-demand（1 日に複数回）は、さらに間隔の中央値が 0（同じ日に次のデプロイがある）ことを求める
+var deployFrequencyBuckets = []struct {
+	Name       string
+	MaxGapDays int // p90 の間隔（営業日）がこれ以下
+}{
+	{"daily-weekly", 5},
+	{"weekly-monthly", 21},
+	{"monthly-6months", 126},
+}
+
+const lowestDeployFrequencyBucket = "less-than-6months"
+
+// マージ日時が標本だけの単位は区分を決めない（間隔が実際より長く見える）
+const sampledDeployFrequencyBucket = "n/a (sampled)"
+
+// デプロイの日時（デプロイソースが無い単位はマージ日時）
+// 抽出した単位のマージ日時は標本だけで、間隔が実際より長くなるので使わない
+func (s *Stats) deployTimes() timeCounts {
+	if s.DeployTracked {
+		return s.DeployTimes
+	}
+	if s.Sampled {
+		return timeCounts{}
+	}
+	return s.MergeTimes
+}
+
+// デプロイソースが無く、マージ日時が標本だけの単位
+func (s *Stats) mergeSampled() bool {
+	return !s.DeployTracked && s.Sampled
+}
+
+// 日ごとのデプロイ数
+func (s *Stats) deployDays() map[string]int {
+	return s.deployTimes().days()
+}
+
+// from の翌日から to までの営業日数（週末・祝日・フリーズ期間を除く）
+func workingDaysBetween(from, to time.Time) int {
+	n := 0
+	for d := from.AddDate(0, 0, 1); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday && !holidays.isHoliday(d) && !isFrozen(d) {
+			n++
+		}
+	}
+	return n
+}
+
+// 期間の始まり→各デプロイ→期間の終わりの間隔（営業日）と、デプロイのあった日数
+// 期間の両端の空白も間隔に含める。同じ日のデプロイ同士の間隔は 0
+func deployGaps(days map[string]int, from, to string) ([]int, int) {
+	start, err1 := time.Parse("2006-01-02", from)
+	end, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil {
+		return nil, 0
+	}
+	if today, _ := time.Parse("2006-01-02", dayOf(time.Now())); end.After(today) {
+		end = today
+	}
+	dates := make([]time.Time, 0, len(days))
+	for date := range days {
+		if t, err := time.Parse("2006-01-02", date); err == nil && !t.Before(start) && !t.After(end) {
+			dates = append(dates, t)
+		}
+	}
+	if len(dates) == 0 {
+		return nil, 0
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	// 期間の初日のデプロイは間隔 0 として数えられるよう、前日から数える
+	prev := start.AddDate(0, 0, -1)
+	gaps := make([]int, 0, len(dates)+1)
+	for _, d := range dates {
+		gaps = append(gaps, workingDaysBetween(prev, d))
+		for range days[d.Format("2006-01-02")] - 1 {
+			gaps = append(gaps, 0)
+		}
+		prev = d
+	}
+	gaps = append(gaps, workingDaysBetween(prev, end))
+	sort.Ints(gaps)
+	return gaps, len(dates)
+}
+
+// DORA の区分。デプロイが無ければ空文字
+func deployFrequencyBucket(s *Stats, from, to string) string {
+	if s.mergeSampled() {
+		return sampledDeployFrequencyBucket
+	}
+	gaps, _ := deployGaps(s.deployDays(), from, to)
+	if len(gaps) == 0 {
+		return ""
+	}
+	p90 := sortedQuantile(gaps, 0.9)
+	if p90 <= 1 && sortedQuantile(gaps, 0.5) == 0 {
+		return "on-demand"
+	}
+	for _, b := range deployFrequencyBuckets {
+		if p90 <= b.MaxGapDays {
+			return b.Name
+		}
+	}
+	return lowestDeployFrequencyBucket
+}
+
+func printDeployFrequencySummary(from, to string, team *Stats, repos map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n📅 Deployment Frequency (DORA buckets, gaps in working days)\n%s\n", line, line)
+	fmt.Printf("%-25s | %-11s | %-9s | %-6s | %s\n", "ENTITY", "Active days", "MedianGap", "P90Gap", "Bucket")
+	row := func(name string, s *Stats) {
+		if s.mergeSampled() {
+			fmt.Printf("%-25s | %11s | %9s | %6s | %s\n", name, "-", "-", "-", sampledDeployFrequencyBucket)
+			return
+		}
+		gaps, active := deployGaps(s.deployDays(), from, to)
+		bucket := deployFrequencyBucket(s, from, to)
+		if bucket == "" {
+			bucket = "no deployments"
+		}
+		fmt.Printf("%-25s | %11d | %9d | %6d | %s\n", name, active, sortedQuantile(gaps, 0.5), sortedQuantile(gaps, 0.9), bucket)
+	}
+	row("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		row(name, repos[name])
+	}
+}
+
+// 連続するデプロイ間の経過時間（暦の時間）。リリース凍結のような長い空白を見つける用
+type deployGapStats struct {
+	Count       int // 間隔の数（デプロイ数 - 1）
+	Median      time.Duration
+	P90         time.Duration
+	Longest     time.Duration
+	LongestFrom time.Time // 最長の空白の直前のデプロイ
+	LongestTo   time.Time // 最長の空白の直後のデプロイ
+}
+
+// デプロイが 2 回未満なら Count は 0
+// 同じ 1 時間の枠の中のデプロイは、枠の最初と最後の間に均等に並んでいたとみなす
+func (s *Stats) deployGapStats() deployGapStats {
+	times := s.deployTimes()
+	if times.Len() < 2 {
+		return deployGapStats{}
+	}
+	var out deployGapStats
+	gaps := make([]time.Duration, 0, times.Len()-1)
+	longest := func(gap time.Duration, from, to time.Time) {
+		if gap > out.Longest {
+			out.Longest, out.LongestFrom, out.LongestTo = gap, from, to
+		}
+	}
+	var prev *timeHour
+	for _, h := range times.sorted() {
+		if prev != nil {
+			gaps = append(gaps, h.First.Sub(prev.Last))
+			longest(h.First.Sub(prev.Last), prev.Last, h.First)
+		}
+		if h.N > 1 {
+			step := h.Last.Sub(h.First) / time.Duration(h.N-1)
+			for range h.N - 1 {
+				gaps = append(gaps, step)
+			}
+			longest(step, h.First, h.First.Add(step))
+		}
+		prev = h
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	out.Count = len(gaps)
+	out.Median = sortedQuantile(gaps, 0.5)
+	out.P90 = sortedQuantile(gaps, 0.9)
+	return out
+}
+
+func printDeployGapSummary(team *Stats, repos map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n⏸️  Time Between Deployments\n%s\n", line, line)
+	fmt.Printf("%-25s | %-5s | %-9s | %-9s | %-9s | %s\n", "ENTITY", "Gaps", "Median", "P90", "Longest", "Longest gap")
+	row := func(name string, s *Stats) {
+		g := s.deployGapStats()
+		if s.mergeSampled() {
+			fmt.Printf("%-25s | %5s | %9s | %9s | %9s | %s\n", name, "-", "-", "-", "-", "skipped: merged PRs were sampled (--max-prs)")
+			return
+		}
+		if g.Count == 0 {
+			fmt.Printf("%-25s | %5d | %9s | %9s | %9s | %s\n", name, 0, "-", "-", "-", "fewer than 2 deployments")
+			return
+		}
+		fmt.Printf("%-25s | %5d | %9s | %9s | %9s | %s → %s\n", name, g.Count, fmtHours(g.Median), fmtHours(g.P90), fmtHours(g.Longest),
+			g.LongestFrom.In(reportLocation).Format("2006-01-02 15:04"), g.LongestTo.In(reportLocation).Format("2006-01-02 15:04"))
+	}
+	row("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		row(name, repos[name])
+	}
+}
@@ -0,0 +1,100 @@
+package dora
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// GitHub Deployments API（/repos/{owner}/{repo}/deployments）のデプロイを使う
+// 状態は最新のデプロイステータスで判定する（success / inactive は成功、failure / error は失敗）
+type githubDeploymentsSource struct {
+	client *github.Client
+	owner  string
+	from   time.Time
+}
+
+func newGitHubDeploymentsSource(client *github.Client, owner string, from time.Time) *githubDeploymentsSource {
+	return &githubDeploymentsSource{client: client, owner: owner, from: from}
+}
+
+func (g *githubDeploymentsSource) Name() string {
+	return "deployments"
+}
+
+func (g *githubDeploymentsSource) Deployments(ctx context.Context, repo string) ([]deployment, error) {
+	var out []deployment
+	opts := &github.DeploymentsListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := g.client.Repositories.ListDeployments(ctx, g.owner, repo, opts)
+		if err != nil {
+			return out, err
+		}
+		older := false
+		for _, dep := range page {
+			d, ok, err := g.deployment(ctx, repo, dep)
+			if err != nil {
+				return out, err
+			}
+			if ok {
+				out = append(out, d)
+			}
+			// 一覧は新しい順。期間前のデプロイもロールバック判定の履歴として 1 ページ分は残す
+			if dep.GetCreatedAt().Before(g.from) {
+				older = true
+			}
+		}
+		if older || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	markRollbacks(out)
+	return out, nil
+}
+
+// ステータスからデプロイ 1 件を組み立てる。完了していないデプロイは ok=false
+func (g *githubDeploymentsSource) deployment(ctx context.Context, repo string, dep *github.Deployment) (deployment, bool, error) {
+	// 再試行を繰り返したデプロイはステータスが 100 件を超えることがあるので全ページ読む
+	var statuses []*github.DeploymentStatus
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := g.client.Repositories.ListDeploymentStatuses(ctx, g.owner, repo, dep.GetID(), opts)
+		if err != nil {
+			return deployment{}, false, err
+		}
+		statuses = append(statuses, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	d := deployment{
+		SHA:         dep.GetSHA(),
+		Environment: dep.GetEnvironment(),
+		Ref:         dep.GetRef(),
+		Rollback:    isExplicitRollback(dep),
+	}
+	// ステータスは新しい順
+	for i := len(statuses) - 1; i >= 0; i-- {
+		// ステータスで環境が変わる（ステージングから本番への昇格など）場合は最新の環境に従う
+		if env := statuses[i].GetEnvironment(); env != "" {
+			d.Environment = env
+		}
+		switch statuses[i].GetState() {
+		case "success":
+			d.Time, d.Failed = statuses[i].GetCreatedAt().Time, false
+		case "failure", "error":
+			d.Time, d.Failed = statuses[i].GetCreatedAt().Time, true
+		}
+	}
+	return d, !d.Time.IsZero(), nil
+}
+
+// タスク名・説明・ペイロードで明示されたロールバック
+func isExplicitRollback(dep *github.Deployment) bool {
+	text := strings.ToLower(dep.GetTask() + " " + dep.GetDescription() + " " + string(dep.Payload))
+	return strings.Contains(text, "rollback") || strings.Contains(text, "roll back")
+}
@@ -0,0 +1,100 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// --repos の特別な値。Owner のリポジトリ一覧（アーカイブ済みを除く）で置き換える
+const allReposKeyword = "all"
+
+// Owner のアーカイブされていないリポジトリ名。Organization でなければユーザーのリポジトリを読む
+func discoverRepos(ctx context.Context, client *github.Client, owner string) ([]string, error) {
+	var names []string
+	opts := &github.RepositoryListByOrgOptions{Type: "all", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Repositories.ListByOrg(ctx, owner, opts)
+		if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound && opts.Page == 0 {
+			return discoverUserRepos(ctx, client, owner)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list repositories of %s: %w", owner, err)
+		}
+		names = append(names, activeRepoNames(page)...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func discoverUserRepos(ctx context.Context, client *github.Client, user string) ([]string, error) {
+	var names []string
+	opts := &github.RepositoryListByUserOptions{Type: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Repositories.ListByUser(ctx, user, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list repositories of %s: %w", user, err)
+		}
+		names = append(names, activeRepoNames(page)...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func activeRepoNames(page []*github.Repository) []string {
+	var names []string
+	for _, r := range page {
+		if !r.GetArchived() && !r.GetDisabled() {
+			names = append(names, r.GetName())
+		}
+	}
+	return names
+}
+
+// --repo-filter のグロブ（"svc-*,!svc-legacy-*"）でリポジトリを絞り込む
+// "!" で始まるパターンは除外。含めるパターンが無ければ除外以外のすべてを残す
+// "gitlab:group/project" のような名前は最後の "/" 以降と照合する
+func filterRepos(repos []string, spec string) ([]string, error) {
+	var include, exclude []string
+	for _, p := range splitList(spec) {
+		pattern := strings.TrimPrefix(p, "!")
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid --repo-filter pattern %q: %w", p, err)
+		}
+		if strings.HasPrefix(p, "!") {
+			exclude = append(exclude, pattern)
+		} else {
+			include = append(include, pattern)
+		}
+	}
+	matches := func(patterns []string, name string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	var out []string
+	for _, r := range repos {
+		name := r[strings.LastIndex(r, "/")+1:]
+		if len(include) > 0 && !matches(include, name) || matches(exclude, name) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
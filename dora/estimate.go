@@ -0,0 +1,162 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// 実行前の見積もり（リポジトリごとの PR 数と API リクエスト数・所要時間）
+type repoEstimate struct {
+	Repo     string
+	Merged   int // 期間内のマージ済み PR 数
+	Analyzed int // --max-prs 適用後
+	Search   int // 検索 API のリクエスト数
+	GraphQL  int // --api graphql での GraphQL リクエスト数（検索 API の代わり）
+	Run      int // 通常の実行での REST リクエスト数（検索を除く）
+	Collect  int // collect / export での REST リクエスト数（検索を除く）
+}
+
+// PR ごとのリクエスト数。デプロイソースがあれば含むデプロイの二分探索で比較 API を呼ぶ
+// --api graphql では PR 本体・レビュー・コミットは検索結果に含まれる
+func (a *analyzer) perPRRequests(analyzed int, reviews, collect bool) int {
+	n := 0
+	if !a.graphql {
+		n++ // PullRequests.Get
+		if reviews || collect {
+			n++ // ListReviews
+		}
+		if collect || a.commitLeadTime {
+			n++ // ListCommits（コミットの作成日時）
+		}
+		if collect {
+			n++ // ListIssueTimeline（collect のタイムライン）
+		}
+	}
+	if a.deploys != nil {
+		n += int(math.Ceil(math.Log2(float64(analyzed + 1))))
+	}
+	return n
+}
+
+// 検索 API で件数だけを取得して見積もる（per_page=1 なので 1 リポジトリ 1 リクエスト）
+func runEstimate(ctx context.Context, a *analyzer, repos []string) error {
+	var estimates []repoEstimate
+	var elapsed time.Duration
+	for _, repo := range repos {
+		if _, _, ok := splitForgeRepo(repo); ok {
+			fmt.Fprintf(os.Stderr, "⚠️  %s: estimates cover GitHub repositories only, skipped\n", repo)
+			continue
+		}
+		query, err := a.mergedQuery(ctx, repo)
+		if err != nil {
+			return fmt.Errorf("%s: %v", repo, err)
+		}
+		query += " merged:" + searchRange(a.from, a.to)
+		start := time.Now()
+		result, _, err := a.client.Search.Issues(ctx, query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}})
+		if err != nil {
+			return fmt.Errorf("%s: %s", repo, describeAPIError(err, a.owner))
+		}
+		elapsed += time.Since(start)
+
+		e := repoEstimate{Repo: repo, Merged: result.GetTotal(), Analyzed: result.GetTotal()}
+		if a.maxPRs > 0 && e.Analyzed > a.maxPRs {
+			e.Analyzed = a.maxPRs
+		}
+		// 1000 件を超える期間は分割して検索し直す
+		e.Search = max(1, (e.Merged+99)/100) + e.Merged/searchResultLimit
+		if a.graphql {
+			e.GraphQL = max(1, (e.Merged+49)/50) + e.Merged/searchResultLimit
+			e.Search = 0
+		}
+		e.Run = e.Analyzed * a.perPRRequests(e.Analyzed, a.needsReviews(), false)
+		e.Collect = e.Analyzed * a.perPRRequests(e.Analyzed, true, true)
+		estimates = append(estimates, e)
+	}
+	latency := time.Second
+	if len(estimates) > 0 {
+		latency = elapsed / time.Duration(len(estimates))
+	}
+
+	limits, resp, err := a.client.RateLimit.Get(ctx)
+	// GitHub Enterprise Server でレート制限を無効にしていると 404 になる（制限なしとして見積もる）
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return err
+	}
+	printEstimate(a, estimates, latency, limits)
+	return nil
+}
+
+// 所要時間: リポジトリは repoWorkers 並列、PR はリポジトリごとに workers 並列で処理する。
+// 並列でも最も時間のかかるリポジトリより短くはならない。
+// 検索 API は 1 分あたりの上限、REST はコアの残量を超えた分だけリセット待ちが加わる
+func estimateDuration(estimates []repoEstimate, collect bool, workers, repoWorkers int, latency time.Duration, limits *github.RateLimits) time.Duration {
+	var total, longest time.Duration
+	search, rest := 0, 0
+	for _, e := range estimates {
+		n := e.Run
+		if collect {
+			n = e.Collect
+		}
+		search += e.Search
+		rest += n
+		repo := time.Duration(e.Search)*latency + time.Duration((n+workers-1)/workers)*latency
+		total += repo
+		longest = max(longest, repo)
+	}
+	d := max(total/time.Duration(max(repoWorkers, 1)), longest)
+	if s := limits.GetSearch(); s != nil && s.Limit > 0 && search > s.Limit {
+		d += time.Duration(search/s.Limit) * time.Minute
+	}
+	if c := limits.GetCore(); c != nil && rest > c.Remaining {
+		d = max(d, time.Until(c.Reset.Time))
+		if c.Limit > 0 {
+			d += time.Duration((rest-c.Remaining-1)/c.Limit) * time.Hour
+		}
+	}
+	return d
+}
+
+func printEstimate(a *analyzer, estimates []repoEstimate, latency time.Duration, limits *github.RateLimits) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🧮 Estimate: %s to %s\n%s\n", line, a.from, a.to, line)
+	fmt.Printf("%-25s | %8s | %8s | %8s | %10s | %14s\n", "REPOSITORY", "Merged", "Analyzed", "Search", "REST (run)", "REST (collect)")
+	var total repoEstimate
+	for _, e := range estimates {
+		fmt.Printf("%-25s | %8d | %8d | %8d | %10d | %14d\n", e.Repo, e.Merged, e.Analyzed, e.Search, e.Run, e.Collect)
+		total.Merged += e.Merged
+		total.Analyzed += e.Analyzed
+		total.Search += e.Search
+		total.Run += e.Run
+		total.Collect += e.Collect
+		total.GraphQL += e.GraphQL
+	}
+	fmt.Println(line)
+	fmt.Printf("%-25s | %8d | %8d | %8d | %10d | %14d\n", "TOTAL", total.Merged, total.Analyzed, total.Search, total.Run, total.Collect)
+	fmt.Println(line)
+	if a.graphql {
+		fmt.Printf("GraphQL requests: %d (search pages of 50 PRs with their commits, reviews and labels)\n", total.GraphQL)
+	} else {
+		fmt.Println("GraphQL requests: 0 (all requests use REST)")
+	}
+	if c := limits.GetCore(); c != nil {
+		fmt.Printf("Rate limit [core]: %d/%d remaining, resets at %s\n", c.Remaining, c.Limit, c.Reset.Local().Format("15:04"))
+	}
+	if s := limits.GetSearch(); s != nil {
+		fmt.Printf("Rate limit [search]: %d requests/minute\n", s.Limit)
+	}
+	fmt.Printf("Concurrency: %d PRs at a time per repository, %d repositories at a time (observed latency %s)\n", a.workers, a.repoWorkers, latency.Round(time.Millisecond))
+	fmt.Printf("Estimated wall clock: run ~%s, collect/export ~%s\n",
+		estimateDuration(estimates, false, a.workers, a.repoWorkers, latency, limits).Round(time.Second),
+		estimateDuration(estimates, true, a.workers, a.repoWorkers, latency, limits).Round(time.Second))
+	if a.deploys != nil {
+		fmt.Printf("Deployment source %q requests are not included.\n", a.deploys.Name())
+	}
+}
@@ -0,0 +1,73 @@
+package dora
+
+import (
+	"sort"
+	"time"
+)
+
+// デプロイ・マージの日時を、レポートのタイムゾーンの 1 時間ごとの件数と最初・最後の日時にまとめる
+// 大きさは PR・デプロイの数ではなく期間の長さで決まる
+// 日・週ごとの件数は正確。間隔は 1 時間の枠をまたぐものは正確で、枠の中は均等に並んでいたとみなす
+type timeCounts struct {
+	n     int
+	hours map[string]*timeHour
+}
+
+type timeHour struct {
+	N     int
+	First time.Time
+	Last  time.Time
+}
+
+func (tc *timeCounts) add(t time.Time) {
+	tc.addHour(t.In(reportLocation).Format("2006-01-02 15"), timeHour{N: 1, First: t, Last: t})
+}
+
+// 別の単位（リポジトリ）の日時をまとめて足す
+func (tc *timeCounts) merge(o timeCounts) {
+	for key, h := range o.hours {
+		tc.addHour(key, *h)
+	}
+}
+
+func (tc *timeCounts) addHour(key string, o timeHour) {
+	if tc.hours == nil {
+		tc.hours = make(map[string]*timeHour)
+	}
+	tc.n += o.N
+	h := tc.hours[key]
+	if h == nil {
+		tc.hours[key] = &o
+		return
+	}
+	h.N += o.N
+	if o.First.Before(h.First) {
+		h.First = o.First
+	}
+	if o.Last.After(h.Last) {
+		h.Last = o.Last
+	}
+}
+
+func (tc timeCounts) Len() int {
+	return tc.n
+}
+
+// 日ごとの件数
+func (tc timeCounts) days() map[string]int {
+	days := make(map[string]int)
+	for key, h := range tc.hours {
+		days[key[:len("2006-01-02")]] += h.N
+	}
+	return days
+}
+
+// 時刻順の枠
+func (tc timeCounts) sorted() []*timeHour {
+	out := make([]*timeHour, 0, len(tc.hours))
+	for _, h := range tc.hours {
+		out = append(out, h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].First.Before(out[j].First) })
+	return out
+}
@@ -0,0 +1,43 @@
+package dora
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeCountsDeployGaps(t *testing.T) {
+	base := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	var s Stats
+	s.DeployTracked = true
+	// 9:00, 9:10, 9:20 は同じ枠、12:20 と翌日 18:20 は別の枠
+	for _, m := range []int{20, 0, 10, 200, 2000} {
+		s.DeployTimes.add(base.Add(time.Duration(m) * time.Minute))
+	}
+
+	if got := s.DeployTimes.Len(); got != 5 {
+		t.Errorf("Len() = %d, want 5", got)
+	}
+	days := s.deployDays()
+	if days["2025-01-06"] != 4 || days["2025-01-07"] != 1 {
+		t.Errorf("deployDays() = %v, want 4 on 01-06 and 1 on 01-07", days)
+	}
+
+	g := s.deployGapStats()
+	if g.Count != 4 || g.Median != 10*time.Minute || g.Longest != 30*time.Hour {
+		t.Errorf("deployGapStats() = %+v, want 4 gaps, median 10m, longest 30h", g)
+	}
+	if want := base.Add(200 * time.Minute); !g.LongestFrom.Equal(want) {
+		t.Errorf("LongestFrom = %v, want %v", g.LongestFrom, want)
+	}
+
+	// 別の単位と合わせても件数と枠は変わらない
+	var team timeCounts
+	team.merge(s.DeployTimes)
+	team.merge(s.DeployTimes)
+	if got := team.Len(); got != 10 {
+		t.Errorf("merged Len() = %d, want 10", got)
+	}
+	if got := countByWeek(chartWeeks("2025-01-06", "2025-01-19"), team); got[0] != 10 || got[1] != 0 {
+		t.Errorf("countByWeek = %v, want [10 0]", got)
+	}
+}
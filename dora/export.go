@@ -0,0 +1,254 @@
+// Package dora は dora-metrics の解析本体。Collect で PR ごとの生データを取り出せる
+//
+//	err := dora.Collect(ctx, dora.Config{Client: gh, Owner: "my-org", From: "2025-01-01", To: "2025-03-31"},
+//		[]string{"api", "web"}, func(r dora.PRRecord) { ... })
+package dora
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// PRRecord は export サブコマンドで出力する 1 PR 分の生データ
+// 集計前の値をそのまま残し、利用者が独自の指標を計算できるようにする
+type PRRecord struct {
+	Repo           string                `json:"repo"`
+	Number         int                   `json:"number"`
+	Title          string                `json:"title"`
+	URL            string                `json:"url"`
+	Author         string                `json:"author"`
+	AuthorLogin    string                `json:"author_login"` // 別名解決前のログイン名
+	Teams          []string              `json:"teams,omitempty"`
+	Labels         []string              `json:"labels"`
+	Reviewers      []string              `json:"reviewers"`
+	ReviewsFetched bool                  `json:"reviews_fetched"`               // false ならレビュー情報は取得できていない
+	Requested      []string              `json:"requested_reviewers,omitempty"` // マージ時点でレビュー依頼が残っていたレビュアー・チーム
+	HeadRef        string                `json:"head_ref"`
+	BaseRef        string                `json:"base_ref"`
+	MergeCommitSHA string                `json:"merge_commit_sha"`
+	FirstCommitAt  *time.Time            `json:"first_commit_at,omitempty"`
+	CommitTimes    []time.Time           `json:"commit_authored_at,omitempty"` // PR に含まれるコミットの作成日時
+	CreatedAt      time.Time             `json:"created_at"`
+	FirstReviewAt  *time.Time            `json:"first_review_at,omitempty"`
+	ApprovedAt     *time.Time            `json:"approved_at,omitempty"`
+	ReviewerFirst  map[string]time.Time  `json:"reviewer_first_at,omitempty"` // レビュアーごとの最初のレビュー
+	MergedAt       time.Time             `json:"merged_at"`
+	DeployedAt     *time.Time            `json:"deployed_at,omitempty"`
+	EnvDeployedAt  map[string]time.Time  `json:"env_deployed_at,omitempty"` // 環境ごとの最初のデプロイ
+	LeadTimeHours  *float64              `json:"lead_time_hours,omitempty"`
+	Additions      int                   `json:"additions"`
+	Deletions      int                   `json:"deletions"`
+	ChangedFiles   int                   `json:"changed_files"`
+	Commits        int                   `json:"commits"`
+	ChangeType     string                `json:"change_type,omitempty"`
+	Failure        bool                  `json:"failure"`
+	FailureReasons []string              `json:"failure_reasons,omitempty"` // keyword / conventional / marker
+	MergedBy       string                `json:"merged_by"`
+	Hygiene        *int                  `json:"hygiene_score,omitempty"`
+	IsRevert       bool                  `json:"is_revert"`
+	QuickRevertOf  string                `json:"quick_revert_of,omitempty"`     // 即時に取り消した PR の作成者
+	Reverts        int                   `json:"reverts,omitempty"`             // 取り消した PR 番号
+	RevertedAuthor string                `json:"reverted_author,omitempty"`     // 取り消した PR の作成者（失敗の帰属先）
+	OriginShipped  *time.Time            `json:"reverted_shipped_at,omitempty"` // 取り消した PR の出荷日時（復旧時間の起点）
+	IsReland       bool                  `json:"is_reland,omitempty"`           // 取り消された PR の再マージ
+	RelandOf       int                   `json:"reland_of,omitempty"`           // 再マージした元の PR 番号
+	Duplicate      bool                  `json:"duplicate"`                     // 他リポジトリと同じマージコミット（全体集計では除外）
+	Incidents      []IncidentAttribution `json:"incidents,omitempty"`           // 出荷したデプロイに帰属したインシデント
+	Timeline       []TimelineEvent       `json:"timeline,omitempty"`            // PR のタイムライン（collect のみ）
+}
+
+// Config は Collect の設定。指定しない項目は CLI の既定値と同じ
+type Config struct {
+	Client       *github.Client // client.NewClient などで作った GitHub のクライアント（必須）
+	Owner        string         // リポジトリのオーナー（Org・ユーザー）
+	From         string         // 集計期間の開始日（YYYY-MM-DD）
+	To           string         // 集計期間の終了日（YYYY-MM-DD、その日を含む）
+	Members      []string       // この作成者の PR だけを対象にする（空なら全員）
+	IncludeBots  bool           // bot の PR・レビューも数える（--exclude-bots=false）
+	DeploySource string         // merge（既定）、semver、deployments、releases
+	Timeline     bool           // PR のタイムラインも取得する（PR ごとに API を呼ぶ）
+	Progress     io.Writer      // リポジトリごとの進捗の出力先（nil なら出さない）
+}
+
+func (c Config) analyzer() (*analyzer, error) {
+	if c.Client == nil {
+		return nil, errors.New("dora: Config.Client is required")
+	}
+	if c.Owner == "" {
+		return nil, errors.New("dora: Config.Owner is required")
+	}
+	if _, err := parseDay(c.From); err != nil {
+		return nil, fmt.Errorf("dora: invalid Config.From %q: %w", c.From, err)
+	}
+	if _, err := parseDay(c.To); err != nil {
+		return nil, fmt.Errorf("dora: invalid Config.To %q: %w", c.To, err)
+	}
+	a := newAnalyzer(c.Client, nil, c.Owner, c.From, c.To)
+	a.revertWindow = 24 * time.Hour
+	a.excludeBots = !c.IncludeBots
+	a.timeline = c.Timeline
+	if len(c.Members) > 0 {
+		a.members = make(map[string]bool)
+		for _, m := range c.Members {
+			a.members[m] = true
+		}
+	}
+	start, _ := a.window()
+	deploys, err := simpleDeploySource(c.Client, c.Owner, c.DeploySource, start)
+	if err != nil {
+		return nil, fmt.Errorf("dora: %w", err)
+	}
+	a.deploys = deploys
+	return a, nil
+}
+
+// Collect はリポジトリのマージ済み PR を解析し、PR ごとの生データを fn に渡す
+// fn は repos の順に逐次呼ばれる（並行には呼ばれない）。取得に失敗した PR・リポジトリは警告を出して飛ばす
+func Collect(ctx context.Context, cfg Config, repos []string, fn func(PRRecord)) error {
+	a, err := cfg.analyzer()
+	if err != nil {
+		return err
+	}
+	collectPRRecords(ctx, a, repos, cfg.Progress, fn)
+	return ctx.Err()
+}
+
+// collectPRRecords はリポジトリを解析し、PR ごとの生データを fn に渡す
+// 集計結果も通常どおり a に残る
+func collectPRRecords(ctx context.Context, a *analyzer, repos []string, progress io.Writer, fn func(PRRecord)) {
+	a.onRecord = fn
+	defer func() { a.onRecord = nil }()
+	a.analyzeRepos(ctx, repos, progress)
+}
+
+// JSON Lines で書き出す（最初のエラー以降は書き込まない）
+type recordWriter struct {
+	enc *json.Encoder
+	err error
+}
+
+func newRecordWriter(w io.Writer) *recordWriter {
+	return &recordWriter{enc: json.NewEncoder(w)}
+}
+
+func (w *recordWriter) Write(r PRRecord) {
+	if w.err == nil {
+		w.err = w.enc.Encode(r)
+	}
+}
+
+func (w *recordWriter) Err() error {
+	return w.err
+}
+
+// 集計には使わないレビュー・コミットの情報も取得して PR の生データを組み立てる
+func (a *analyzer) prRecord(ctx context.Context, repoName string, pr *github.PullRequest, r prResult, deployedAt *time.Time, reasons []string) PRRecord {
+	rec := PRRecord{
+		Repo:           repoName,
+		Number:         pr.GetNumber(),
+		Title:          pr.GetTitle(),
+		URL:            pr.GetHTMLURL(),
+		Author:         r.Author,
+		AuthorLogin:    pr.GetUser().GetLogin(),
+		Teams:          a.membership[r.Author],
+		Labels:         []string{},
+		Reviewers:      []string{},
+		HeadRef:        pr.GetHead().GetRef(),
+		BaseRef:        pr.GetBase().GetRef(),
+		MergeCommitSHA: pr.GetMergeCommitSHA(),
+		CreatedAt:      pr.GetCreatedAt().Time,
+		MergedAt:       pr.GetMergedAt().Time,
+		DeployedAt:     deployedAt,
+		Additions:      pr.GetAdditions(),
+		Deletions:      pr.GetDeletions(),
+		ChangedFiles:   pr.GetChangedFiles(),
+		Commits:        pr.GetCommits(),
+		ChangeType:     r.ChangeType,
+		MergedBy:       pr.GetMergedBy().GetLogin(),
+		Failure:        r.IsFix,
+		FailureReasons: reasons,
+		IsRevert:       r.IsRevert,
+		QuickRevertOf:  r.RevertedBy,
+		Reverts:        r.Reverts,
+		RevertedAuthor: r.RevertedAuthor,
+		IsReland:       r.IsReland,
+		RelandOf:       r.RelandOf,
+		Incidents:      r.Incidents,
+	}
+	if r.Hygiene >= 0 {
+		h := r.Hygiene
+		rec.Hygiene = &h
+	}
+	if !r.RevertedShipped.IsZero() {
+		t := r.RevertedShipped
+		rec.OriginShipped = &t
+	}
+	for _, l := range pr.Labels {
+		rec.Labels = append(rec.Labels, l.GetName())
+	}
+	if r.HasLeadTime {
+		h := r.LeadTime.Hours()
+		rec.LeadTimeHours = &h
+	}
+	if len(r.DeployLags) > 0 {
+		rec.EnvDeployedAt = make(map[string]time.Time)
+		for env, lag := range r.DeployLags {
+			rec.EnvDeployedAt[env] = rec.MergedAt.Add(lag)
+		}
+	}
+
+	// コミットの作成日時（processPR で取得済み。取得できなかった場合は空）
+	rec.CommitTimes = r.CommitTimes
+	for _, t := range r.CommitTimes {
+		if rec.FirstCommitAt == nil || t.Before(*rec.FirstCommitAt) {
+			rec.FirstCommitAt = &t
+		}
+	}
+
+	// レビュー（通常の解析で取得済みならそれを使う）
+	rs := r.Reviews
+	if rs == nil {
+		if fetched, err := a.fetchReviews(ctx, repoName, pr); err == nil {
+			rs = &fetched
+		}
+	}
+	if rs != nil {
+		rec.ReviewsFetched = true
+		rec.Reviewers = append(rec.Reviewers, rs.Reviewers...)
+		rec.Requested = rs.Requested
+		rec.ReviewerFirst = rs.FirstBy
+		rec.FirstReviewAt = rs.FirstReviewAt
+		rec.ApprovedAt = rs.ApprovedAt
+	}
+
+	// タイムライン（GitHub のリポジトリのみ。取得できなかった場合は空）
+	if _, _, isForge := splitForgeRepo(repoName); a.timeline && !isForge {
+		rec.Timeline, _ = a.fetchTimeline(ctx, repoName, pr.GetNumber())
+	}
+	return rec
+}
+
+// export サブコマンド本体。out が "-" なら標準出力に書く
+func runExport(ctx context.Context, a *analyzer, repos []string, out string) error {
+	n := 0
+	err := writeReportFile(out, func(w io.Writer) error {
+		rw := newRecordWriter(w)
+		collectPRRecords(ctx, a, repos, os.Stderr, func(r PRRecord) {
+			rw.Write(r)
+			n++
+		})
+		return rw.Err()
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "📦 Exported %d PRs\n", n)
+	return nil
+}
@@ -0,0 +1,38 @@
+package dora
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// PR テンプレートのチェックボックス（"- [x] This is an incident fix"）
+var checkedBoxPattern = regexp.MustCompile(`^\s*[-*]\s*\[[xX]\]\s*(.+)$`)
+
+// 作成者が明示した失敗マーカーを持つか
+// マーカーは「チェック済みのチェックボックスの文言」か「[incident] のようなタグ」で指定する
+func hasFailureMarker(pr *github.PullRequest, markers []string) bool {
+	if len(markers) == 0 {
+		return false
+	}
+	body := pr.GetBody()
+	text := strings.ToLower(pr.GetTitle() + "\n" + body)
+	for _, line := range strings.Split(body, "\n") {
+		m := checkedBoxPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		for _, marker := range markers {
+			if strings.Contains(strings.ToLower(m[1]), strings.ToLower(marker)) {
+				return true
+			}
+		}
+	}
+	for _, marker := range markers {
+		if strings.HasPrefix(marker, "[") && strings.Contains(text, strings.ToLower(marker)) {
+			return true
+		}
+	}
+	return false
+}
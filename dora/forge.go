@@ -0,0 +1,292 @@
+package dora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// GitHub 以外のホスティングサービス。--repos に "gitlab:group/project" のように接頭辞を付けるか、--provider で指定する
+// マージされた変更は github.PullRequest の形に直して渡すので、失敗判定・衛生スコア・ラベルなどは GitHub と同じ規則で求まる
+type forge interface {
+	Name() string
+	// from〜to に branch（空ならすべてのブランチ）へマージされた PR（MR）を fn に渡し、件数を返す
+	MergedPRs(ctx context.Context, repo, branch string, from, to time.Time, fn func(*github.PullRequest)) (int, error)
+	// リポジトリの既定ブランチ
+	DefaultBranch(ctx context.Context, repo string) (string, error)
+	// 作成者以外のレビュー（承認は APPROVED）
+	Reviews(ctx context.Context, repo string, pr *github.PullRequest) ([]*github.PullRequestReview, error)
+	// PR に含まれるコミットの作成日時
+	Commits(ctx context.Context, repo string, pr *github.PullRequest) ([]time.Time, error)
+	// from 以降に終わったデプロイと、それぞれで出荷された PR 番号
+	Deployments(ctx context.Context, repo string, from time.Time) ([]forgeDeployment, error)
+}
+
+type forgeDeployment struct {
+	deployment
+	PRs []int // 出荷した PR が分からないサービスは nil（マージ後、最初に成功したデプロイで出荷されたとみなす）
+}
+
+// --provider の値。github 以外は接頭辞の無いリポジトリを "<provider>:<owner>/<repo>" として扱う
+var providerNames = []string{"github", "gitlab", "bitbucket", "gitea"}
+
+func providerRepos(provider, owner string, repos []string) ([]string, error) {
+	if !slices.Contains(providerNames, provider) {
+		return nil, fmt.Errorf("unsupported --provider %q (want one of %s)", provider, strings.Join(providerNames, ", "))
+	}
+	if provider == "github" {
+		return repos, nil
+	}
+	out := make([]string, len(repos))
+	for i, repo := range repos {
+		switch _, _, ok := splitForgeRepo(repo); {
+		case ok:
+			out[i] = repo
+		case strings.Contains(repo, "/"):
+			out[i] = provider + ":" + repo
+		default:
+			out[i] = provider + ":" + owner + "/" + repo
+		}
+	}
+	return out, nil
+}
+
+// レビューした人を依頼の残りから外す（GitHub と同じく、残るのは応答しなかった相手）
+func dropReviewed(pr *github.PullRequest, reviews []*github.PullRequestReview) {
+	reviewed := make(map[string]bool)
+	for _, r := range reviews {
+		reviewed[r.GetUser().GetLogin()] = true
+	}
+	requested := pr.RequestedReviewers[:0:0]
+	for _, u := range pr.RequestedReviewers {
+		if !reviewed[u.GetLogin()] {
+			requested = append(requested, u)
+		}
+	}
+	pr.RequestedReviewers = requested
+}
+
+// "gitlab:group/project" をサービスとプロジェクトに分ける（接頭辞が無ければ GitHub のリポジトリ）
+func splitForgeRepo(repo string) (name, project string, ok bool) {
+	name, project, ok = strings.Cut(repo, ":")
+	if !ok || name == "" || project == "" {
+		return "", "", false
+	}
+	return name, project, true
+}
+
+func (a *analyzer) forgeFor(repo string) (forge, string, bool) {
+	name, project, ok := splitForgeRepo(repo)
+	if !ok {
+		return nil, "", false
+	}
+	f, ok := a.forges[name]
+	return f, project, ok
+}
+
+// GitHub 以外のリポジトリを解析する。取得した PR はスナップショットの再集計と同じ経路（PRRecord → prResult）で集計する
+// --max-prs のサンプリングと、取り消しの連鎖・コミット単位のリードタイムは GitHub のリポジトリだけで求める
+func (a *analyzer) analyzeForgeRepo(ctx context.Context, f forge, repoName, project string, turn <-chan struct{}) int {
+	repoStats := &Stats{}
+	repoCtx, repoSpan := a.tracer.Start(ctx, "dora.repo", map[string]any{"dora.repo": repoName, "dora.forge": f.Name()})
+	defer repoSpan.End()
+	from, to := a.window()
+
+	// デプロイソースを指定した実行では、そのサービスのデプロイを使う
+	// デプロイを記録しないサービスは、デプロイソースが無い場合と同じくマージまでをリードタイムとする
+	var index *deployIndex
+	var deployments []deployment
+	shipped := make(map[int][]deployment) // PR 番号 -> 出荷したデプロイ
+	var unmapped []deployment             // 出荷した PR が分からない成功デプロイ
+	var fds []forgeDeployment
+	var err error
+	if a.deploys != nil {
+		fds, err = f.Deployments(repoCtx, project, from)
+	}
+	if errors.Is(err, errNoForgeDeployments) {
+		log.Printf("⚠️  %s: %s has no deployments; lead time ends at merge for this repository", repoName, f.Name())
+	} else if a.deploys != nil {
+		if err != nil {
+			log.Printf("⚠️  %s: failed to load deployments from %s: %v", repoName, f.Name(), err)
+			repoSpan.SetError(err)
+		}
+		for _, fd := range fds {
+			deployments = append(deployments, fd.deployment)
+			switch {
+			case fd.Failed:
+			case fd.PRs == nil:
+				unmapped = append(unmapped, fd.deployment)
+			default:
+				for _, n := range fd.PRs {
+					shipped[n] = append(shipped[n], fd.deployment)
+				}
+			}
+		}
+		index, _ = a.deployStats(repoName, repoStats, deployments)
+	}
+
+	var prs []*github.PullRequest
+	var found int
+	branch, err := a.baseBranch(repoCtx, repoName)
+	if err == nil {
+		found, err = f.MergedPRs(repoCtx, project, branch, from, to, func(pr *github.PullRequest) {
+			prs = append(prs, pr)
+		})
+	}
+	if err != nil {
+		log.Printf("⚠️  %s: failed to list merged changes from %s: %v", repoName, f.Name(), err)
+		repoSpan.SetError(err)
+	}
+	repoSpan.SetAttr("dora.merged_prs", found)
+	for _, pr := range prs {
+		shipped[pr.GetNumber()] = append(shipped[pr.GetNumber()], firstAfterMerge(unmapped, pr.GetMergedAt().Time)...)
+	}
+
+	// レビューの取得は並列に行い、集計は一覧の順に行う
+	recs := make([]PRRecord, len(prs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(a.workers, len(prs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				recs[i] = a.forgeRecord(repoCtx, f, repoName, project, index, prs[i], shipped[prs[i].GetNumber()])
+			}
+		}()
+	}
+	for i := range prs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if turn != nil {
+		<-turn
+	}
+	// 母集団は絞り込みに残った PR の数（GitHub のリポジトリと同じ定義）
+	eligible := 0
+	for _, rec := range recs {
+		r := a.replayResult(rec)
+		if (len(a.members) > 0 && !a.members[r.Author]) || a.excludedUser(rec.AuthorLogin) || !a.labelsAllowed(rec.Labels) {
+			continue
+		}
+		eligible++
+		a.checkReviewSLA(repoName, rec.Title, rec.URL, r)
+		duplicate := a.record(repoStats, rec.MergeCommitSHA, r)
+		if a.onRecord != nil {
+			rec.Duplicate = duplicate
+			a.mu.Lock()
+			a.onRecord(rec)
+			a.mu.Unlock()
+		}
+	}
+	a.addRepo(repoName, repoStats, eligible)
+	if a.onRepo != nil {
+		a.onRepo(snapshotRepo{Name: repoName, Population: eligible, Deployments: deployments})
+	}
+	return found
+}
+
+// 環境ごとに、マージ以降で最初のデプロイ
+func firstAfterMerge(deployments []deployment, mergedAt time.Time) []deployment {
+	first := make(map[string]deployment)
+	for _, d := range deployments {
+		if d.Time.Before(mergedAt) {
+			continue
+		}
+		if cur, ok := first[d.Environment]; !ok || d.Time.Before(cur.Time) {
+			first[d.Environment] = d
+		}
+	}
+	var out []deployment
+	for _, env := range sortedKeys(first) {
+		out = append(out, first[env])
+	}
+	return out
+}
+
+// PR の生データを組み立てる。リードタイムの終点は、その PR を出荷したデプロイのうち最初のもの
+func (a *analyzer) forgeRecord(ctx context.Context, f forge, repoName, project string, index *deployIndex, pr *github.PullRequest, shipped []deployment) PRRecord {
+	author := a.aliases.canonical(pr.GetUser().GetLogin())
+	changeType := ""
+	if a.conventional || a.conventionalCFR {
+		if changeType = conventionalType(pr.GetTitle()); changeType == "" {
+			changeType = "other"
+		}
+	}
+	r, reasons := a.classifyPR(repoName, index, pr, author, changeType)
+
+	// レビューは必要なときだけ取得する（export・collect では常に取得する）
+	fetched := false
+	rs := reviewSummary{}
+	if a.needsReviews() || a.onRecord != nil {
+		reviews, err := f.Reviews(ctx, project, pr)
+		if err != nil {
+			log.Printf("⚠️  %s#%d: failed to load reviews: %v", repoName, pr.GetNumber(), err)
+		} else {
+			rs, fetched = a.summarizeReviews(pr, reviews), true
+		}
+	}
+	// prRecord が GitHub から取り直さないよう、取得しなかった場合も空で渡す
+	r.Reviews = &rs
+
+	// コミット単位のリードタイム・export・新メンバーの立ち上がりに使う
+	if _, newMember := a.newMembers[author]; a.commitLeadTime || a.onRecord != nil || newMember {
+		times, err := f.Commits(ctx, project, pr)
+		if err != nil {
+			log.Printf("⚠️  %s#%d: failed to load commits: %v", repoName, pr.GetNumber(), err)
+		}
+		r.CommitTimes = times
+	}
+
+	var deployedAt *time.Time
+	if index != nil {
+		var first *deployment
+		for i, d := range shipped {
+			// 環境ごとの遅延は絞り込み前の全環境で求める（GitHub と同じ）
+			if lag := d.Time.Sub(r.MergedAt); r.DeployLags == nil {
+				r.DeployLags = map[string]time.Duration{d.Environment: lag}
+			} else if cur, ok := r.DeployLags[d.Environment]; !ok || lag < cur {
+				r.DeployLags[d.Environment] = lag
+			}
+			if a.env != "" && !environmentMatches(a.env, d.Environment) {
+				continue
+			}
+			if first == nil || d.Time.Before(first.Time) {
+				first = &shipped[i]
+			}
+		}
+		if first != nil {
+			r.LeadTime = first.Time.Sub(r.CreatedAt)
+			deployedAt = &first.Time
+			r.Incidents = index.IncidentsFor(first)
+		} else {
+			r.HasLeadTime = false
+		}
+	}
+
+	rec := a.prRecord(ctx, repoName, pr, r, deployedAt, reasons)
+	rec.ReviewsFetched = fetched
+	return rec
+}
+
+func (a *analyzer) validateForgeRepos(repos []string) error {
+	for _, repo := range repos {
+		name, _, ok := splitForgeRepo(repo)
+		if !ok {
+			continue
+		}
+		if _, known := a.forges[name]; !known {
+			return fmt.Errorf("unsupported repository prefix %q in %q (want gitlab:group/project, bitbucket:workspace/repo, or gitea:owner/repo with --gitea-url)", name, repo)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package dora
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// デプロイを止めている期間（年末のコードフリーズなど）。起動時に読み込んだ後は参照のみ
+// 祝日と同じく、デプロイ頻度の分母とデプロイ間隔（営業日）から除く
+var freezes []freezeWindow
+
+type freezeWindow struct {
+	Name     string
+	From, To string // YYYY-MM-DD（両端を含む）
+}
+
+// "[名前=]YYYY-MM-DD..YYYY-MM-DD" のカンマ区切り
+func parseFreezes(s string) ([]freezeWindow, error) {
+	var out []freezeWindow
+	for _, item := range splitList(s) {
+		name, span, ok := strings.Cut(item, "=")
+		if !ok {
+			name, span = "", item
+		}
+		from, to, ok := strings.Cut(span, "..")
+		start, err1 := time.Parse("2006-01-02", strings.TrimSpace(from))
+		end, err2 := time.Parse("2006-01-02", strings.TrimSpace(to))
+		if !ok || err1 != nil || err2 != nil || end.Before(start) {
+			return nil, fmt.Errorf("invalid freeze %q (want [name=]YYYY-MM-DD..YYYY-MM-DD)", item)
+		}
+		w := freezeWindow{Name: strings.TrimSpace(name), From: start.Format("2006-01-02"), To: end.Format("2006-01-02")}
+		if w.Name == "" {
+			w.Name = "freeze"
+		}
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+func isFrozen(t time.Time) bool {
+	date := t.Format("2006-01-02")
+	for _, f := range freezes {
+		if date >= f.From && date <= f.To {
+			return true
+		}
+	}
+	return false
+}
+
+// from〜to（YYYY-MM-DD、両端を含む）でデプロイが無くて当然の日数（祝日とフリーズ。重なりは 1 日）
+func excludedDays(from, to string) (holidayDays, frozenDays int) {
+	start, err1 := time.Parse("2006-01-02", from)
+	end, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil {
+		return 0, 0
+	}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		switch {
+		case isFrozen(d):
+			frozenDays++
+		case holidays.isHoliday(d):
+			holidayDays++
+		}
+	}
+	return holidayDays, frozenDays
+}
+
+// 期間に掛かるフリーズ（注記用）
+func freezesBetween(from, to string) []freezeWindow {
+	var out []freezeWindow
+	for _, f := range freezes {
+		if f.From <= to && f.To >= from {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (f freezeWindow) String() string {
+	return fmt.Sprintf("%s (%s to %s)", f.Name, f.From, f.To)
+}
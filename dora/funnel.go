@@ -0,0 +1,137 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// 期間内に作成された PR が各段階まで進んだ数（期間末の時点）。
+// 各段階は前の段階をすべて通った PR だけを数える
+type funnelStats struct {
+	Opened     int
+	Ready      int // ドラフトでない（ドラフトから外された、または最初からレビュー可）
+	Reviewed   int // 作成者以外の最初のレビューがあった
+	Approved   int
+	Merged     int
+	Unapproved int // 承認なしでマージされた（ファネルの外）
+	Closed     int // マージされずに閉じられた
+}
+
+func (f *funnelStats) add(o *funnelStats) {
+	f.Opened += o.Opened
+	f.Ready += o.Ready
+	f.Reviewed += o.Reviewed
+	f.Approved += o.Approved
+	f.Merged += o.Merged
+	f.Unapproved += o.Unapproved
+	f.Closed += o.Closed
+}
+
+// 期間内に作成された PR を検索し、レビュー状況を取得して各段階の到達数を数える。
+// 期間末より後の出来事は数えない
+func (a *analyzer) repoFunnel(ctx context.Context, repoName string) *funnelStats {
+	_, end := a.window()
+	f := &funnelStats{}
+	branch, err := a.baseBranch(ctx, repoName)
+	if err != nil {
+		log.Printf("⚠️  %s: funnel skipped: %v", repoName, err)
+		return f
+	}
+	var mu sync.Mutex
+	issues := make(chan *github.Issue, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < a.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for issue := range issues {
+				pr := &github.PullRequest{Number: issue.Number, User: issue.User}
+				rs, err := a.fetchReviews(ctx, repoName, pr)
+				if err != nil {
+					log.Printf("⚠️  %s#%d: failed to load reviews: %s", repoName, issue.GetNumber(), describeAPIError(err, a.owner))
+					continue
+				}
+				mergedAt := issue.GetPullRequestLinks().GetMergedAt()
+				merged := !mergedAt.IsZero() && mergedAt.Before(end)
+
+				// マージ済みならレビュー可になっていたはず
+				ready := !issue.GetDraft() || merged
+				reviewed := ready && rs.FirstReviewAt != nil && rs.FirstReviewAt.Before(end)
+				approved := reviewed && rs.ApprovedAt != nil && rs.ApprovedAt.Before(end)
+
+				mu.Lock()
+				f.Opened++
+				if ready {
+					f.Ready++
+				}
+				if reviewed {
+					f.Reviewed++
+				}
+				if approved {
+					f.Approved++
+				}
+				switch {
+				case merged && approved:
+					f.Merged++
+				case merged:
+					f.Unapproved++
+				case issue.GetState() == "closed" && issue.GetClosedAt().Before(end):
+					f.Closed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	query := fmt.Sprintf("repo:%s/%s is:pr", a.owner, repoName)
+	if branch != "" {
+		query += " base:" + branch
+	}
+	_, err = streamPRs(ctx, a.client, query, "created", a.from, a.to, func(issue *github.Issue) {
+		author := a.aliases.canonical(issue.GetUser().GetLogin())
+		if (len(a.members) > 0 && !a.members[author]) || a.excludedAccount(issue.GetUser()) {
+			return
+		}
+		labels := make([]string, 0, len(issue.Labels))
+		for _, l := range issue.Labels {
+			labels = append(labels, l.GetName())
+		}
+		if !a.labelsAllowed(labels) {
+			return
+		}
+		issues <- issue
+	})
+	if err != nil {
+		log.Printf("⚠️  %s: funnel search failed: %s", repoName, describeAPIError(err, a.owner))
+	}
+	close(issues)
+	wg.Wait()
+	return f
+}
+
+// 各段階の到達数と、前の段階からの脱落数
+func printFunnelSummary(team *Stats, repos map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🔻 PR Funnel (PRs opened in the period)\n%s\n", line, line)
+	fmt.Printf("%-25s | %6s | %11s | %11s | %11s | %11s | %10s | %6s\n", "ENTITY", "Opened", "Ready", "Reviewed", "Approved", "Merged", "Unapproved", "Closed")
+	row := func(name string, f *funnelStats) {
+		stage := func(n, prev int) string {
+			return fmt.Sprintf("%d (-%d)", n, prev-n)
+		}
+		fmt.Printf("%-25s | %6d | %11s | %11s | %11s | %11s | %10d | %6d\n", name, f.Opened,
+			stage(f.Ready, f.Opened), stage(f.Reviewed, f.Ready), stage(f.Approved, f.Reviewed), stage(f.Merged, f.Approved), f.Unapproved, f.Closed)
+	}
+	row("OVERALL TEAM", team.Funnel)
+	for _, name := range sortedKeys(repos) {
+		if f := repos[name].Funnel; f != nil {
+			row(name, f)
+		}
+	}
+	fmt.Println("(-N) = PRs that reached the previous stage but not this one by the end of the period")
+	fmt.Println("Unapproved = merged without passing review/approval; Closed = closed without merging")
+}
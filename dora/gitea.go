@@ -0,0 +1,230 @@
+package dora
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// Gitea / Forgejo の REST API v1。GitHub とほぼ同じ形なので、PR はフィールドをそのまま写す
+// リポジトリは "gitea:owner/repo"
+type giteaForge struct {
+	baseURL string // https://gitea.example.com/api/v1
+	token   string
+	client  *http.Client
+}
+
+func newGiteaForge(baseURL, token string, client *http.Client) *giteaForge {
+	base := strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(base, "/api/v1") {
+		base += "/api/v1"
+	}
+	return &giteaForge{baseURL: base, token: token, client: client}
+}
+
+func (g *giteaForge) Name() string { return "gitea" }
+
+// 1 ページの件数（Gitea の既定の上限は 50）
+const giteaPageSize = 50
+
+type giteaUser struct {
+	Login string `json:"login"`
+}
+
+type giteaPR struct {
+	Number         int                     `json:"number"`
+	Title          string                  `json:"title"`
+	Body           string                  `json:"body"`
+	User           giteaUser               `json:"user"`
+	Merged         bool                    `json:"merged"`
+	MergedAt       *time.Time              `json:"merged_at"`
+	MergedBy       *giteaUser              `json:"merged_by"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+	MergeCommitSHA string                  `json:"merge_commit_sha"`
+	HTMLURL        string                  `json:"html_url"`
+	Additions      int                     `json:"additions"` // Gitea 1.21 以降
+	Deletions      int                     `json:"deletions"`
+	ChangedFiles   int                     `json:"changed_files"`
+	Labels         []struct{ Name string } `json:"labels"`
+	Head           struct{ Ref string }    `json:"head"`
+	Base           struct{ Ref string }    `json:"base"`
+	Reviewers      []giteaUser             `json:"requested_reviewers"`
+}
+
+// page を 1 から増やし、件数が 1 ページに満たなくなるまで読む。fn が false を返したら打ち切る
+func (g *giteaForge) paginate(ctx context.Context, path string, query url.Values, fn func(body []byte) (n int, more bool, err error)) error {
+	query.Set("limit", strconv.Itoa(giteaPageSize))
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+path+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		if g.token != "" {
+			req.Header.Set("Authorization", "token "+g.token)
+		}
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("gitea: GET %s: %s", path, resp.Status)
+		}
+		n, more, err := fn(body)
+		if err != nil || !more || n < giteaPageSize {
+			return err
+		}
+	}
+}
+
+func giteaRepoPath(repo string) string {
+	owner, name, _ := strings.Cut(repo, "/")
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+}
+
+// 閉じた PR を更新日時の新しい順に from まで読み、マージ日時で選ぶ
+// 一覧はマージ先のブランチでは絞れないので、base.ref で選ぶ
+func (g *giteaForge) MergedPRs(ctx context.Context, repo, branch string, from, to time.Time, fn func(*github.PullRequest)) (int, error) {
+	q := url.Values{"state": {"closed"}, "sort": {"recentupdate"}}
+	found := 0
+	err := g.paginate(ctx, giteaRepoPath(repo)+"/pulls", q, func(body []byte) (int, bool, error) {
+		var prs []giteaPR
+		if err := json.Unmarshal(body, &prs); err != nil {
+			return 0, false, err
+		}
+		for _, pr := range prs {
+			if pr.UpdatedAt.Before(from) {
+				return len(prs), false, nil
+			}
+			if !pr.Merged || pr.MergedAt == nil || pr.MergedAt.Before(from) || !pr.MergedAt.Before(to) {
+				continue
+			}
+			if branch != "" && pr.Base.Ref != branch {
+				continue
+			}
+			found++
+			fn(pr.pullRequest())
+		}
+		return len(prs), true, nil
+	})
+	return found, err
+}
+
+func (g *giteaForge) DefaultBranch(ctx context.Context, repo string) (string, error) {
+	var r struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	err := g.paginate(ctx, giteaRepoPath(repo), url.Values{}, func(body []byte) (int, bool, error) {
+		return 0, false, json.Unmarshal(body, &r)
+	})
+	return r.DefaultBranch, err
+}
+
+func (pr giteaPR) pullRequest() *github.PullRequest {
+	out := &github.PullRequest{
+		Number:         github.Int(pr.Number),
+		Title:          github.String(pr.Title),
+		Body:           github.String(pr.Body),
+		User:           &github.User{Login: github.String(pr.User.Login)},
+		CreatedAt:      &github.Timestamp{Time: pr.CreatedAt},
+		MergedAt:       &github.Timestamp{Time: *pr.MergedAt},
+		Head:           &github.PullRequestBranch{Ref: github.String(pr.Head.Ref)},
+		Base:           &github.PullRequestBranch{Ref: github.String(pr.Base.Ref)},
+		MergeCommitSHA: github.String(pr.MergeCommitSHA),
+		HTMLURL:        github.String(pr.HTMLURL),
+		Additions:      github.Int(pr.Additions),
+		Deletions:      github.Int(pr.Deletions),
+		ChangedFiles:   github.Int(pr.ChangedFiles),
+	}
+	if pr.MergedBy != nil {
+		out.MergedBy = &github.User{Login: github.String(pr.MergedBy.Login)}
+	}
+	for _, l := range pr.Labels {
+		out.Labels = append(out.Labels, &github.Label{Name: github.String(l.Name)})
+	}
+	for _, r := range pr.Reviewers {
+		out.RequestedReviewers = append(out.RequestedReviewers, &github.User{Login: github.String(r.Login)})
+	}
+	return out
+}
+
+// Gitea のレビューの状態を GitHub の名前に直す（依頼・下書きは除く）
+var giteaReviewStates = map[string]string{
+	"APPROVED":        "APPROVED",
+	"COMMENT":         "COMMENTED",
+	"REQUEST_CHANGES": "CHANGES_REQUESTED",
+}
+
+func (g *giteaForge) Reviews(ctx context.Context, repo string, pr *github.PullRequest) ([]*github.PullRequestReview, error) {
+	var out []*github.PullRequestReview
+	path := fmt.Sprintf("%s/pulls/%d/reviews", giteaRepoPath(repo), pr.GetNumber())
+	err := g.paginate(ctx, path, url.Values{}, func(body []byte) (int, bool, error) {
+		var reviews []struct {
+			User        giteaUser `json:"user"`
+			State       string    `json:"state"`
+			SubmittedAt time.Time `json:"submitted_at"`
+		}
+		if err := json.Unmarshal(body, &reviews); err != nil {
+			return 0, false, err
+		}
+		for _, rv := range reviews {
+			state, ok := giteaReviewStates[rv.State]
+			if !ok {
+				continue
+			}
+			out = append(out, &github.PullRequestReview{
+				User:        &github.User{Login: github.String(rv.User.Login)},
+				State:       github.String(state),
+				SubmittedAt: &github.Timestamp{Time: rv.SubmittedAt},
+			})
+		}
+		return len(reviews), true, nil
+	})
+	dropReviewed(pr, out)
+	return out, err
+}
+
+func (g *giteaForge) Commits(ctx context.Context, repo string, pr *github.PullRequest) ([]time.Time, error) {
+	var times []time.Time
+	path := fmt.Sprintf("%s/pulls/%d/commits", giteaRepoPath(repo), pr.GetNumber())
+	err := g.paginate(ctx, path, url.Values{}, func(body []byte) (int, bool, error) {
+		var commits []struct {
+			Commit struct {
+				Author struct {
+					Date time.Time `json:"date"`
+				} `json:"author"`
+			} `json:"commit"`
+		}
+		if err := json.Unmarshal(body, &commits); err != nil {
+			return 0, false, err
+		}
+		for _, c := range commits {
+			times = append(times, c.Commit.Author.Date)
+		}
+		return len(commits), true, nil
+	})
+	return times, err
+}
+
+// Gitea にはデプロイの API が無い
+func (g *giteaForge) Deployments(ctx context.Context, repo string, from time.Time) ([]forgeDeployment, error) {
+	return nil, errNoForgeDeployments
+}
+
+// デプロイを記録しないサービス。リードタイムはマージまで、デプロイ数はマージ数で求める
+var errNoForgeDeployments = errors.New("deployments are not available")
@@ -0,0 +1,293 @@
+package dora
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// GitLab（gitlab.com またはセルフホスト）の REST API v4
+// マージリクエストは iid を PR 番号として github.PullRequest に直す
+type gitlabForge struct {
+	baseURL string // https://gitlab.com/api/v4
+	token   string
+	client  *http.Client
+}
+
+func newGitLabForge(baseURL, token string, client *http.Client) *gitlabForge {
+	base := strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(base, "/api/v4") {
+		base += "/api/v4"
+	}
+	return &gitlabForge{baseURL: base, token: token, client: client}
+}
+
+func (g *gitlabForge) Name() string { return "gitlab" }
+
+type gitlabUser struct {
+	Username string `json:"username"`
+}
+
+type gitlabMR struct {
+	IID             int          `json:"iid"`
+	Title           string       `json:"title"`
+	Description     string       `json:"description"`
+	Author          gitlabUser   `json:"author"`
+	MergeUser       *gitlabUser  `json:"merge_user"`
+	MergedBy        *gitlabUser  `json:"merged_by"` // 古い GitLab は merge_user が無い
+	Reviewers       []gitlabUser `json:"reviewers"`
+	CreatedAt       time.Time    `json:"created_at"`
+	MergedAt        *time.Time   `json:"merged_at"`
+	Labels          []string     `json:"labels"`
+	SourceBranch    string       `json:"source_branch"`
+	TargetBranch    string       `json:"target_branch"`
+	MergeCommitSHA  string       `json:"merge_commit_sha"`
+	SquashCommitSHA string       `json:"squash_commit_sha"`
+	WebURL          string       `json:"web_url"`
+}
+
+type gitlabNote struct {
+	Body      string     `json:"body"`
+	Author    gitlabUser `json:"author"`
+	System    bool       `json:"system"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type gitlabDeployment struct {
+	ID          int    `json:"id"`
+	SHA         string `json:"sha"`
+	Ref         string `json:"ref"`
+	Status      string `json:"status"`
+	Environment struct {
+		Name string `json:"name"`
+	} `json:"environment"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// path と query の一覧を X-Next-Page がなくなるまで読み、ページごとに fn に渡す。fn が false を返したら打ち切る
+func (g *gitlabForge) paginate(ctx context.Context, path string, query url.Values, fn func(body []byte) (bool, error)) error {
+	query.Set("per_page", "100")
+	page := "1"
+	for page != "" {
+		query.Set("page", page)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+path+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		if g.token != "" {
+			req.Header.Set("PRIVATE-TOKEN", g.token)
+		}
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("gitlab: GET %s: %s", path, resp.Status)
+		}
+		more, err := fn(body)
+		if err != nil || !more {
+			return err
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return nil
+}
+
+// プロジェクトのパス（group/subgroup/project）は URL エンコードして ID の代わりに使える
+func gitlabProjectPath(project string) string {
+	return "/projects/" + url.PathEscape(project)
+}
+
+// 一覧はマージ日では絞れないので、更新日時（マージすると更新される）で絞ってからマージ日で選ぶ
+func (g *gitlabForge) MergedPRs(ctx context.Context, project, branch string, from, to time.Time, fn func(*github.PullRequest)) (int, error) {
+	q := url.Values{"state": {"merged"}, "updated_after": {from.UTC().Format(time.RFC3339)}, "order_by": {"updated_at"}, "sort": {"asc"}}
+	if branch != "" {
+		q.Set("target_branch", branch)
+	}
+	n := 0
+	err := g.paginate(ctx, gitlabProjectPath(project)+"/merge_requests", q, func(body []byte) (bool, error) {
+		var mrs []gitlabMR
+		if err := json.Unmarshal(body, &mrs); err != nil {
+			return false, err
+		}
+		for _, mr := range mrs {
+			if mr.MergedAt == nil || mr.MergedAt.Before(from) || !mr.MergedAt.Before(to) {
+				continue
+			}
+			n++
+			fn(mr.pullRequest())
+		}
+		return true, nil
+	})
+	return n, err
+}
+
+func (g *gitlabForge) DefaultBranch(ctx context.Context, project string) (string, error) {
+	var p struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	err := g.paginate(ctx, gitlabProjectPath(project), url.Values{}, func(body []byte) (bool, error) {
+		return false, json.Unmarshal(body, &p)
+	})
+	return p.DefaultBranch, err
+}
+
+// 失敗判定などの規則をそのまま使えるよう、GitHub の PR の形に直す
+func (mr gitlabMR) pullRequest() *github.PullRequest {
+	pr := &github.PullRequest{
+		Number:         github.Int(mr.IID),
+		Title:          github.String(mr.Title),
+		Body:           github.String(mr.Description),
+		User:           &github.User{Login: github.String(mr.Author.Username)},
+		CreatedAt:      &github.Timestamp{Time: mr.CreatedAt},
+		MergedAt:       &github.Timestamp{Time: *mr.MergedAt},
+		Head:           &github.PullRequestBranch{Ref: github.String(mr.SourceBranch)},
+		Base:           &github.PullRequestBranch{Ref: github.String(mr.TargetBranch)},
+		MergeCommitSHA: github.String(mr.MergeCommitSHA),
+		HTMLURL:        github.String(mr.WebURL),
+	}
+	// スカッシュした MR は、デフォルトブランチに入るのはスカッシュコミット
+	if mr.SquashCommitSHA != "" {
+		pr.MergeCommitSHA = github.String(mr.SquashCommitSHA)
+	}
+	merger := mr.MergeUser
+	if merger == nil {
+		merger = mr.MergedBy
+	}
+	if merger != nil {
+		pr.MergedBy = &github.User{Login: github.String(merger.Username)}
+	}
+	for _, l := range mr.Labels {
+		pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l)})
+	}
+	for _, r := range mr.Reviewers {
+		pr.RequestedReviewers = append(pr.RequestedReviewers, &github.User{Login: github.String(r.Username)})
+	}
+	return pr
+}
+
+// MR のアクティビティ（ノート）をレビューに直す。承認はシステムノート "approved this merge request"、
+// それ以外の人のコメントは COMMENTED。承認 API は日時を返さないのでノートから読む
+func (g *gitlabForge) Reviews(ctx context.Context, project string, pr *github.PullRequest) ([]*github.PullRequestReview, error) {
+	var out []*github.PullRequestReview
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", gitlabProjectPath(project), pr.GetNumber())
+	err := g.paginate(ctx, path, url.Values{"sort": {"asc"}, "order_by": {"created_at"}}, func(body []byte) (bool, error) {
+		var notes []gitlabNote
+		if err := json.Unmarshal(body, &notes); err != nil {
+			return false, err
+		}
+		for _, n := range notes {
+			state := "COMMENTED"
+			if n.System {
+				if !strings.HasPrefix(n.Body, "approved this merge request") {
+					continue
+				}
+				state = "APPROVED"
+			}
+			out = append(out, &github.PullRequestReview{
+				User:        &github.User{Login: github.String(n.Author.Username)},
+				State:       github.String(state),
+				SubmittedAt: &github.Timestamp{Time: n.CreatedAt},
+			})
+		}
+		return true, nil
+	})
+	dropReviewed(pr, out)
+	return out, err
+}
+
+func (g *gitlabForge) Commits(ctx context.Context, project string, pr *github.PullRequest) ([]time.Time, error) {
+	var times []time.Time
+	path := fmt.Sprintf("%s/merge_requests/%d/commits", gitlabProjectPath(project), pr.GetNumber())
+	err := g.paginate(ctx, path, url.Values{}, func(body []byte) (bool, error) {
+		var commits []struct {
+			AuthoredDate time.Time `json:"authored_date"`
+		}
+		if err := json.Unmarshal(body, &commits); err != nil {
+			return false, err
+		}
+		for _, c := range commits {
+			times = append(times, c.AuthoredDate)
+		}
+		return true, nil
+	})
+	return times, err
+}
+
+// 終わったデプロイ（success / failed）と、それぞれで出荷された MR
+func (g *gitlabForge) Deployments(ctx context.Context, project string, from time.Time) ([]forgeDeployment, error) {
+	var out []forgeDeployment
+	q := url.Values{"updated_after": {from.UTC().Format(time.RFC3339)}, "order_by": {"updated_at"}, "sort": {"asc"}}
+	err := g.paginate(ctx, gitlabProjectPath(project)+"/deployments", q, func(body []byte) (bool, error) {
+		var deps []gitlabDeployment
+		if err := json.Unmarshal(body, &deps); err != nil {
+			return false, err
+		}
+		for _, dep := range deps {
+			if dep.Status != "success" && dep.Status != "failed" {
+				continue
+			}
+			d := forgeDeployment{deployment: deployment{
+				SHA:         dep.SHA,
+				Time:        dep.UpdatedAt,
+				Environment: dep.Environment.Name,
+				Failed:      dep.Status == "failed",
+				Ref:         dep.Ref,
+			}}
+			if dep.FinishedAt != nil {
+				d.Time = *dep.FinishedAt
+			}
+			out = append(out, d)
+			if d.Failed {
+				continue
+			}
+			prs, err := g.deployedMRs(ctx, project, dep.ID)
+			if err != nil {
+				return false, err
+			}
+			out[len(out)-1].PRs = prs
+		}
+		return true, nil
+	})
+	deployments := make([]deployment, len(out))
+	for i := range out {
+		deployments[i] = out[i].deployment
+	}
+	markRollbacks(deployments)
+	for i := range out {
+		out[i].Rollback = deployments[i].Rollback
+	}
+	return out, err
+}
+
+// デプロイで初めて出荷された MR の iid
+func (g *gitlabForge) deployedMRs(ctx context.Context, project string, id int) ([]int, error) {
+	iids := []int{}
+	path := gitlabProjectPath(project) + "/deployments/" + strconv.Itoa(id) + "/merge_requests"
+	err := g.paginate(ctx, path, url.Values{}, func(body []byte) (bool, error) {
+		var mrs []gitlabMR
+		if err := json.Unmarshal(body, &mrs); err != nil {
+			return false, err
+		}
+		for _, mr := range mrs {
+			iids = append(iids, mr.IID)
+		}
+		return true, nil
+	})
+	return iids, err
+}
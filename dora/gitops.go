@@ -0,0 +1,252 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// GitOps リポジトリのマージを、アプリリポジトリのデプロイとして扱う
+// マニフェストの追加行に含まれるイメージタグ / コミット SHA でアプリの PR と突き合わせる
+type gitopsSource struct {
+	client     *github.Client
+	appClient  *github.Client // アプリリポジトリ用（Organization が異なりトークンを分ける場合）
+	appOwner   string
+	owner      string
+	repo       string
+	pathPrefix string
+	envPattern *regexp.Regexp // マニフェストのパスから環境名を取り出す（1 番目のキャプチャ）
+	tagPattern *regexp.Regexp // イメージタグからコミット SHA を取り出す（1 番目のキャプチャ）
+	from       string
+
+	once   sync.Once
+	merges []gitopsMerge
+	err    error
+
+	mu       sync.Mutex
+	resolved map[string]string // "repo@ref" -> フル SHA（解決できなければ空）
+}
+
+type gitopsMerge struct {
+	Number   int
+	MergedAt time.Time
+	Refs     map[string][]string // 環境名 -> 追加された参照
+}
+
+var shaPattern = regexp.MustCompile(`\b[0-9a-f]{7,40}\b`)
+
+// image: registry/org/app:v1.4.2 / tag: 2024.05.01-abc / newTag: v1.4.2（kustomize）の値
+var (
+	imageLinePattern = regexp.MustCompile(`^\s*-?\s*image:\s*["']?([^\s"'#]+)`)
+	tagLinePattern   = regexp.MustCompile(`^\s*-?\s*(?:tag|newTag|imageTag):\s*["']?([^\s"'#]+)`)
+)
+
+func newGitOpsSource(client *github.Client, appOwner, spec, pathPrefix, envPattern, tagPattern, from string) (*gitopsSource, error) {
+	owner, repo := appOwner, spec
+	if o, r, ok := strings.Cut(spec, "/"); ok {
+		owner, repo = o, r
+	}
+	if repo == "" {
+		return nil, fmt.Errorf("--gitops-repo is required for the gitops deploy source")
+	}
+	var envRe *regexp.Regexp
+	if envPattern != "" {
+		re, err := regexp.Compile(envPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --gitops-env-pattern: %w", err)
+		}
+		envRe = re
+	}
+	var tagRe *regexp.Regexp
+	if tagPattern != "" {
+		re, err := regexp.Compile(tagPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --gitops-tag-pattern: %w", err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, fmt.Errorf("--gitops-tag-pattern needs a capture group for the commit SHA: %s", tagPattern)
+		}
+		tagRe = re
+	}
+	return &gitopsSource{
+		client:     client,
+		appClient:  client,
+		appOwner:   appOwner,
+		owner:      owner,
+		repo:       repo,
+		pathPrefix: pathPrefix,
+		envPattern: envRe,
+		tagPattern: tagRe,
+		from:       from,
+		resolved:   make(map[string]string),
+	}, nil
+}
+
+func (g *gitopsSource) Name() string {
+	return "gitops:" + g.owner + "/" + g.repo
+}
+
+func (g *gitopsSource) Deployments(ctx context.Context, appRepo string) ([]deployment, error) {
+	g.once.Do(func() { g.err = g.load(ctx) })
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	var out []deployment
+	for _, m := range g.merges {
+		for env, refs := range m.Refs {
+			for _, ref := range refs {
+				sha, err := g.resolve(ctx, appRepo, ref)
+				if err != nil {
+					return out, err
+				}
+				if sha == "" {
+					continue
+				}
+				out = append(out, deployment{
+					SHA:         sha,
+					Time:        m.MergedAt,
+					Environment: env,
+					Ref:         fmt.Sprintf("%s/%s#%d", g.owner, g.repo, m.Number),
+				})
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// 期間開始以降（現在まで）にマージされた GitOps PR を読み込む
+// 期間末にマージされたアプリ PR も後続のデプロイで拾えるよう、終了日では切らない
+func (g *gitopsSource) load(ctx context.Context) error {
+	query := fmt.Sprintf("repo:%s/%s is:pr is:merged", g.owner, g.repo)
+	to := dayOf(time.Now())
+	var issues []*github.Issue
+	if _, err := streamMergedPRs(ctx, g.client, query, g.from, to, func(issue *github.Issue) {
+		issues = append(issues, issue)
+	}); err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		refs, err := g.addedRefs(ctx, issue.GetNumber())
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		g.merges = append(g.merges, gitopsMerge{
+			Number:   issue.GetNumber(),
+			MergedAt: issue.GetPullRequestLinks().GetMergedAt().Time,
+			Refs:     refs,
+		})
+	}
+	return nil
+}
+
+func (g *gitopsSource) addedRefs(ctx context.Context, number int) (map[string][]string, error) {
+	seen := make(map[string]bool)
+	refs := make(map[string][]string)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := g.client.PullRequests.ListFiles(ctx, g.owner, g.repo, number, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if g.pathPrefix != "" && !strings.HasPrefix(f.GetFilename(), g.pathPrefix) {
+				continue
+			}
+			env := ""
+			if g.envPattern != nil {
+				m := g.envPattern.FindStringSubmatch(f.GetFilename())
+				if len(m) < 2 {
+					continue
+				}
+				env = m[1]
+			}
+			for _, line := range strings.Split(f.GetPatch(), "\n") {
+				if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+					continue
+				}
+				add := func(ref string) {
+					if key := env + "@" + ref; !seen[key] {
+						seen[key] = true
+						refs[env] = append(refs[env], ref)
+					}
+				}
+				for _, ref := range shaPattern.FindAllString(line, -1) {
+					// 日付やバージョン番号などの数字だけの並びは除外
+					if strings.ContainsAny(ref, "abcdef") {
+						add(ref)
+					}
+				}
+				if ref := g.tagRef(line[1:]); ref != "" {
+					add(ref)
+				}
+			}
+		}
+		if resp.NextPage == 0 {
+			return refs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// image: / tag: の行のタグを参照に直す（タグが無ければ空）
+// --gitops-tag-pattern に合うタグは取り出した SHA、それ以外はアプリリポジトリの同名の git タグ
+func (g *gitopsSource) tagRef(line string) string {
+	var tag string
+	if m := imageLinePattern.FindStringSubmatch(line); m != nil {
+		image, _, _ := strings.Cut(m[1], "@") // ダイジェストは除く
+		// レジストリのポート（registry:5000/app）と区別するため、最後の / より後ろで分ける
+		_, tag, _ = strings.Cut(image[strings.LastIndex(image, "/")+1:], ":")
+	} else if m := tagLinePattern.FindStringSubmatch(line); m != nil {
+		tag = m[1]
+	}
+	if tag == "" || tag == "latest" {
+		return ""
+	}
+	if g.tagPattern != nil {
+		if m := g.tagPattern.FindStringSubmatch(tag); m != nil && m[1] != "" {
+			return m[1]
+		}
+	}
+	// SHA そのもののタグは SHA として拾い済み
+	if shaPattern.FindString(tag) == tag {
+		return ""
+	}
+	return "refs/tags/" + tag
+}
+
+// 参照（SHA または refs/tags/<tag>）をアプリリポジトリのコミットに解決する
+func (g *gitopsSource) resolve(ctx context.Context, appRepo, ref string) (string, error) {
+	key := appRepo + "@" + ref
+	g.mu.Lock()
+	sha, ok := g.resolved[key]
+	g.mu.Unlock()
+	if ok {
+		return sha, nil
+	}
+
+	sha, resp, err := g.appClient.Repositories.GetCommitSHA1(ctx, g.appOwner, appRepo, ref, "")
+	if err != nil {
+		// 別アプリのタグなど、このリポジトリに存在しない参照
+		if resp != nil && (resp.StatusCode == 404 || resp.StatusCode == 422) {
+			sha = ""
+		} else {
+			return "", err
+		}
+	}
+
+	g.mu.Lock()
+	g.resolved[key] = sha
+	g.mu.Unlock()
+	return sha, nil
+}
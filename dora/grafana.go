@@ -0,0 +1,166 @@
+package dora
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Grafana の JSON datasource（simpod-json-datasource）向けのエンドポイント。
+// 接続先 URL に <server>/grafana、ヘッダーに Authorization: Bearer <api_token> を設定する。
+// クエリの target は指標名（deployments_per_day など）、payload の entity で対象を選ぶ
+//
+//	{"entity": "overall"} / {"entity": "repo:api"} / {"entity": "team:payments"}
+func (s *metricsServer) grafanaRoutes(mux *http.ServeMux) {
+	// 接続テスト
+	mux.HandleFunc("GET /grafana/{$}", s.authed(func(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+		writeJSON(w, http.StatusOK, map[string]string{"tenant": t.Name})
+	}))
+	mux.HandleFunc("POST /grafana/metrics", s.authed(s.handleGrafanaMetrics))
+	mux.HandleFunc("POST /grafana/metric-payload-options", s.authed(s.handleGrafanaPayloadOptions))
+	mux.HandleFunc("POST /grafana/variable", s.authed(s.handleGrafanaPayloadOptions))
+	mux.HandleFunc("POST /grafana/query", s.authed(s.handleGrafanaQuery))
+}
+
+// 時系列にできる指標（statsSummary の JSON 名）
+var grafanaMetrics = []string{
+	"merged_prs", "deployments", "deployments_per_day", "avg_lead_time_hours", "median_lead_time_hours",
+	"p90_lead_time_hours", "cfr_percent", "failed_deployments", "rollbacks", "quick_revert_percent",
+	"unreviewed_percent", "self_merged_percent", "avg_hygiene_score", "incidents", "mttr_hours",
+}
+
+type grafanaOption struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+func (s *metricsServer) handleGrafanaMetrics(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	out := make([]map[string]any, 0, len(grafanaMetrics))
+	for _, m := range grafanaMetrics {
+		out = append(out, map[string]any{
+			"label": m,
+			"value": m,
+			"payloads": []map[string]any{
+				{"name": "entity", "label": "Entity", "type": "select", "reloadMetric": true},
+			},
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// payload の entity と変数クエリの候補（全体・リポジトリ・チーム）
+func (s *metricsServer) handleGrafanaPayloadOptions(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	opts := []grafanaOption{{Label: "Overall", Value: "overall"}}
+	for _, repo := range t.Repos {
+		opts = append(opts, grafanaOption{Label: "repo: " + repo, Value: "repo:" + repo})
+	}
+	for _, team := range t.Teams {
+		opts = append(opts, grafanaOption{Label: "team: " + team.Name, Value: "team:" + team.Name})
+	}
+	writeJSON(w, http.StatusOK, opts)
+}
+
+type grafanaQuery struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target  string `json:"target"`
+		RefID   string `json:"refId"`
+		Hide    bool   `json:"hide"`
+		Payload struct {
+			Entity string `json:"entity"`
+		} `json:"payload"`
+	} `json:"targets"`
+}
+
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"` // [値, UNIX ミリ秒]
+}
+
+// スナップショットごとに 1 点。時刻は集計期間の末日
+func (s *metricsServer) handleGrafanaQuery(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	var q grafanaQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid query: "+err.Error())
+		return
+	}
+	var since, until string
+	if !q.Range.From.IsZero() {
+		since = q.Range.From.UTC().Format("2006-01-02")
+	}
+	if !q.Range.To.IsZero() {
+		until = q.Range.To.UTC().Format("2006-01-02")
+	}
+	points, err := s.history(t, since, until)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	out := []grafanaSeries{}
+	for _, target := range q.Targets {
+		if target.Hide || target.Target == "" {
+			continue
+		}
+		entity := target.Payload.Entity
+		if entity == "" {
+			entity = "overall"
+		}
+		series := grafanaSeries{Target: target.Target, Datapoints: [][2]float64{}}
+		if entity != "overall" {
+			series.Target = entity + " " + target.Target
+		}
+		for _, p := range points {
+			sum, ok := p.entity(entity)
+			if !ok {
+				continue
+			}
+			v, ok := summaryValues(sum)[target.Target]
+			if !ok {
+				writeJSONError(w, http.StatusBadRequest, "unknown metric: "+target.Target)
+				return
+			}
+			end, err := parseDay(p.To)
+			if err != nil {
+				continue
+			}
+			series.Datapoints = append(series.Datapoints, [2]float64{v, float64(end.UnixMilli())})
+		}
+		out = append(out, series)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// "overall" / "repo:<name>" / "team:<name>" の集計
+func (p historyPoint) entity(name string) (statsSummary, bool) {
+	kind, key, _ := strings.Cut(name, ":")
+	switch kind {
+	case "overall":
+		return p.Overall, true
+	case "repo":
+		sum, ok := p.Repos[key]
+		return sum, ok
+	case "team":
+		sum, ok := p.Teams[key]
+		return sum, ok
+	}
+	return statsSummary{}, false
+}
+
+// 指標名（JSON 名）-> 値
+func summaryValues(sum statsSummary) map[string]float64 {
+	data, _ := json.Marshal(sum)
+	var raw map[string]float64
+	json.Unmarshal(data, &raw)
+	// omitempty で落ちた指標は 0
+	for _, m := range grafanaMetrics {
+		if _, ok := raw[m]; !ok {
+			raw[m] = 0
+		}
+	}
+	return raw
+}
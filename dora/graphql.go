@@ -0,0 +1,306 @@
+package dora
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// --api graphql 用。REST では PR ごとに本体・レビュー・コミットで 3 リクエスト以上かかるため、
+// 検索結果のページ単位でまとめて取得し、processPR からは取得済みのものを使う
+
+// 検索 1 ページ分。コミットは 100 件、レビューは 100 件まで（超える PR は REST で取り直す）
+const graphqlSearchQuery = `query($q: String!, $cursor: String) {
+  search(query: $q, type: ISSUE, first: 50, after: $cursor) {
+    issueCount
+    pageInfo { hasNextPage endCursor }
+    nodes {
+      ... on PullRequest {
+        number title body url isDraft createdAt mergedAt additions deletions changedFiles headRefName baseRefName
+        author { __typename login }
+        mergedBy { __typename login }
+        mergeCommit { oid message }
+        labels(first: 50) { nodes { name } }
+        commits(first: 100) { totalCount nodes { commit { authoredDate } } }
+        reviews(first: 100) { totalCount nodes { author { __typename login } state submittedAt } }
+        reviewRequests(first: 50) { nodes { requestedReviewer { ... on User { login } ... on Team { slug } } } }
+      }
+    }
+  }
+}`
+
+type graphqlLogin struct {
+	Typename string `json:"__typename"` // User / Bot / Organization など
+	Login    string `json:"login"`
+}
+
+// REST の User と同じ形に直す。GraphQL は App の bot を "[bot]" なしで返すので、REST に合わせて付ける
+func (l *graphqlLogin) user() *github.User {
+	if l == nil {
+		return nil
+	}
+	u := &github.User{Login: github.String(l.Login)}
+	if l.Typename == "Bot" {
+		u.Type = github.String("Bot")
+		if !strings.HasSuffix(l.Login, "[bot]") {
+			u.Login = github.String(l.Login + "[bot]")
+		}
+	}
+	return u
+}
+
+type graphqlPR struct {
+	Number       int           `json:"number"`
+	Title        string        `json:"title"`
+	Body         string        `json:"body"`
+	URL          string        `json:"url"`
+	IsDraft      bool          `json:"isDraft"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	MergedAt     time.Time     `json:"mergedAt"`
+	Additions    int           `json:"additions"`
+	Deletions    int           `json:"deletions"`
+	ChangedFiles int           `json:"changedFiles"`
+	HeadRefName  string        `json:"headRefName"`
+	BaseRefName  string        `json:"baseRefName"`
+	Author       *graphqlLogin `json:"author"`
+	MergedBy     *graphqlLogin `json:"mergedBy"`
+	MergeCommit  *struct {
+		OID     string `json:"oid"`
+		Message string `json:"message"`
+	} `json:"mergeCommit"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Commits struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Commit struct {
+				AuthoredDate time.Time `json:"authoredDate"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+	Reviews struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Author      *graphqlLogin `json:"author"`
+			State       string        `json:"state"`
+			SubmittedAt *time.Time    `json:"submittedAt"`
+		} `json:"nodes"`
+	} `json:"reviews"`
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer struct {
+				Login string `json:"login"`
+				Slug  string `json:"slug"`
+			} `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"reviewRequests"`
+}
+
+type graphqlSearchResult struct {
+	Data struct {
+		Search struct {
+			IssueCount int `json:"issueCount"`
+			PageInfo   struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []graphqlPR `json:"nodes"`
+		} `json:"search"`
+	} `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GraphQL で取得済みの PR（REST の型に直したもの）
+type prefetchedPR struct {
+	pr            *github.PullRequest
+	reviews       []*github.PullRequestReview // 件数が上限を超えた場合は nil（REST で取り直す）
+	commitTimes   []time.Time                 // 同上
+	commitMessage string                      // マージコミットのメッセージ（Conventional Commits の判定用）
+}
+
+// リポジトリごとの取得済み PR。ワーカーから並行して参照される
+type prefetchCache struct {
+	mu  sync.Mutex
+	prs map[string]*prefetchedPR // "repo#番号"
+}
+
+func (c *prefetchCache) put(repo string, p *prefetchedPR) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.prs == nil {
+		c.prs = make(map[string]*prefetchedPR)
+	}
+	c.prs[fmt.Sprintf("%s#%d", repo, p.pr.GetNumber())] = p
+}
+
+func (c *prefetchCache) get(repo string, num int) *prefetchedPR {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.prs[fmt.Sprintf("%s#%d", repo, num)]
+}
+
+// 集計し終えた PR、標本から外れた PR を捨てる
+func (c *prefetchCache) remove(repo string, num int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.prs, fmt.Sprintf("%s#%d", repo, num))
+}
+
+// リポジトリの処理が終わったら残りを捨てる（取得に失敗した PR など）
+func (c *prefetchCache) drop(repo string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.prs {
+		if strings.HasPrefix(key, repo+"#") {
+			delete(c.prs, key)
+		}
+	}
+}
+
+// REST の BaseURL から GraphQL のエンドポイントを求める（GHES は /api/v3/ → /api/graphql）
+func graphqlEndpoint(client *github.Client) string {
+	base := client.BaseURL.String()
+	if strings.HasSuffix(base, "/api/v3/") {
+		return strings.TrimSuffix(base, "/api/v3/") + "/api/graphql"
+	}
+	return base + "graphql"
+}
+
+func graphqlSearch(ctx context.Context, client *github.Client, query, cursor string) (*graphqlSearchResult, error) {
+	vars := map[string]any{"q": query}
+	if cursor != "" {
+		vars["cursor"] = cursor
+	}
+	body, err := json.Marshal(map[string]any{"query": graphqlSearchQuery, "variables": vars})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, graphqlEndpoint(client), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// 認証・リトライ・計測は REST と同じ http.Client（ミドルウェア込み）に任せる
+	resp, err := client.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("graphql: %s", resp.Status)
+	}
+	var result graphqlSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("graphql: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("graphql: %s: %s", result.Errors[0].Type, result.Errors[0].Message)
+	}
+	return &result, nil
+}
+
+// streamMergedPRs の GraphQL 版。検索の上限（1000 件）を超える場合は同じく期間を半分に分ける
+func streamMergedPRsGraphQL(ctx context.Context, client *github.Client, baseQuery, from, to string, fn func(*prefetchedPR)) (int, error) {
+	query := fmt.Sprintf("%s merged:%s", baseQuery, searchRange(from, to))
+	result, err := graphqlSearch(ctx, client, query, "")
+	if err != nil {
+		return 0, err
+	}
+	if result.Data.Search.IssueCount > searchResultLimit {
+		start, err1 := time.Parse("2006-01-02", from)
+		end, err2 := time.Parse("2006-01-02", to)
+		if err1 == nil && err2 == nil && end.After(start) {
+			mid := start.Add(end.Sub(start) / 2).Truncate(24 * time.Hour)
+			n1, err := streamMergedPRsGraphQL(ctx, client, baseQuery, from, mid.Format("2006-01-02"), fn)
+			if err != nil {
+				return n1, err
+			}
+			n2, err := streamMergedPRsGraphQL(ctx, client, baseQuery, mid.AddDate(0, 0, 1).Format("2006-01-02"), to, fn)
+			return n1 + n2, err
+		}
+		log.Printf("⚠️  More than %d PRs merged on %s; only the first %d are analyzed", searchResultLimit, from, searchResultLimit)
+	}
+
+	found := 0
+	for {
+		for _, node := range result.Data.Search.Nodes {
+			fn(node.prefetched())
+			found++
+		}
+		if !result.Data.Search.PageInfo.HasNextPage {
+			return found, nil
+		}
+		result, err = graphqlSearch(ctx, client, query, result.Data.Search.PageInfo.EndCursor)
+		if err != nil {
+			return found, err
+		}
+	}
+}
+
+// REST の PullRequest と同じ形に直す
+func (n graphqlPR) prefetched() *prefetchedPR {
+	pr := &github.PullRequest{
+		Number:       github.Int(n.Number),
+		Title:        github.String(n.Title),
+		Body:         github.String(n.Body),
+		HTMLURL:      github.String(n.URL),
+		Draft:        github.Bool(n.IsDraft),
+		CreatedAt:    &github.Timestamp{Time: n.CreatedAt},
+		MergedAt:     &github.Timestamp{Time: n.MergedAt},
+		Additions:    github.Int(n.Additions),
+		Deletions:    github.Int(n.Deletions),
+		ChangedFiles: github.Int(n.ChangedFiles),
+		Commits:      github.Int(n.Commits.TotalCount),
+		Head:         &github.PullRequestBranch{Ref: github.String(n.HeadRefName)},
+		Base:         &github.PullRequestBranch{Ref: github.String(n.BaseRefName)},
+	}
+	// 削除済みアカウント（ghost）は author が null になる
+	pr.User = n.Author.user()
+	pr.MergedBy = n.MergedBy.user()
+	p := &prefetchedPR{pr: pr}
+	if n.MergeCommit != nil {
+		pr.MergeCommitSHA = github.String(n.MergeCommit.OID)
+		p.commitMessage = n.MergeCommit.Message
+	}
+	for _, l := range n.Labels.Nodes {
+		pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l.Name)})
+	}
+	for _, rr := range n.ReviewRequests.Nodes {
+		if rr.RequestedReviewer.Login != "" {
+			pr.RequestedReviewers = append(pr.RequestedReviewers, &github.User{Login: github.String(rr.RequestedReviewer.Login)})
+		} else if rr.RequestedReviewer.Slug != "" {
+			pr.RequestedTeams = append(pr.RequestedTeams, &github.Team{Slug: github.String(rr.RequestedReviewer.Slug)})
+		}
+	}
+	if n.Commits.TotalCount <= len(n.Commits.Nodes) {
+		p.commitTimes = make([]time.Time, 0, len(n.Commits.Nodes))
+		for _, c := range n.Commits.Nodes {
+			p.commitTimes = append(p.commitTimes, c.Commit.AuthoredDate)
+		}
+	}
+	if n.Reviews.TotalCount <= len(n.Reviews.Nodes) {
+		p.reviews = make([]*github.PullRequestReview, 0, len(n.Reviews.Nodes))
+		for _, rv := range n.Reviews.Nodes {
+			review := &github.PullRequestReview{State: github.String(rv.State), User: rv.Author.user()}
+			if rv.SubmittedAt != nil {
+				review.SubmittedAt = &github.Timestamp{Time: *rv.SubmittedAt}
+			}
+			p.reviews = append(p.reviews, review)
+		}
+	}
+	return p
+}
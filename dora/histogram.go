@@ -0,0 +1,155 @@
+package dora
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --histogram の区間の上限（時間）。最後の区間は上限なし（起動時に --histogram-buckets で設定）
+var histogramBounds = []float64{1, 4, 24, 72, 168}
+
+// "1h,4h,1d,3d,1w"（d は日、w は週。ほかは time.ParseDuration の書式）
+func parseHistogramBuckets(spec string) ([]float64, error) {
+	var out []float64
+	for _, item := range splitList(spec) {
+		var d time.Duration
+		var err error
+		switch {
+		case strings.HasSuffix(item, "d") || strings.HasSuffix(item, "w"):
+			unit := 24 * time.Hour
+			if strings.HasSuffix(item, "w") {
+				unit *= 7
+			}
+			var n float64
+			n, err = strconv.ParseFloat(item[:len(item)-1], 64)
+			d = time.Duration(n * float64(unit))
+		default:
+			d, err = time.ParseDuration(item)
+		}
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid histogram bucket %q (want e.g. 30m, 4h, 1d or 1w)", item)
+		}
+		out = append(out, d.Hours())
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("--histogram-buckets has no values")
+	}
+	if !sort.Float64sAreSorted(out) {
+		return nil, fmt.Errorf("--histogram-buckets must be in increasing order")
+	}
+	return out, nil
+}
+
+// 1 → "1h"、72 → "3d"、168 → "1w"、0.5 → "30m"
+func formatBucketHours(h float64) string {
+	switch {
+	case h >= 168 && h == float64(int(h/168))*168:
+		return fmt.Sprintf("%dw", int(h/168))
+	case h >= 24 && h == float64(int(h/24))*24:
+		return fmt.Sprintf("%dd", int(h/24))
+	case h >= 1:
+		return strconv.FormatFloat(h, 'f', -1, 64) + "h"
+	}
+	return strconv.FormatFloat(h*60, 'f', -1, 64) + "m"
+}
+
+// "<1h", "1h-4h", ..., ">1w"
+func histogramLabels(bounds []float64) []string {
+	labels := make([]string, len(bounds)+1)
+	for i, b := range bounds {
+		if i == 0 {
+			labels[i] = "<" + formatBucketHours(b)
+			continue
+		}
+		labels[i] = formatBucketHours(bounds[i-1]) + "-" + formatBucketHours(b)
+	}
+	labels[len(bounds)] = ">" + formatBucketHours(bounds[len(bounds)-1])
+	return labels
+}
+
+// JSON のヒストグラムの区間。UpperHours は最後の区間だけ nil
+type histogramBucket struct {
+	Label      string   `json:"label"`
+	UpperHours *float64 `json:"upper_hours,omitempty"`
+	Count      float64  `json:"count"` // --lead-time-weight lines では変更行数の合計
+	Percent    float64  `json:"percent"`
+}
+
+// 分布を区間に分ける。分布が無ければ nil
+func digestHistogram(d *tdigest) []histogramBucket {
+	if d == nil || d.Count() == 0 {
+		return nil
+	}
+	counts := d.histogram(histogramBounds)
+	total := 0.0
+	for _, c := range counts {
+		total += c
+	}
+	labels := histogramLabels(histogramBounds)
+	out := make([]histogramBucket, len(counts))
+	for i, c := range counts {
+		out[i] = histogramBucket{Label: labels[i], Count: c, Percent: c / total * 100}
+		if i < len(histogramBounds) {
+			out[i].UpperHours = &histogramBounds[i]
+		}
+	}
+	return out
+}
+
+// 端末用の棒グラフ（最も多い区間を 40 文字）
+func printHistogram(title string, buckets []histogramBucket) {
+	fmt.Println(title)
+	peak := 0.0
+	for _, b := range buckets {
+		peak = max(peak, b.Percent)
+	}
+	for _, b := range buckets {
+		width := 0
+		if peak > 0 {
+			width = int(b.Percent / peak * 40)
+		}
+		fmt.Printf("  %-12s | %-40s | %5.1f%% (%.0f)\n", b.Label, strings.Repeat("█", width), b.Percent, b.Count)
+	}
+}
+
+func printHistogramSummary(sum reportSummary) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n📶 Duration Distribution\n%s\n", line, line)
+	entity := func(name string, s statsSummary) {
+		if s.LeadTimeHistogram != nil {
+			printHistogram(name+" · lead time", s.LeadTimeHistogram)
+		}
+		if s.FirstReviewHistogram != nil {
+			printHistogram(name+" · time to first review", s.FirstReviewHistogram)
+		}
+	}
+	entity("OVERALL TEAM", sum.Overall)
+	for _, name := range sortedKeys(sum.Repos) {
+		entity(name, sum.Repos[name])
+	}
+}
+
+func writeMarkdownHistograms(b *strings.Builder, sum reportSummary) {
+	b.WriteString("\n## 📶 Duration distribution\n")
+	entity := func(name string, s statsSummary) {
+		for _, h := range []struct {
+			title   string
+			buckets []histogramBucket
+		}{{"lead time", s.LeadTimeHistogram}, {"time to first review", s.FirstReviewHistogram}} {
+			if h.buckets == nil {
+				continue
+			}
+			fmt.Fprintf(b, "\n### %s · %s\n\n| Bucket | Share | Count |\n|---|---:|---:|\n", mdEscape(name), h.title)
+			for _, bk := range h.buckets {
+				fmt.Fprintf(b, "| %s | %.1f%% | %.0f |\n", bk.Label, bk.Percent, bk.Count)
+			}
+		}
+	}
+	entity("Overall", sum.Overall)
+	for _, name := range sortedKeys(sum.Repos) {
+		entity(name, sum.Repos[name])
+	}
+}
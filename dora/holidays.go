@@ -0,0 +1,119 @@
+package dora
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 祝日（YYYY-MM-DD -> 名前）。起動時に読み込んだ後は参照のみ
+var holidays = holidayCalendar{}
+
+type holidayCalendar map[string]string
+
+// 国コード（JP / US / GB など）
+var holidayCountryPattern = regexp.MustCompile(`^[A-Za-z]{2,3}$`)
+
+// --holidays の値（"JP,holidays.txt"）をファイルと国コードに分ける。存在するファイルを優先する
+func splitHolidaySources(spec string) (files, countries []string, err error) {
+	for _, s := range splitList(spec) {
+		if _, statErr := os.Stat(s); statErr == nil {
+			files = append(files, s)
+			continue
+		}
+		if !holidayCountryPattern.MatchString(s) {
+			return nil, nil, fmt.Errorf("holiday calendar %q is neither a file nor a country code (e.g. JP)", s)
+		}
+		countries = append(countries, strings.ToUpper(s))
+	}
+	return files, countries, nil
+}
+
+// 別のカレンダーの祝日を足す（同じ日は後の名前を使う）
+func (c holidayCalendar) merge(other holidayCalendar) {
+	for date, name := range other {
+		c[date] = name
+	}
+}
+
+// 1 行 1 日の祝日ファイル（"2025-05-05 こどもの日"。名前は省略可、# 以降はコメント）
+func loadHolidaysFile(path string) (holidayCalendar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cal := holidayCalendar{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		date, name, _ := strings.Cut(strings.Replace(text, ",", " ", 1), " ")
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid date %q", path, line, date)
+		}
+		cal[date] = strings.TrimSpace(name)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// 国の祝日を Nager.Date（https://date.nager.at）から取得する
+func fetchCountryHolidays(ctx context.Context, client *http.Client, country string, fromYear, toYear int) (holidayCalendar, error) {
+	cal := holidayCalendar{}
+	for year := fromYear; year <= toYear; year++ {
+		url := fmt.Sprintf("https://date.nager.at/api/v3/PublicHolidays/%d/%s", year, strings.ToUpper(country))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var days []struct {
+			Date      string `json:"date"`
+			LocalName string `json:"localName"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("holiday calendar %s/%d: %s", country, year, resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&days)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("holiday calendar %s/%d: %w", country, year, err)
+		}
+		for _, d := range days {
+			cal[d.Date] = d.LocalName
+		}
+	}
+	return cal, nil
+}
+
+func (c holidayCalendar) isHoliday(t time.Time) bool {
+	_, ok := c[t.Format("2006-01-02")]
+	return ok
+}
+
+// from〜to（YYYY-MM-DD、両端を含む）に含まれる祝日の数
+func (c holidayCalendar) countBetween(from, to string) int {
+	n := 0
+	for date := range c {
+		if date >= from && date <= to {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,200 @@
+package dora
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+)
+
+// report --output html 用の 1 ファイルで完結する HTML
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>DORA Metrics {{.From}} - {{.To}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2rem; }
+th, td { border: 1px solid #ddd; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #f4f4f4; }
+tr.total td { font-weight: bold; }
+.note { color: #666; font-size: 0.9em; }
+svg text { font-size: 10px; fill: #555; text-anchor: middle; }
+svg rect { fill: #4e79a7; }
+svg rect.band { fill: #eee; }
+</style>
+</head>
+<body>
+<h1>📊 DORA &amp; Contribution Summary</h1>
+<p>{{.Owner}} · {{.From}} - {{.To}}</p>
+<p class="note">CFR: {{.CFRDefinition}}</p>
+<table>
+<tr><th>Entity</th><th>PRs</th><th>Avg LT (h)</th><th>Median LT (h)</th><th>P90 LT (h)</th><th>CFR</th><th>Avg size</th></tr>
+{{range .Rows}}<tr{{if .Total}} class="total"{{end}}><td>{{.Name}}</td><td>{{.PRs}}</td><td>{{printf "%.1f" .AvgLT}}</td><td>{{printf "%.1f" .MedianLT}}</td><td>{{printf "%.1f" .P90LT}}</td><td>{{printf "%.1f" .CFR}}%</td><td>+{{.AvgSize}}</td></tr>
+{{end}}</table>
+{{if .PercentileRows}}<h2>⏱️ Duration Percentiles (h)</h2>
+<table>
+<tr><th>Entity</th><th>Metric</th>{{range .Percentiles}}<th>{{.}}</th>{{end}}</tr>
+{{range .PercentileRows}}<tr><td>{{.Name}}</td><td>{{.Metric}}</td>{{range .Values}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}{{with .Charts}}<h2>📈 Trends</h2>
+{{range .}}<h3>{{.Title}}</h3>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="{{.Title}}">
+{{range .Bands}}<rect class="band" x="{{printf "%.1f" .X}}" y="0" width="{{printf "%.1f" .W}}" height="{{printf "%.1f" .H}}"><title>{{.Title}}</title></rect>
+{{end}}{{range .Bars}}<rect x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" width="{{printf "%.1f" .W}}" height="{{printf "%.1f" .H}}"><title>{{.Value}}</title></rect>{{if .ShowValue}}<text x="{{printf "%.1f" .CenterX}}" y="{{printf "%.1f" .Y}}" dy="-3">{{.Value}}</text>{{end}}{{if .Label}}<text x="{{printf "%.1f" .CenterX}}" y="{{.LabelY}}">{{.Label}}</text>{{end}}
+{{end}}</svg>
+<p class="note">{{.Note}}</p>
+{{end}}{{end}}{{if .DeploySource}}<h2>🚚 Deployments ({{.DeploySource}})</h2>
+<table>
+<tr><th>Entity</th><th>Deploys</th><th>Deploys/day</th><th>Failed</th><th>Rollbacks</th><th>Deploy CFR</th></tr>
+{{range .Deploys}}<tr{{if .Total}} class="total"{{end}}><td>{{.Name}}</td><td>{{.Deploys}}</td><td>{{printf "%.2f" .PerDay}}</td><td>{{.Failed}}</td><td>{{.Rollbacks}}</td><td>{{printf "%.1f" .CFR}}%</td></tr>
+{{end}}</table>
+{{end}}<h2>👤 Contributors</h2>
+{{if .RelativeMembers}}<p class="note">Relative to the team: lead time against the team median, size against the team average, CFR in percentage points. Listed alphabetically, not ranked.</p>
+{{end}}<table>
+{{if .RelativeMembers}}<tr><th>Contributor</th><th>PRs</th><th>Median LT</th><th>P90 LT</th><th>Avg size</th><th>CFR</th></tr>
+{{range .RelativeMembers}}<tr><td>{{.Name}}</td><td>{{.PRs}}</td><td>{{.MedianLT}}</td><td>{{.P90LT}}</td><td>{{.Size}}</td><td>{{.CFR}}</td></tr>
+{{end}}{{else}}<tr><th>Contributor</th><th>PRs</th><th>New work</th><th>Fix / maintenance</th><th>Avg size</th></tr>
+{{range .Members}}<tr><td>{{.Name}}</td><td>{{.PRs}}</td><td>{{.NewWork}}</td><td>{{.Fixes}}</td><td>+{{.AvgSize}}</td></tr>
+{{end}}{{end}}</table>
+{{with .ReviewMatrix}}<h2>🔍 Review Matrix</h2>
+<p class="note">Rows are PR authors, columns are reviewers (busiest first). Each cell: reviews / median hours from PR creation to that reviewer's first review.</p>
+<table>
+<tr><th>Author / Reviewer</th>{{range .Reviewers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr><td>{{.Author}}</td>{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}<h2>📖 Definitions</h2>
+{{with .Definitions}}<table>
+<tr><td>Lead time</td><td>{{.LeadTime}}</td></tr>
+<tr><td>Deployment frequency</td><td>{{.DeploymentFrequency}}{{if .Environments}} ({{.Environments}}){{end}}</td></tr>
+<tr><td>Change failure rate</td><td>{{.CFR}}</td></tr>
+<tr><td>Failure rules</td><td>{{range .FailureRules}}{{.}}<br>{{end}}</td></tr>
+<tr><td>Time to restore</td><td>{{.MTTR}}</td></tr>
+<tr><td>PRs opened before the period</td><td>{{.Carryover}}</td></tr>
+<tr><td>Timezone</td><td>{{.Timezone}}</td></tr>
+{{if .BusinessHours}}<tr><td>Business hours</td><td>lead time and time to first review count only {{.BusinessHours}}</td></tr>
+{{end}}{{if .IncludeLabels}}<tr><td>Only PRs labeled</td><td>{{range $i, $l := .IncludeLabels}}{{if $i}}, {{end}}{{$l}}{{end}}</td></tr>
+{{end}}{{if .ExcludeLabels}}<tr><td>PRs excluded by label</td><td>{{range $i, $l := .ExcludeLabels}}{{if $i}}, {{end}}{{$l}}{{end}}</td></tr>
+{{end}}{{if .Members}}<tr><td>Members</td><td>{{range $i, $m := .Members}}{{if $i}}, {{end}}{{$m}}{{end}}</td></tr>
+{{end}}{{if .MaxPRsPerRepo}}<tr><td>Sample</td><td>at most {{.MaxPRsPerRepo}} PRs per repository</td></tr>
+{{end}}</table>
+{{end}}<script type="application/json" id="dora-definitions">{{.Definitions}}</script>
+</body>
+</html>
+`))
+
+type htmlRow struct {
+	Name                   string
+	Total                  bool
+	PRs                    int
+	AvgLT, MedianLT, P90LT float64
+	CFR                    float64
+	AvgSize                int
+}
+
+type htmlDeployRow struct {
+	Name                       string
+	Total                      bool
+	Deploys, Failed, Rollbacks int
+	PerDay, CFR                float64
+}
+
+type htmlMemberRow struct {
+	Name                         string
+	PRs, NewWork, Fixes, AvgSize int
+}
+
+// 分位点の行（値は "12.5h" などの文字列）
+type htmlPercentileRow struct {
+	Name, Metric string
+	Values       []string
+}
+
+// --member-view relative の行（"+20%" などの文字列）
+type htmlRelativeRow struct {
+	Name                       string
+	PRs                        int
+	MedianLT, P90LT, Size, CFR string
+}
+
+func writeHTMLReport(w io.Writer, a *analyzer) error {
+	days := periodDays(a.from, a.to)
+	avgSize := func(s *Stats) int {
+		if s.TotalPRs == 0 {
+			return 0
+		}
+		return s.TotalAdditions / s.TotalPRs
+	}
+	row := func(name string, s *Stats, total bool) htmlRow {
+		return htmlRow{name, total, s.TotalPRs, s.AvgLeadTimeHours(), s.LeadTimeQuantile(0.5), s.LeadTimeQuantile(0.9), s.CFR(), avgSize(s)}
+	}
+	deployRow := func(name string, s *Stats, total bool) htmlDeployRow {
+		return htmlDeployRow{name, total, s.Deployments, s.FailedDeployments, s.Rollbacks, float64(s.Deployments) / days, s.DeployCFR()}
+	}
+
+	data := struct {
+		Owner, From, To, CFRDefinition, DeploySource string
+		Rows                                         []htmlRow
+		Deploys                                      []htmlDeployRow
+		Members                                      []htmlMemberRow
+		Percentiles                                  []string
+		PercentileRows                               []htmlPercentileRow
+		RelativeMembers                              []htmlRelativeRow
+		ReviewMatrix                                 *htmlMatrix
+		Charts                                       []htmlChart
+		Definitions                                  reportDefinitions
+	}{Owner: a.owner, From: a.from, To: a.to, CFRDefinition: cfrDefinition(a.team), ReviewMatrix: buildReviewMatrix(a.pairs), Charts: buildHTMLCharts(a), Definitions: a.definitions()}
+
+	data.Rows = append(data.Rows, row("OVERALL TEAM", a.team, true))
+	for _, name := range sortedKeys(a.repos) {
+		data.Rows = append(data.Rows, row(name, a.repos[name], false))
+	}
+	for _, p := range reportPercentiles {
+		data.Percentiles = append(data.Percentiles, percentileKey(p))
+	}
+	percentileRows := func(name string, s *Stats) {
+		for _, m := range durationSeries(digestPercentiles(s.LeadTimes), digestPercentiles(s.Pickups)) {
+			r := htmlPercentileRow{Name: name, Metric: m.label}
+			for _, p := range reportPercentiles {
+				r.Values = append(r.Values, formatPercentile(m.values, p))
+			}
+			data.PercentileRows = append(data.PercentileRows, r)
+		}
+	}
+	percentileRows("OVERALL TEAM", a.team)
+	for _, name := range sortedKeys(a.repos) {
+		percentileRows(name, a.repos[name])
+	}
+	if a.deploys != nil {
+		data.DeploySource = a.deploys.Name()
+		data.Deploys = append(data.Deploys, deployRow("OVERALL TEAM", a.team, true))
+		for _, name := range sortedKeys(a.repos) {
+			data.Deploys = append(data.Deploys, deployRow(name, a.repos[name], false))
+		}
+	}
+	for _, name := range sortedKeys(a.users) {
+		s := a.users[name]
+		if a.memberView == memberViewRelative {
+			r := summarizeRelative(s, a.team)
+			data.RelativeMembers = append(data.RelativeMembers, htmlRelativeRow{name, r.MergedPRs,
+				formatRelative(r.MedianLeadTimeVsTeam, "%"), formatRelative(r.P90LeadTimeVsTeam, "%"), formatRelative(r.AvgAdditionsVsTeam, "%"), formatRelative(r.CFRVsTeamPoints, "pt")})
+			continue
+		}
+		data.Members = append(data.Members, htmlMemberRow{name, s.TotalPRs, s.FeaturePRs, s.BugFixPRs, avgSize(s)})
+	}
+	if err := htmlReportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("render html: %w", err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
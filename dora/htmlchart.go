@@ -0,0 +1,163 @@
+package dora
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// HTML レポートのグラフ。外部のスクリプトや CDN を読まずに開けるよう、SVG の棒グラフをここで組み立てる
+type htmlChart struct {
+	Title, Note   string
+	Width, Height float64
+	Bars          []htmlBar
+	Bands         []htmlBand // 背景に塗る期間（フリーズ）
+}
+
+type htmlBand struct {
+	Title   string
+	X, W, H float64
+}
+
+type htmlBar struct {
+	Label, Value string // Label が空の棒は軸ラベルを省く（週が多いとき）
+	ShowValue    bool   // 棒が細いときは値を重ねて描かない（マウスオーバーで見る）
+	X, Y, W, H   float64
+	CenterX      float64
+	LabelY       float64
+}
+
+const (
+	chartWidth     = 640
+	chartPlot      = 160 // 棒の最大の高さ
+	chartTop       = 16  // 値ラベルの分の余白
+	chartMaxLabels = 12
+)
+
+func newBarChart(title, note string, labels []string, values []float64, format func(float64) string) htmlChart {
+	c := htmlChart{Title: title, Note: note, Width: chartWidth, Height: chartTop + chartPlot + 20}
+	top := 0.0
+	for _, v := range values {
+		top = math.Max(top, v)
+	}
+	slot := float64(chartWidth) / float64(max(len(values), 1))
+	every := (len(values) + chartMaxLabels - 1) / chartMaxLabels
+	for i, v := range values {
+		h := 0.0
+		if top > 0 {
+			h = v / top * chartPlot
+		}
+		b := htmlBar{Value: format(v), X: float64(i)*slot + slot*0.15, W: slot * 0.7, H: h, Y: chartTop + chartPlot - h, CenterX: float64(i)*slot + slot/2, LabelY: chartTop + chartPlot + 14}
+		b.ShowValue = slot >= 24
+		if i%every == 0 {
+			b.Label = labels[i]
+		}
+		c.Bars = append(c.Bars, b)
+	}
+	return c
+}
+
+// 期間を 7 日ごとに区切った週の開始日
+func chartWeeks(from, to string) []time.Time {
+	start, err1 := parseDay(from)
+	end, err2 := parseDay(to)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	var weeks []time.Time
+	for w := start; !w.After(end); w = w.AddDate(0, 0, 7) {
+		weeks = append(weeks, w)
+	}
+	return weeks
+}
+
+// 週ごとの件数（週の範囲外の日時は数えない）
+func countByWeek(weeks []time.Time, times timeCounts) []float64 {
+	out := make([]float64, len(weeks))
+	if len(weeks) == 0 {
+		return out
+	}
+	for _, h := range times.hours {
+		i := int(h.First.Sub(weeks[0]).Hours() / (24 * 7))
+		if h.First.Before(weeks[0]) || i >= len(weeks) {
+			continue
+		}
+		out[i] += float64(h.N)
+	}
+	return out
+}
+
+// フリーズ期間に掛かる週を背景で示し、注記に期間を足す
+func (c *htmlChart) shadeFreezes(weeks []time.Time, from, to string) {
+	active := freezesBetween(from, to)
+	if len(active) == 0 || len(weeks) == 0 {
+		return
+	}
+	slot := c.Width / float64(len(weeks))
+	for i, w := range weeks {
+		for d := w; d.Before(w.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+			if isFrozen(d) {
+				c.Bands = append(c.Bands, htmlBand{Title: "Freeze", X: float64(i) * slot, W: slot, H: chartTop + chartPlot})
+				break
+			}
+		}
+	}
+	names := make([]string, len(active))
+	for i, f := range active {
+		names[i] = f.String()
+	}
+	c.Note += ". Shaded weeks overlap a freeze: " + strings.Join(names, ", ")
+}
+
+// リードタイムのヒストグラムの区切り（時間）
+var leadTimeBuckets = []float64{1, 4, 24, 72, 168, 336, 672}
+var leadTimeBucketLabels = []string{"<1h", "1-4h", "4-24h", "1-3d", "3-7d", "1-2w", "2-4w", "4w+"}
+
+func buildHTMLCharts(a *analyzer) []htmlChart {
+	weeks := chartWeeks(a.from, a.to)
+	labels := make([]string, len(weeks))
+	for i, w := range weeks {
+		labels[i] = w.Format("01-02")
+	}
+	count := func(v float64) string { return fmt.Sprintf("%.0f", v) }
+	percent := func(v float64) string { return fmt.Sprintf("%.0f%%", v) }
+
+	deployNote := "Merged PRs per week (re-lands excluded)"
+	deployTimes := a.team.deployTimes()
+	switch {
+	case a.team.DeployTracked:
+		deployNote = "Successful deployments per week"
+	case a.team.Sampled:
+		// 抽出した PR の週ごとの数。形は母集団と同じだが、件数は標本の分だけ
+		deployNote = "Sampled merged PRs per week (--max-prs); counts cover the sample only"
+		deployTimes = a.team.MergeTimes
+	}
+	deploys := newBarChart("Deployments per week", deployNote, labels, countByWeek(weeks, deployTimes), count)
+	deploys.shadeFreezes(weeks, a.from, a.to)
+	charts := []htmlChart{deploys}
+
+	if a.team.LeadTimes != nil && a.team.LeadTimes.Count() > 0 {
+		hist := a.team.LeadTimes.histogram(leadTimeBuckets)
+		total := 0.0
+		for _, v := range hist {
+			total += v
+		}
+		for i := range hist {
+			hist[i] = hist[i] / total * 100
+		}
+		charts = append(charts, newBarChart("Lead time distribution", "Share of lead time samples: "+a.leadTimeDefinition(), leadTimeBucketLabels, hist, percent))
+	}
+
+	merges := countByWeek(weeks, a.team.MergeTimes)
+	failures := countByWeek(weeks, a.team.FailureTimes)
+	cfr := make([]float64, len(weeks))
+	for i := range weeks {
+		if merges[i] > 0 {
+			cfr[i] = failures[i] / merges[i] * 100
+		}
+	}
+	cfrChart := newBarChart("Change failure rate per week", "Failure PRs ÷ merged PRs in each week", labels, cfr, percent)
+	cfrChart.shadeFreezes(weeks, a.from, a.to)
+	return append(charts, cfrChart)
+}
@@ -0,0 +1,138 @@
+package dora
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// GET のレスポンスをディスクに保存し、次回は If-None-Match / If-Modified-Since を付けて送る
+// 304 なら保存済みのレスポンスを返す（GitHub は 304 をレート制限に数えない）
+// 期間を少しずつ変えて何度も実行するときに、ほとんどのリクエストが 304 で済む
+type diskCache struct {
+	dir  string
+	warn sync.Once
+}
+
+func newDiskCache(dir string) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &diskCache{dir: dir}, nil
+}
+
+// トークンごとに見えるデータが違うので、キーには Authorization も含める
+func (c *diskCache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Header.Get("Authorization") + "\n" + req.Header.Get("Accept") + "\n" + req.URL.String()))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+func (c *diskCache) Middleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet {
+			return next.RoundTrip(req)
+		}
+		path := c.path(req)
+		cached := c.load(path, req)
+		if cached != nil {
+			req = req.Clone(req.Context())
+			if etag := cached.Header.Get("ETag"); etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if lm := cached.Header.Get("Last-Modified"); lm != "" {
+				req.Header.Set("If-Modified-Since", lm)
+			}
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			resp.Body.Close()
+			// レート制限の残りは最新の値を go-github に渡す
+			for k, v := range resp.Header {
+				if strings.HasPrefix(k, "X-Ratelimit-") {
+					cached.Header[k] = v
+				}
+			}
+			cached.Header.Set("X-From-Cache", "1")
+			return cached, nil
+		}
+		if cached != nil {
+			cached.Body.Close()
+		}
+		if resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") {
+			return c.store(path, resp), nil
+		}
+		return resp, nil
+	})
+}
+
+func (c *diskCache) load(path string, req *http.Request) *http.Response {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+// 本文を読み切って保存し、読み直せるレスポンスを返す
+func (c *diskCache) store(path string, resp *http.Response) *http.Response {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp
+	}
+	// Transport が展開済みの本文を保存するので、長さや圧縮のヘッダーは付けない
+	saved := *resp
+	saved.Body = io.NopCloser(bytes.NewReader(body))
+	saved.ContentLength = int64(len(body))
+	saved.TransferEncoding = nil
+	saved.Header = resp.Header.Clone()
+	saved.Header.Del("Content-Encoding")
+	saved.Header.Del("Content-Length")
+	dump, err := httputil.DumpResponse(&saved, true)
+	if err == nil {
+		err = c.write(path, dump)
+	}
+	if err != nil {
+		c.warn.Do(func() { log.Printf("⚠️  Failed to write the response cache: %v", err) })
+	}
+	return resp
+}
+
+// 並行して書いても壊れないよう、一時ファイルに書いてから置き換える
+func (c *diskCache) write(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,102 @@
+package dora
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// PR 説明の衛生スコア（各項目 25 点、満点 100）
+//   - 本文がある
+//   - Issue へのリンクがある（#123 / Issue URL / PROJ-123）
+//   - 変更行数が閾値以下
+//   - テンプレートの見出しがすべて埋まっている
+var (
+	issueLinkPattern    = regexp.MustCompile(`#\d+\b|/issues/\d+|\b[A-Z][A-Z0-9]+-\d+\b`)
+	htmlCommentPattern  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	markdownHeadPattern = regexp.MustCompile(`^#{1,6}\s+\S`)
+)
+
+const hygieneCriteria = 4
+
+func hygieneScore(pr *github.PullRequest, maxLines int) int {
+	body := htmlCommentPattern.ReplaceAllString(pr.GetBody(), "")
+	passed := 0
+	if strings.TrimSpace(body) != "" {
+		passed++
+	}
+	if issueLinkPattern.MatchString(pr.GetTitle() + "\n" + body) {
+		passed++
+	}
+	if pr.GetAdditions()+pr.GetDeletions() <= maxLines {
+		passed++
+	}
+	if strings.TrimSpace(body) != "" && templateFilled(body) {
+		passed++
+	}
+	return passed * 100 / hygieneCriteria
+}
+
+// 見出しの下に何も書かれていないセクションが無いか（見出しが無ければ true）
+func templateFilled(body string) bool {
+	filled := true
+	inSection, hasContent := false, false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if markdownHeadPattern.MatchString(line) {
+			if inSection && !hasContent {
+				filled = false
+			}
+			inSection, hasContent = true, false
+			continue
+		}
+		// 未チェックのチェックボックスだけでは記入済みとみなさない
+		if line != "" && !strings.HasPrefix(line, "- [ ]") && !strings.HasPrefix(line, "* [ ]") {
+			hasContent = true
+		}
+	}
+	if inSection && !hasContent {
+		filled = false
+	}
+	return filled
+}
+
+// スコアごとの PR 数とレビュー着手時間
+type hygieneBucket struct {
+	PRs     int
+	Pickups *tdigest // 時間
+}
+
+// スコア別のレビュー着手時間（PR 作成から最初のレビューまで）と、単位ごとの平均スコア
+func printHygieneSummary(team *Stats, repos map[string]*Stats, users map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🧼 PR Hygiene\n%s\n", line, line)
+	fmt.Printf("%-25s | %-8s | %-12s | %-12s | %-12s\n", "SCORE", "PRs", "Reviewed", "MedianPickup", "P90Pickup")
+	scores := make([]int, 0, len(team.HygieneBuckets))
+	for score := range team.HygieneBuckets {
+		scores = append(scores, score)
+	}
+	sort.Ints(scores)
+	for _, score := range scores {
+		b := team.HygieneBuckets[score]
+		fmt.Printf("%-25d | %8d | %12d | %11.1fh | %11.1fh\n",
+			score, b.PRs, b.Pickups.Count(), b.Pickups.Quantile(0.5), b.Pickups.Quantile(0.9))
+	}
+	fmt.Println(line)
+
+	fmt.Printf("%-25s | %-8s | %-10s\n", "ENTITY", "PRs", "AvgScore")
+	printHygieneRow := func(name string, s *Stats) {
+		fmt.Printf("%-25s | %8d | %10.1f\n", name, s.HygieneCount, s.AvgHygiene())
+	}
+	printHygieneRow("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		printHygieneRow(name, repos[name])
+	}
+	fmt.Println(line)
+	for _, name := range sortedKeys(users) {
+		printHygieneRow(name, users[name])
+	}
+}
@@ -0,0 +1,59 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// 重大度を表すラベル（sev1 / SEV-2 / P0 など）
+var severityLabelPattern = regexp.MustCompile(`(?i)^(?:sev|p)[-_ ]?\d$`)
+
+// labels のいずれかが付いた Issue をインシデントとして読む（作成日時 → クローズ日時）
+// since 以降に更新された Issue だけを引く。service はリポジトリ名
+func fetchIncidentIssues(ctx context.Context, client *github.Client, owner, repo string, labels []string, since time.Time) ([]incident, error) {
+	seen := make(map[int]bool)
+	var out []incident
+	// ラベルの指定は AND になるので、ラベルごとに引いて重複を除く
+	for _, label := range labels {
+		opts := &github.IssueListByRepoOptions{
+			State:       "all",
+			Labels:      []string{label},
+			Since:       since,
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			issues, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
+			if err != nil {
+				return nil, fmt.Errorf("list %s issues of %s/%s: %w", label, owner, repo, err)
+			}
+			for _, is := range issues {
+				if is.IsPullRequest() || seen[is.GetNumber()] {
+					continue
+				}
+				seen[is.GetNumber()] = true
+				in := incident{Start: is.GetCreatedAt().Time, Service: repo}
+				if is.ClosedAt != nil {
+					in.End = is.GetClosedAt().Time
+				}
+				for _, l := range is.Labels {
+					if severityLabelPattern.MatchString(l.GetName()) {
+						in.Severity = l.GetName()
+						break
+					}
+				}
+				out = append(out, in)
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out, nil
+}
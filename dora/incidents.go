@@ -0,0 +1,281 @@
+package dora
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// スプレッドシートで管理しているインシデント記録（CSV）
+//
+//	start,end,severity,service
+//	2025-01-10 14:05,2025-01-10 15:30,SEV2,api
+//
+// end が空なら未復旧。service はリポジトリ名（owner/repo も可）で、一致しなければ全体にだけ数える
+type incident struct {
+	Start    time.Time
+	End      time.Time // 未復旧ならゼロ値
+	Severity string
+	Service  string
+}
+
+var incidentTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006/01/02 15:04", "2006-01-02"}
+
+func parseIncidentTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range incidentTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+// タイムゾーンの無い時刻は loc として読む
+func loadIncidentsFile(path string, loc *time.Location) ([]incident, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		// よくある別名も受け付ける
+		switch h {
+		case "started_at", "opened", "opened_at":
+			h = "start"
+		case "resolved", "resolved_at", "ended_at", "restored_at":
+			h = "end"
+		case "repo", "repository", "affected":
+			h = "service"
+		case "sev", "priority":
+			h = "severity"
+		}
+		cols[h] = i
+	}
+	if _, ok := cols["start"]; !ok {
+		return nil, fmt.Errorf("%s: missing start column", path)
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var out []incident
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		in := incident{Severity: field(rec, "severity"), Service: field(rec, "service")}
+		if in.Start, err = parseIncidentTime(field(rec, "start"), loc); err != nil {
+			return nil, fmt.Errorf("%s:%d: start: %w", path, line, err)
+		}
+		if end := field(rec, "end"); end != "" {
+			if in.End, err = parseIncidentTime(end, loc); err != nil {
+				return nil, fmt.Errorf("%s:%d: end: %w", path, line, err)
+			}
+			if in.End.Before(in.Start) {
+				return nil, fmt.Errorf("%s:%d: end is before start", path, line)
+			}
+		}
+		out = append(out, in)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out, nil
+}
+
+func (in incident) matches(repo string) bool {
+	svc := in.Service
+	if i := strings.LastIndex(svc, "/"); i >= 0 {
+		svc = svc[i+1:]
+	}
+	return strings.EqualFold(svc, repo)
+}
+
+// インシデント件数と復旧時間を加える
+func (s *Stats) addIncidents(incidents []incident) {
+	for _, in := range incidents {
+		s.Incidents++
+		if in.End.IsZero() {
+			s.OpenIncidents++
+			continue
+		}
+		if s.RestoreTimes == nil {
+			s.RestoreTimes = newTDigest()
+		}
+		s.RestoreTimes.Add(in.End.Sub(in.Start).Hours())
+		s.RestoreSum += in.End.Sub(in.Start)
+	}
+}
+
+// 期間内に始まったインシデント（repo が空なら全件）
+func (a *analyzer) incidentsFor(repo string) []incident {
+	from, to := a.window()
+	var out []incident
+	for _, in := range a.incidents {
+		if in.Start.Before(from) || !in.Start.Before(to) {
+			continue
+		}
+		if repo == "" || in.matches(repo) {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+// IncidentAttribution はインシデントをデプロイに帰属させた結果（export で CFR の根拠を確かめられるように）
+type IncidentAttribution struct {
+	Start      time.Time `json:"incident_start"`
+	Severity   string    `json:"severity,omitempty"`
+	Service    string    `json:"service,omitempty"`
+	DeployedAt time.Time `json:"deployed_at"`
+	Weight     float64   `json:"weight"` // このデプロイが負う割合（1 つのインシデントで合計 1）
+}
+
+func deployKey(d deployment) string {
+	return d.SHA + "@" + d.Time.Format(time.RFC3339Nano)
+}
+
+// インシデントを、開始前 window 以内の成功したデプロイのうち新しいものから最大 depth 個に帰属させる。
+// 複数に分けるときは新しいデプロイほど重くする（重みは 1 - 経過時間/window に比例し、合計 1）
+func (x *deployIndex) AttributeIncidents(incidents []incident, window time.Duration, depth int) {
+	x.attributions = make(map[string][]IncidentAttribution)
+	for _, in := range incidents {
+		end := sort.Search(len(x.ok), func(i int) bool { return x.ok[i].Time.After(in.Start) })
+		var picked []deployment
+		for i := end - 1; i >= 0 && len(picked) < max(depth, 1); i-- {
+			if in.Start.Sub(x.ok[i].Time) > window {
+				break
+			}
+			picked = append(picked, x.ok[i])
+		}
+		weights := make([]float64, len(picked))
+		total := 0.0
+		for k, d := range picked {
+			weights[k] = 1
+			if window > 0 {
+				weights[k] = 1 - float64(in.Start.Sub(d.Time))/float64(window)
+			}
+			total += weights[k]
+		}
+		for k, d := range picked {
+			// ちょうど window 前のデプロイだけなら均等に分ける
+			w := 1 / float64(len(picked))
+			if total > 0 {
+				w = weights[k] / total
+			}
+			key := deployKey(d)
+			x.attributions[key] = append(x.attributions[key], IncidentAttribution{
+				Start: in.Start, Severity: in.Severity, Service: in.Service, DeployedAt: d.Time, Weight: w,
+			})
+		}
+	}
+}
+
+// 期間内の成功したデプロイのうちインシデントが帰属したものの数と、失敗としての重み（1 デプロイあたり最大 1）
+func (x *deployIndex) IncidentFailuresBetween(from, to time.Time) (deploys int, weight float64) {
+	for _, d := range x.ok {
+		if d.Time.Before(from) || !d.Time.Before(to) {
+			continue
+		}
+		atts := x.attributions[deployKey(d)]
+		if len(atts) == 0 {
+			continue
+		}
+		deploys++
+		sum := 0.0
+		for _, at := range atts {
+			sum += at.Weight
+		}
+		weight += min(sum, 1)
+	}
+	return deploys, weight
+}
+
+// デプロイに帰属したインシデント
+func (x *deployIndex) IncidentsFor(d *deployment) []IncidentAttribution {
+	if d == nil {
+		return nil
+	}
+	return x.attributions[deployKey(*d)]
+}
+
+// --incidents-file などのインシデントがあれば MTTR はインシデントから求める（起動時に設定）
+var incidentMTTR bool
+
+// インシデントの復旧時間。--incidents-file が無ければ修正 PR の作成からマージまで（取り消し PR は元の変更の出荷から）
+func (s *Stats) MTTRHours() float64 {
+	if !incidentMTTR {
+		if s.FixRestores == 0 {
+			return 0
+		}
+		return s.FixRestoreSum.Hours() / float64(s.FixRestores)
+	}
+	n := s.Incidents - s.OpenIncidents
+	if n == 0 {
+		return 0
+	}
+	return s.RestoreSum.Hours() / float64(n)
+}
+
+func (s *Stats) MedianTTRHours() float64 {
+	if !incidentMTTR {
+		if s.FixRestoreTimes == nil {
+			return 0
+		}
+		return s.FixRestoreTimes.Quantile(0.5)
+	}
+	if s.RestoreTimes == nil {
+		return 0
+	}
+	return s.RestoreTimes.Quantile(0.5)
+}
+
+// MTTR の定義（出力に明記する）
+func mttrDefinition() string {
+	if incidentMTTR {
+		return "incident start → incident end (--incidents-file, --incident-labels, --pagerduty-services, --jira-jql)"
+	}
+	return "hotfix PR opened → merged; revert PR: reverted change shipped → revert merged"
+}
+
+func printIncidentSummary(team *Stats, repos map[string]*Stats, deployTracked bool) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🚨 Incidents (MTTR)\n%s\n", line, line)
+	fmt.Printf("%-25s | %9s | %6s | %9s | %9s | %s\n", "ENTITY", "Incidents", "Open", "MTTR", "MedianTTR", "Linked deploys")
+	row := func(name string, s *Stats) {
+		linked := "-"
+		if deployTracked {
+			linked = fmt.Sprintf("%d", s.IncidentDeploys)
+			// 複数のデプロイに分けた場合は CFR に数えた重みも出す
+			if s.IncidentFailures != float64(s.IncidentDeploys) {
+				linked += fmt.Sprintf(" (%.2f weighted)", s.IncidentFailures)
+			}
+		}
+		fmt.Printf("%-25s | %9d | %6d | %8.1fh | %8.1fh | %s\n", name, s.Incidents, s.OpenIncidents, s.MTTRHours(), s.MedianTTRHours(), linked)
+	}
+	row("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		if repos[name].Incidents > 0 {
+			row(name, repos[name])
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package dora
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 集計結果に簡単な規則を当てて出す、次の一手の提案（--insights）
+type insight struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// 規則を当てるのに必要な PR 数（これより少ないと偏りが大きい）
+const minInsightPRs = 10
+
+// 提案の閾値
+const (
+	insightShare         = 0.5  // 待ち時間がリードタイムの中央値のこの割合以上なら、その待ちが支配的
+	insightRepoFailShare = 0.5  // 1 リポジトリの失敗がこの割合以上なら、CFR はそのリポジトリ次第
+	insightLargePRLines  = 400  // 平均追加行数がこれ以上なら PR が大きい
+	insightUnreviewed    = 20.0 // レビューなしのマージ（%）
+	insightLongTail      = 5.0  // p90 が中央値のこの倍数以上ならリードタイムの裾が長い
+	insightMTTRHours     = 24.0
+)
+
+func buildInsights(a *analyzer) []insight {
+	s := a.team
+	if s.TotalPRs < minInsightPRs {
+		return nil
+	}
+	var out []insight
+	add := func(rule, format string, args ...any) {
+		out = append(out, insight{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+	median := s.LeadTimeQuantile(0.5)
+
+	// レビュー待ち（レビュー状況を取得した実行のみ）
+	if s.Pickups != nil && s.Pickups.Count() >= minInsightPRs && median > 0 {
+		if pickup := s.Pickups.Quantile(0.5); pickup >= median*insightShare {
+			add("review-pickup", "Waiting for a first review takes %.1fh of the %.1fh median lead time. Consider a review rotation or --review-sla reminders.", pickup, median)
+		}
+	}
+
+	// マージ後のデプロイ待ち（最もデプロイの多い環境）
+	if env, es := busiestEnvironment(s); es != nil && es.Lags.Count() >= minInsightPRs && median > 0 {
+		if lag := es.Lags.Quantile(0.5); lag >= median*insightShare {
+			add("deploy-wait", "Merged changes wait %.1fh for deployment to %s, out of a %.1fh median lead time. Deploy more often or automate releases.", lag, env, median)
+		}
+	}
+
+	// CFR が 1 リポジトリに偏っている
+	if len(a.repos) > 1 {
+		total := failureCount(s)
+		for _, name := range sortedKeys(a.repos) {
+			if n := failureCount(a.repos[name]); total >= 3 && n >= total*insightRepoFailShare {
+				add("cfr-concentrated", "CFR is driven by %s (%.0f of %.0f failures). Review its tests and release checks first.", name, n, total)
+			}
+		}
+	}
+
+	if s.Relands > 0 && s.QuickReverts > 0 {
+		add("revert-churn", "%d changes were reverted shortly after merging and %d were merged again. Feature flags or canary releases can catch these before users do.", s.QuickReverts, s.Relands)
+	}
+
+	if avg := float64(s.TotalAdditions) / float64(s.TotalPRs); avg >= insightLargePRLines {
+		add("large-prs", "PRs add %.0f lines on average. Smaller PRs are reviewed and shipped faster.", avg)
+	}
+
+	if s.ReviewChecked >= minInsightPRs && s.UnreviewedRate() >= insightUnreviewed {
+		add("unreviewed", "%.0f%% of PRs were merged without a review. Require an approval on the default branch.", s.UnreviewedRate())
+	}
+
+	if p90 := s.LeadTimeQuantile(0.9); median > 0 && p90 >= median*insightLongTail {
+		add("long-tail", "The p90 lead time (%.1fh) is %.0fx the median. A few PRs stall for much longer than the rest; look at the slowest PRs.", p90, p90/median)
+	}
+
+	if bucket := deployFrequencyBucket(s, a.from, a.to); bucket == "monthly-6months" || bucket == lowestDeployFrequencyBucket {
+		add("deploy-frequency", "Deployments are %s. Shipping smaller batches more often lowers the risk of each one.", strings.ReplaceAll(bucket, "-", " to "))
+	}
+
+	if mttr := s.MTTRHours(); mttr >= insightMTTRHours {
+		add("slow-restore", "Restoring service takes %.1fh on average. Practice rollbacks and make them one command.", mttr)
+	}
+	return out
+}
+
+// CFR の分子（デプロイ単位なら失敗デプロイ、PR 単位なら失敗 PR）
+func failureCount(s *Stats) float64 {
+	if !cfrPRBased && s.DeployTracked {
+		return float64(s.FailedDeployments+s.Rollbacks) + s.IncidentFailures
+	}
+	return float64(s.BugFixPRs)
+}
+
+func busiestEnvironment(s *Stats) (string, *envStats) {
+	var name string
+	var busiest *envStats
+	for _, env := range sortedKeys(s.Environments) {
+		if es := s.Environments[env]; busiest == nil || es.Deployments > busiest.Deployments {
+			name, busiest = env, es
+		}
+	}
+	return name, busiest
+}
+
+func printInsights(insights []insight) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n💡 Insights\n%s\n", line, line)
+	if len(insights) == 0 {
+		fmt.Println("No suggestions: the results did not trip any rule (or fewer than 10 PRs were merged).")
+		return
+	}
+	for _, in := range insights {
+		fmt.Printf("• %s\n", in.Message)
+	}
+}
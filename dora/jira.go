@@ -0,0 +1,174 @@
+package dora
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Jira の JQL で選んだ課題を MTTR・CFR のインシデントとして読む（作成日時 → 解決日時）
+// リポジトリとはコンポーネントかラベルの名前で結び付ける
+type jiraSource struct {
+	client    *http.Client
+	address   string
+	user      string // Jira Cloud のメールアドレス（空なら Server / Data Center の個人アクセストークン）
+	token     string
+	jql       string
+	repoField string // "component" / "label"
+}
+
+// JQL 末尾の ORDER BY（期間の条件を足すときに外す）
+var jqlOrderByPattern = regexp.MustCompile(`(?i)\s+order\s+by\s+.*$`)
+
+func newJiraSource(transport http.RoundTripper, address, user, token, jql, repoField string) (*jiraSource, error) {
+	if address == "" || token == "" {
+		return nil, fmt.Errorf("--jira-url and JIRA_API_TOKEN are required for --jira-jql")
+	}
+	if repoField != "component" && repoField != "label" {
+		return nil, fmt.Errorf("unsupported --jira-repo-field %q (want component or label)", repoField)
+	}
+	return &jiraSource{
+		client:    &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		address:   strings.TrimRight(address, "/"),
+		user:      user,
+		token:     token,
+		jql:       strings.TrimSpace(jqlOrderByPattern.ReplaceAllString(jql, "")),
+		repoField: repoField,
+	}, nil
+}
+
+// Jira の日時（2025-01-10T14:05:00.000+0900）
+type jiraTime struct{ time.Time }
+
+func (t *jiraTime) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil || s == "" {
+		return nil
+	}
+	parsed, err := time.Parse("2006-01-02T15:04:05.000-0700", s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Created        jiraTime `json:"created"`
+		ResolutionDate jiraTime `json:"resolutiondate"`
+		Priority       *struct {
+			Name string `json:"name"`
+		} `json:"priority"`
+		Components []struct {
+			Name string `json:"name"`
+		} `json:"components"`
+		Labels []string `json:"labels"`
+	} `json:"fields"`
+}
+
+// [from, to) に作成された課題。repos に一致するコンポーネント・ラベルがあればそのリポジトリのインシデントにする
+func (j *jiraSource) Incidents(ctx context.Context, from, to time.Time, repos []string) ([]incident, error) {
+	jql := fmt.Sprintf(`created >= "%s" AND created < "%s"`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if j.jql != "" {
+		jql = "(" + j.jql + ") AND " + jql
+	}
+	q := url.Values{}
+	q.Set("jql", jql+" ORDER BY created ASC")
+	q.Set("fields", "created,resolutiondate,priority,components,labels")
+	q.Set("maxResults", "100")
+	// Jira Cloud は /search/jql（nextPageToken）、Server / Data Center は /search（startAt）でページを送る
+	path := "/rest/api/2/search"
+	if j.user != "" {
+		path = "/rest/api/3/search/jql"
+	}
+
+	var out []incident
+	for startAt := 0; ; {
+		if j.user == "" {
+			q.Set("startAt", strconv.Itoa(startAt))
+		}
+		var page struct {
+			Issues        []jiraIssue `json:"issues"`
+			Total         int         `json:"total"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if err := j.get(ctx, path+"?"+q.Encode(), &page); err != nil {
+			return nil, err
+		}
+		for _, is := range page.Issues {
+			in := incident{Start: is.Fields.Created.Time, End: is.Fields.ResolutionDate.Time, Service: j.service(is, repos)}
+			if is.Fields.Priority != nil {
+				in.Severity = is.Fields.Priority.Name
+			}
+			if in.Start.IsZero() {
+				return nil, fmt.Errorf("jira issue %s has no created date", is.Key)
+			}
+			out = append(out, in)
+		}
+		startAt += len(page.Issues)
+		if j.user != "" {
+			if page.NextPageToken == "" {
+				break
+			}
+			q.Set("nextPageToken", page.NextPageToken)
+			continue
+		}
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			break
+		}
+	}
+	return out, nil
+}
+
+// 課題のコンポーネント（ラベル）のうち解析するリポジトリと同名のもの。無ければ最初のもの
+func (j *jiraSource) service(is jiraIssue, repos []string) string {
+	var names []string
+	if j.repoField == "label" {
+		names = is.Fields.Labels
+	} else {
+		for _, c := range is.Fields.Components {
+			names = append(names, c.Name)
+		}
+	}
+	for _, name := range names {
+		for _, repo := range repos {
+			if (incident{Service: name}).matches(repo) {
+				return name
+			}
+		}
+	}
+	if len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+func (j *jiraSource) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.address+path, nil)
+	if err != nil {
+		return err
+	}
+	if j.user != "" {
+		req.SetBasicAuth(j.user, j.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+j.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jira API %s: %s", strings.SplitN(path, "?", 2)[0], resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
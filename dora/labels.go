@@ -0,0 +1,162 @@
+package dora
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// --required-labels の 1 グループ。いずれかのパターン（glob）に合うラベルがあれば満たす
+// 例: "bug|feature|chore,area/*" は「種別のどれか」と「area/ で始まるもの」の両方を求める
+type labelGroup []string
+
+func parseRequiredLabels(s string) []labelGroup {
+	var groups []labelGroup
+	for _, item := range splitList(s) {
+		var g labelGroup
+		for _, p := range strings.Split(item, "|") {
+			if p = strings.TrimSpace(p); p != "" {
+				g = append(g, strings.ToLower(p))
+			}
+		}
+		if len(g) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func (g labelGroup) String() string {
+	return strings.Join(g, "|")
+}
+
+func (g labelGroup) matches(labels []string) bool {
+	for _, l := range labels {
+		for _, p := range g {
+			if ok, _ := path.Match(p, strings.ToLower(l)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// --include-labels / --exclude-labels のラベル（glob、大文字小文字を区別しない）
+func parseLabelPatterns(s string) labelGroup {
+	var g labelGroup
+	for _, p := range splitList(s) {
+		g = append(g, strings.ToLower(p))
+	}
+	return g
+}
+
+// --include-labels / --exclude-labels で数える PR か（両方に合う PR は除く）
+func (a *analyzer) labelsAllowed(labels []string) bool {
+	if len(a.excludeLabels) > 0 && a.excludeLabels.matches(labels) {
+		return false
+	}
+	return len(a.includeLabels) == 0 || a.includeLabels.matches(labels)
+}
+
+// 作成者（--members・bot・--exclude-users）とラベル（--include-labels / --exclude-labels）で PR を選ぶ
+// 検索結果の段階で使えるよう、作成者とラベルだけで判定する
+func (a *analyzer) prSelected(user *github.User, labels []*github.Label) bool {
+	if len(a.members) > 0 && !a.members[a.aliases.canonical(user.GetLogin())] {
+		return false
+	}
+	if a.excludedAccount(user) {
+		return false
+	}
+	if len(a.includeLabels)+len(a.excludeLabels) == 0 {
+		return true
+	}
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.GetName())
+	}
+	return a.labelsAllowed(names)
+}
+
+// 満たしていない必須ラベルのグループ
+func missingLabels(labels []string, groups []labelGroup) []string {
+	var out []string
+	for _, g := range groups {
+		if !g.matches(labels) {
+			out = append(out, g.String())
+		}
+	}
+	return out
+}
+
+// 必須ラベルが欠けている PR
+type labelGap struct {
+	Number  int
+	Author  string
+	Missing []string
+}
+
+// 単位ごとに一覧に残す必須ラベル漏れの PR の数（件数は MissingLabelPRs で全件数える）
+const maxLabelGaps = 50
+
+const topLabels = 5
+
+// 件数の多い順（同数なら名前順）
+func labelsByCount(counts map[string]int) []string {
+	names := sortedKeys(counts)
+	sort.SliceStable(names, func(i, j int) bool { return counts[names[i]] > counts[names[j]] })
+	return names
+}
+
+func printLabelSummary(a *analyzer) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🏷️  Labels on Merged PRs\n%s\n", line, line)
+	fmt.Printf("%-25s | %-8s | %-10s | %-10s | %s\n", "ENTITY", "PRs", "Unlabeled", "Missing", "Top labels")
+	row := func(name string, s *Stats) {
+		var top []string
+		for i, l := range labelsByCount(s.LabelCounts) {
+			if i == topLabels {
+				break
+			}
+			top = append(top, fmt.Sprintf("%s (%d)", l, s.LabelCounts[l]))
+		}
+		missing := "-"
+		if len(a.requiredLabels) > 0 {
+			missing = fmt.Sprintf("%d", s.MissingLabelPRs)
+		}
+		fmt.Printf("%-25s | %8d | %10d | %10s | %s\n", name, s.LabelChecked, s.UnlabeledPRs, missing, strings.Join(top, ", "))
+	}
+	row("OVERALL TEAM", a.team)
+	for _, name := range sortedKeys(a.repos) {
+		row(name, a.repos[name])
+	}
+
+	fmt.Printf("%s\n%-40s | %-8s | %s\n", line, "LABEL", "PRs", "Share")
+	for _, l := range labelsByCount(a.team.LabelCounts) {
+		fmt.Printf("%-40s | %8d | %5.1f%%\n", l, a.team.LabelCounts[l], float64(a.team.LabelCounts[l])/float64(max(a.team.LabelChecked, 1))*100)
+	}
+
+	if len(a.requiredLabels) == 0 {
+		return
+	}
+	groups := make([]string, len(a.requiredLabels))
+	for i, g := range a.requiredLabels {
+		groups[i] = g.String()
+	}
+	fmt.Printf("%s\n⚠️  PRs missing required labels (%s)\n%s\n", line, strings.Join(groups, ", "), line)
+	if a.team.MissingLabelPRs == 0 {
+		fmt.Println("Every merged PR has the required labels")
+		return
+	}
+	for _, name := range sortedKeys(a.repos) {
+		s := a.repos[name]
+		for _, g := range s.LabelGaps {
+			fmt.Printf("%-25s | #%-5d | %-15s | %s\n", name, g.Number, g.Author, strings.Join(g.Missing, ", "))
+		}
+		if more := s.MissingLabelPRs - len(s.LabelGaps); more > 0 {
+			fmt.Printf("%-25s | ... and %d more\n", name, more)
+		}
+	}
+}
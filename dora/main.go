@@ -0,0 +1,1610 @@
+package dora
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/joho/godotenv"
+
+	"github.com/kkeeth/get-DORA-4keys-metrics/client"
+)
+
+type Stats struct {
+	TotalPRs          int
+	TotalLeadTime     time.Duration
+	BugFixPRs         int // "不具合修正/パッチ対応" を行った数
+	FeaturePRs        int // "新規・機能改善" を行った数
+	TotalAdditions    int
+	LeadTimeCount     int                    // リードタイムが求まった PR 数（デプロイ未検出の PR を除く）
+	LeadTimes         *tdigest               // リードタイム（時間）の分布。中央値・p90 用
+	LeadTimeSqSum     float64                // リードタイム（時間）の二乗和。信頼区間用
+	Population        int                    // サンプリング前の、絞り込みに残ったマージ済み PR 数
+	Deployments       int                    // デプロイソース使用時のデプロイ数
+	FailedDeployments int                    // 失敗したデプロイ数（errored apply など）
+	Rollbacks         int                    // ロールバックのデプロイ数
+	DeployTracked     bool                   // デプロイソースでデプロイ数を求めた単位（全体・リポジトリ）
+	ReleaseTypes      map[string]int         // リリース種別（major / minor / patch）ごとのデプロイ数
+	ChangeTypes       map[string]int         // Conventional Commits の種別ごとの PR 数
+	ReviewChecked     int                    // レビュー状況を取得できた PR 数
+	Pickups           *tdigest               // 作成→最初のレビュー（時間）。レビュー状況を取得した PR のみ
+	UnreviewedPRs     int                    // 作成者以外のレビューなしでマージされた PR 数
+	SelfMergedPRs     int                    // 作成者自身がマージした PR 数
+	AfterHoursMerges  int                    // 平日の営業時間外のマージ数
+	WeekendMerges     int                    // 週末のマージ数
+	AfterHoursDeploys int                    // 平日の営業時間外のデプロイ数
+	WeekendDeploys    int                    // 週末のデプロイ数
+	HygieneCount      int                    // 衛生スコアを求めた PR 数
+	HygieneSum        int                    // 衛生スコアの合計
+	HygieneBuckets    map[int]*hygieneBucket // スコアごとのレビュー着手時間
+	RevertPRs         int                    // 他の PR を取り消す PR 数
+	QuickReverts      int                    // マージ（デプロイ）直後に取り消された PR 数
+	Relands           int                    // 取り消された PR の再マージ数
+	Environments      map[string]*envStats   // 環境ごとのデプロイ数と遅延
+	Incidents         int                    // インシデント件数（--incidents-file）
+	OpenIncidents     int                    // 未復旧のインシデント数
+	RestoreSum        time.Duration          // 復旧時間の合計
+	RestoreTimes      *tdigest               // 復旧時間（時間）の分布
+	IncidentDeploys   int                    // 直後にインシデントが起きたデプロイ数
+	IncidentFailures  float64                // インシデントの帰属の重み（デプロイごとに最大 1。CFR に使う）
+	IncidentsLinked   bool                   // インシデントをデプロイに結び付けた単位（全体・リポジトリ）
+	LeadTimeSamples   int                    // 平均・信頼区間に使ったリードタイムの件数（コミット単位ならコミット数）
+	Funnel            *funnelStats           // 期間内に作成された PR のファネル（--funnel）
+	WeightedLeadTime  float64                // 変更行数で重み付けしたリードタイム（時間）の合計
+	LeadTimeWeight    float64                // 重みの合計（--lead-time-weight=lines）
+	ClockSkewedPRs    int                    // 日付が不正なコミットを含んだ PR 数
+	MergeTimes        timeCounts             // マージ日時（デプロイ頻度の区分・デプロイ間隔用）
+	Sampled           bool                   // --max-prs で PR を抽出した（MergeTimes は標本だけ）
+	FailureTimes      timeCounts             // 失敗 PR のマージ日時（HTML の週ごとの CFR 用）
+	DeployTimes       timeCounts             // 期間内の成功したデプロイの日時（デプロイソースがある単位）
+	FixRestores       int                    // 復旧時間を求めた修正・取り消し PR 数（インシデントが無い場合の MTTR）
+	FixRestoreSum     time.Duration          // 修正・取り消し PR の作成からマージまでの合計
+	FixRestoreTimes   *tdigest               // 修正・取り消し PR の作成からマージまで（時間）の分布
+	LabelChecked      int                    // ラベルを集計した PR 数
+	LabelCounts       map[string]int         // ラベルごとの PR 数
+	UnlabeledPRs      int                    // ラベルの無い PR 数
+	MissingLabelPRs   int                    // 必須ラベルが欠けている PR 数
+	LabelGaps         []labelGap             // 必須ラベルが欠けている PR（先頭の maxLabelGaps 件）
+}
+
+// 環境ごとのデプロイ集計
+type envStats struct {
+	Deployments int
+	Rollbacks   int
+	Lags        *tdigest // マージ→デプロイの遅延（時間）
+}
+
+func (s *Stats) env(name string) *envStats {
+	if s.Environments == nil {
+		s.Environments = make(map[string]*envStats)
+	}
+	if s.Environments[name] == nil {
+		s.Environments[name] = &envStats{Lags: newTDigest()}
+	}
+	return s.Environments[name]
+}
+
+func (s *Stats) AvgLeadTimeHours() float64 {
+	if leadTimeWeighted {
+		if s.LeadTimeWeight == 0 {
+			return 0
+		}
+		return s.WeightedLeadTime / s.LeadTimeWeight
+	}
+	if s.LeadTimeSamples == 0 {
+		return 0
+	}
+	return s.TotalLeadTime.Hours() / float64(s.LeadTimeSamples)
+}
+
+// --lead-time-weight=lines の場合、平均・分位点を変更行数で重み付けする（起動時に設定）
+var leadTimeWeighted bool
+
+// 重み付けに使う変更行数（空の PR も 1 行として数える）
+func leadTimeWeight(r prResult) float64 {
+	return float64(max(r.Additions+r.Deletions, 1))
+}
+
+// --cfr-basis=prs の場合は常に失敗 PR ÷ マージ済み PR で CFR を求める
+// それ以外はデプロイ数を持つ単位（全体・リポジトリ）をデプロイ単位（DORA の定義）で求める
+var cfrPRBased bool
+
+func (s *Stats) CFR() float64 {
+	if !cfrPRBased && s.DeployTracked {
+		return s.DeployCFR()
+	}
+	if s.TotalPRs == 0 {
+		return 0
+	}
+	return float64(s.BugFixPRs) / float64(s.TotalPRs) * 100
+}
+
+// 失敗デプロイ（エラー・ロールバック）÷ デプロイ数
+func (s *Stats) DeployCFR() float64 {
+	total := s.Deployments + s.FailedDeployments
+	if total == 0 {
+		return 0
+	}
+	return (float64(s.FailedDeployments+s.Rollbacks) + s.IncidentFailures) / float64(total) * 100
+}
+
+func cfrDefinition(s *Stats) string {
+	if s.DeployTracked && !cfrPRBased {
+		if s.IncidentsLinked {
+			return "failed deployments (errored + rollbacks + followed by an incident) ÷ deployments; members and teams: failure PRs ÷ merged PRs"
+		}
+		return "failed deployments (errored + rollbacks) ÷ deployments; members and teams: failure PRs ÷ merged PRs"
+	}
+	return "failure PRs ÷ merged PRs"
+}
+
+func (s *Stats) UnreviewedRate() float64 {
+	if s.ReviewChecked == 0 {
+		return 0
+	}
+	return float64(s.UnreviewedPRs) / float64(s.ReviewChecked) * 100
+}
+
+func (s *Stats) SelfMergeRate() float64 {
+	if s.TotalPRs == 0 {
+		return 0
+	}
+	return float64(s.SelfMergedPRs) / float64(s.TotalPRs) * 100
+}
+
+func (s *Stats) AvgHygiene() float64 {
+	if s.HygieneCount == 0 {
+		return 0
+	}
+	return float64(s.HygieneSum) / float64(s.HygieneCount)
+}
+
+// 即時の取り消し率（PR ベースの CFR でデプロイ失敗に最も近い指標）
+func (s *Stats) QuickRevertRate() float64 {
+	if s.TotalPRs == 0 {
+		return 0
+	}
+	return float64(s.QuickReverts) / float64(s.TotalPRs) * 100
+}
+
+// マージをデプロイとみなす場合のデプロイ数。再マージは同じ変更の出し直しなので数えない
+func (s *Stats) MergeDeploys() int {
+	return s.TotalPRs - s.Relands
+}
+
+func (s *Stats) LeadTimeQuantile(q float64) float64 {
+	if s.LeadTimes == nil {
+		return 0
+	}
+	return s.LeadTimes.Quantile(q)
+}
+
+// 上下 10% を除いたリードタイムの平均（外れ値の影響を受けにくい）
+func (s *Stats) TrimmedLeadTimeHours() float64 {
+	if s.LeadTimes == nil {
+		return 0
+	}
+	return s.LeadTimes.TrimmedMean(trimFraction, 1-trimFraction)
+}
+
+// Main は dora-metrics コマンドの本体（フラグ・環境変数・サブコマンドを解釈して実行する）
+func Main() {
+	_ = godotenv.Load()
+
+	configFlag := flag.String("config", os.Getenv("DORA_CONFIG"), "Configuration file (YAML, or TOML with a .toml extension); command-line flags override its values")
+	ownerFlag := flag.String("owner", os.Getenv("TARGET_OWNER"), "GitHub Owner/Org name")
+	reposFlag := flag.String("repos", os.Getenv("TARGET_REPOS"), "Comma-separated repository names (\"all\" lists the owner's non-archived repositories)")
+	discoverFlag := flag.Bool("discover", envBool("DORA_DISCOVER"), "Analyze all non-archived repositories of the owner (same as --repos all)")
+	repoFilterFlag := flag.String("repo-filter", os.Getenv("DORA_REPO_FILTER"), "Glob patterns that select repositories, e.g. 'svc-*,!svc-legacy-*' (\"!\" excludes)")
+	membersFlag := flag.String("members", os.Getenv("TARGET_MEMBERS"), "Comma-separated GitHub usernames to filter (\"org\" expands to the organization's members)")
+	memberRoleFlag := flag.String("member-role", envOr("DORA_MEMBER_ROLE", "all"), "With --members org, only include members with this role: all, admin or member")
+	startFlag := flag.String("start", os.Getenv("DORA_FROM"), "Start date (YYYY-MM-DD)")
+	endFlag := flag.String("end", os.Getenv("DORA_TO"), "End date (YYYY-MM-DD)")
+	histogramFlag := flag.Bool("histogram", envBool("DORA_HISTOGRAM"), "Show the distribution of lead time and time to first review per repository")
+	histogramBucketsFlag := flag.String("histogram-buckets", envOr("DORA_HISTOGRAM_BUCKETS", "1h,4h,1d,3d,1w"), "Upper bounds of the --histogram buckets (m, h, d or w)")
+	percentilesFlag := flag.String("percentiles", envOr("DORA_PERCENTILES", "50,75,90,95"), "Comma-separated percentiles reported for lead time and time to first review")
+	bucketFlag := flag.String("bucket", os.Getenv("DORA_BUCKET"), "Split the period into week or month buckets reported side by side (like --periods)")
+	periodsFlag := flag.String("periods", os.Getenv("DORA_PERIODS"), "Comma-separated periods reported side by side from one collection (2024-Q1, 2024-H1, 2024-03, 2024 or YYYY-MM-DD..YYYY-MM-DD); replaces --start/--end")
+	caCertFlag := flag.String("ca-cert", os.Getenv("DORA_CA_CERT"), "Path to a PEM CA bundle trusted in addition to the system roots")
+	insecureFlag := flag.Bool("insecure-skip-verify", envBool("DORA_INSECURE_SKIP_VERIFY"), "Skip TLS certificate verification (not recommended)")
+	userAgentFlag := flag.String("user-agent", envOr("DORA_USER_AGENT", client.DefaultUserAgent), "User-Agent sent to the GitHub API")
+	githubAPIURLFlag := flag.String("github-api-url", os.Getenv("GITHUB_API_URL"), "GitHub REST API base URL, for GitHub Enterprise Server (e.g. https://github.mycorp.com/api/v3; default https://api.github.com)")
+	providerFlag := flag.String("provider", envOr("DORA_PROVIDER", "github"), "Hosting service for repositories without a prefix: github, gitlab, bitbucket or gitea (--owner is the GitLab group, Bitbucket workspace or Gitea owner)")
+	gitlabURLFlag := flag.String("gitlab-url", envOr("GITLAB_URL", "https://gitlab.com"), "GitLab URL for repositories given as gitlab:group/project (token from GITLAB_TOKEN)")
+	giteaURLFlag := flag.String("gitea-url", os.Getenv("GITEA_URL"), "Gitea or Forgejo URL for repositories given as gitea:owner/repo (token from GITEA_TOKEN)")
+	maxRetriesFlag := flag.Int("max-retries", 5, "Retries for rate-limited (403/429), 5xx and network-failed GitHub API requests (0 = no retries)")
+	maxRateLimitWaitFlag := flag.Duration("max-rate-limit-wait", time.Hour, "Longest wait for a rate limit reset before giving up on a request")
+	cacheDirFlag := flag.String("cache-dir", os.Getenv("DORA_CACHE_DIR"), "Directory caching GitHub API responses; later runs revalidate them with ETags and get 304s for unchanged data")
+	telemetryFlag := flag.Bool("telemetry", envBool("DORA_TELEMETRY"), "Print an API usage summary at the end of the run")
+	telemetryOutFlag := flag.String("telemetry-out", os.Getenv("DORA_TELEMETRY_OUT"), "Write the API usage summary as JSON to this path")
+	otlpEndpointFlag := flag.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP endpoint for exporting traces (e.g. http://localhost:4318)")
+	remoteWriteFlag := flag.String("remote-write-url", os.Getenv("DORA_REMOTE_WRITE_URL"), "Prometheus remote-write endpoint to push the computed metrics to (e.g. http://mimir:9009/api/v1/push)")
+	pushgatewayFlag := flag.String("pushgateway-url", os.Getenv("DORA_PUSHGATEWAY_URL"), "Prometheus Pushgateway to push the computed metrics to (e.g. http://pushgateway:9091)")
+	pushgatewayJobFlag := flag.String("pushgateway-job", envOr("DORA_PUSHGATEWAY_JOB", "dora-metrics"), "Job name used for --pushgateway-url")
+	remoteWriteHeadersFlag := flag.String("remote-write-headers", os.Getenv("DORA_REMOTE_WRITE_HEADERS"), "Extra headers for --remote-write-url (k1=v1,k2=v2; e.g. Authorization=Bearer xxx,X-Scope-OrgID=team)")
+	maxPRsFlag := flag.Int("max-prs", envInt("DORA_MAX_PRS"), "Analyze at most N merged PRs per repository (0 = all)")
+	sampleFlag := flag.String("sample", envOr("DORA_SAMPLE", "random"), "Sampling strategy used with --max-prs (random)")
+	deploySourceFlag := flag.String("deploy-source", envOr("DORA_DEPLOY_SOURCE", "merge"), "Deployment signal: merge, gitops, terraform, changelog, semver, deployments, releases, tags, workflow, audit-log")
+	deployWorkflowFlag := flag.String("deploy-workflow", os.Getenv("DORA_DEPLOY_WORKFLOW"), "GitHub Actions workflow (file name such as deploy.yml, or its name) whose successful runs are deployments; implies --deploy-source=workflow")
+	workflowFailuresFlag := flag.Bool("deploy-workflow-failures", envBool("DORA_DEPLOY_WORKFLOW_FAILURES"), "Count failed runs of --deploy-workflow as failed deployments in CFR")
+	releaseTagPatternFlag := flag.String("release-tag-pattern", os.Getenv("DORA_RELEASE_TAG_PATTERN"), "Glob for release tag names with --deploy-source=releases or tags (e.g. v*; tags defaults to v*)")
+	gitopsRepoFlag := flag.String("gitops-repo", os.Getenv("DORA_GITOPS_REPO"), "GitOps/deploy repository ([owner/]repo) for --deploy-source=gitops")
+	gitopsEnvFlag := flag.String("gitops-env-pattern", os.Getenv("DORA_GITOPS_ENV_PATTERN"), "Regexp whose first capture group extracts the environment from GitOps manifest paths (e.g. envs/([^/]+)/)")
+	deployBranchFlag := flag.String("deploy-branch", os.Getenv("DORA_DEPLOY_BRANCH"), "Only count PRs merged into this branch (default: each repository's default branch; * for any branch)")
+	deployEnvFlag := flag.String("deploy-environment", os.Getenv("DORA_DEPLOY_ENVIRONMENT"), "Only count deployments to these environments for lead time and deployment frequency (comma-separated, globs such as production-* allowed)")
+	gitopsPathFlag := flag.String("gitops-path", os.Getenv("DORA_GITOPS_PATH"), "Only consider manifest files under this path in the GitOps repository")
+	gitopsTagFlag := flag.String("gitops-tag-pattern", os.Getenv("DORA_GITOPS_TAG_PATTERN"), "Regexp whose first capture group extracts a commit SHA from GitOps image tags (e.g. -([0-9a-f]{7,40})$); other tags are looked up as git tags of the application repository")
+	tfcAddressFlag := flag.String("tfc-address", envOr("TFE_ADDRESS", "https://app.terraform.io"), "Terraform Cloud/Enterprise address")
+	tfcOrgFlag := flag.String("tfc-org", os.Getenv("TFC_ORGANIZATION"), "Terraform Cloud organization for --deploy-source=terraform")
+	tfcWorkspacesFlag := flag.String("tfc-workspaces", os.Getenv("DORA_TFC_WORKSPACES"), "Repository to workspace mapping (repo=workspace,...); unmapped repos use a workspace of the same name")
+	changelogPathFlag := flag.String("changelog-path", envOr("DORA_CHANGELOG_PATH", "CHANGELOG.md"), "Changelog file whose release headings mark deployments for --deploy-source=changelog")
+	conventionalFlag := flag.Bool("conventional-commits", envBool("DORA_CONVENTIONAL_COMMITS"), "Classify PRs by Conventional Commits prefix and report the change-type mix")
+	conventionalCFRFlag := flag.Bool("conventional-cfr", envBool("DORA_CONVENTIONAL_CFR"), "Count fix:/revert PRs (instead of keyword matches) toward CFR")
+	fixWindowFlag := flag.Duration("fix-window", 7*24*time.Hour, "With --conventional-cfr and a deploy source, only fixes merged within this window after a deployment count as failures")
+	failureMarkerFlag := flag.String("failure-markers", os.Getenv("DORA_FAILURE_MARKERS"), "Comma-separated PR body markers that flag a failure fix (checked checkbox text or tags like [incident])")
+	markersOnlyFlag := flag.Bool("failure-markers-only", envBool("DORA_FAILURE_MARKERS_ONLY"), "Use only --failure-markers to detect failure PRs (ignore branch/label/title keywords)")
+	excludeBotsFlag := flag.Bool("exclude-bots", envOr("DORA_EXCLUDE_BOTS", "true") != "false", "Leave out PRs and reviews by bots (dependabot, renovate, *[bot]); --exclude-bots=false counts them")
+	excludeUsersFlag := flag.String("exclude-users", os.Getenv("DORA_EXCLUDE_USERS"), "Comma-separated usernames whose PRs and reviews are left out")
+	teamFlag := flag.String("team", os.Getenv("DORA_TEAMS"), "GitHub teams (org/slug or slug, comma-separated) whose members are analyzed and reported per team")
+	teamsFileFlag := flag.String("teams-file", os.Getenv("DORA_TEAMS_FILE"), "YAML file defining teams (members, SLO targets, Slack webhook)")
+	notifyFlag := flag.Bool("notify", envBool("DORA_NOTIFY"), "Post target pass/fail results to the Slack webhooks in --teams-file")
+	aliasesFileFlag := flag.String("aliases-file", os.Getenv("DORA_ALIASES_FILE"), "YAML file mapping canonical members to their other identities (old usernames, bot proxies, emails)")
+	governanceFlag := flag.Bool("governance", envBool("DORA_GOVERNANCE"), "Fetch PR reviews and report governance metrics (unreviewed merges)")
+	afterHoursFlag := flag.Bool("after-hours", envBool("DORA_AFTER_HOURS"), "Report the share of merges/deployments outside business hours or on weekends")
+	businessHoursFlag := flag.String("business-hours", os.Getenv("DORA_BUSINESS_HOURS"), "Business hours ([days] HH:MM-HH:MM [timezone], e.g. \"Mon-Fri 09:00-18:00 Asia/Tokyo\"); when set, lead time and time to first review count only these hours. --after-hours and --review-sla default to Mon-Fri 09:00-18:00")
+	holidaysFlag := flag.String("holidays", os.Getenv("DORA_HOLIDAYS"), "Holiday calendars excluded from business hours and the deployment-frequency denominator: holiday files and/or country codes, comma-separated (e.g. JP or JP,holidays.txt)")
+	holidaysFileFlag := flag.String("holidays-file", os.Getenv("DORA_HOLIDAYS_FILE"), "File listing holidays (one YYYY-MM-DD per line) excluded from business hours and the deployment-frequency denominator")
+	freezeFlag := flag.String("freeze", os.Getenv("DORA_FREEZE"), "Deployment freeze windows ([name=]YYYY-MM-DD..YYYY-MM-DD, comma-separated) excluded from the deployment-frequency denominator and shaded in charts")
+	holidayCountryFlag := flag.String("holiday-country", os.Getenv("DORA_HOLIDAY_COUNTRY"), "Country code (e.g. JP) whose public holidays are fetched from date.nager.at and treated like --holidays-file")
+	timezoneFlag := flag.String("timezone", os.Getenv("DORA_TIMEZONE"), "IANA timezone (e.g. Asia/Tokyo) for --start/--end and period boundaries (default: UTC), daily and weekly bucketing, business hours and incident times without an offset (default: local)")
+	funnelFlag := flag.Bool("funnel", envBool("DORA_FUNNEL"), "Report how far PRs opened in the period got (ready → first review → approved → merged) with drop-off counts")
+	reviewMatrixFlag := flag.Bool("review-matrix", envBool("DORA_REVIEW_MATRIX"), "Build an author × reviewer matrix (review counts and median response time); shown in the HTML report")
+	newMembersFlag := flag.String("new-members", os.Getenv("DORA_NEW_MEMBERS"), "New members and their join dates (login=YYYY-MM-DD,...) for the onboarding report")
+	reviewMatrixOutFlag := flag.String("review-matrix-out", os.Getenv("DORA_REVIEW_MATRIX_OUT"), "Write the author × reviewer matrix as CSV to this path (implies --review-matrix)")
+	reviewSLAFlag := flag.Duration("review-sla", 0, "List PRs whose first review took longer than this many business hours (e.g. 4h; see --business-hours)")
+	reviewDigestFlag := flag.Bool("review-digest", envBool("DORA_REVIEW_DIGEST"), "List open PRs still waiting for a first review after --review-sla, grouped by requested reviewer")
+	reviewDigestWebhookFlag := flag.String("review-digest-webhook", os.Getenv("DORA_REVIEW_DIGEST_WEBHOOK"), "Post the --review-digest to this Slack webhook (implies --review-digest)")
+	maxCommitAgeFlag := flag.Duration("max-commit-age", 365*24*time.Hour, "With --lead-time-unit commit, commits authored this long before the PR was opened (or after it shipped) are treated as clock-skewed (0 = no lower bound)")
+	carryoverFlag := flag.String("carryover", envOr("DORA_CARRYOVER", "include"), "PRs merged in the period but opened before it: include, exclude or separate (reported on their own)")
+	carryoverAgeFlag := flag.Duration("carryover-age", 0, "With --carryover, only PRs opened more than this long before --from count as carried over (e.g. 720h)")
+	clockSkewFlag := flag.String("clock-skew", envOr("DORA_CLOCK_SKEW", "exclude"), "What to do with clock-skewed commits: exclude or clamp")
+	leadTimeWeightFlag := flag.String("lead-time-weight", envOr("DORA_LEAD_TIME_WEIGHT", "none"), "Weight lead time averages and percentiles: none or lines (additions + deletions)")
+	concurrencyFlag := flag.Int("concurrency", defaultPRWorkers, "PRs fetched in parallel per repository (1-50)")
+	repoConcurrencyFlag := flag.Int("repo-concurrency", defaultRepoWorkers, "Repositories analyzed in parallel (1-20); results are still aggregated in repository order")
+	apiFlag := flag.String("api", envOr("DORA_API", "rest"), "GitHub API used to fetch PRs: rest, or graphql (PRs with their commits, reviews and labels in one paginated query)")
+	leadTimeUnitFlag := flag.String("lead-time-unit", envOr("DORA_LEAD_TIME_UNIT", "pr"), "Lead time unit: pr (PR opened → deployed) or commit (each commit authored → deployed)")
+	benchmarkFlag := flag.Bool("benchmark", envBool("DORA_BENCHMARK"), "Classify each of the four keys (and the overall result) into the DORA Elite/High/Medium/Low tiers")
+	memberViewFlag := flag.String("member-view", envOr("DORA_MEMBER_VIEW", memberViewAbsolute), "How member breakdowns are shown: absolute, or relative to the team median (discourages ranking people)")
+	shadowFlag := flag.String("shadow-definitions", os.Getenv("DORA_SHADOW_DEFINITIONS"), "YAML/TOML file of alternative metric definitions (flag names) computed in the same run and reported side by side")
+	insightsFlag := flag.Bool("insights", envBool("DORA_INSIGHTS"), "Add suggested next steps derived from the results (terminal, Slack and JSON)")
+	hygieneFlag := flag.Bool("hygiene", envBool("DORA_HYGIENE"), "Score PR descriptions (body, linked issue, size, filled template) and correlate with review pickup time")
+	hygieneMaxLinesFlag := flag.Int("hygiene-max-lines", 400, "Changed lines at or below which a PR counts as small for --hygiene")
+	labelsReportFlag := flag.Bool("labels-report", envBool("DORA_LABELS_REPORT"), "Report the label distribution of merged PRs per repository")
+	includeLabelsFlag := flag.String("include-labels", os.Getenv("DORA_INCLUDE_LABELS"), "Only count PRs with at least one of these labels (comma-separated, globs such as deploy/* allowed)")
+	excludeLabelsFlag := flag.String("exclude-labels", os.Getenv("DORA_EXCLUDE_LABELS"), "Do not count PRs with any of these labels, e.g. chore,docs (comma-separated, globs allowed)")
+	requiredLabelsFlag := flag.String("required-labels", os.Getenv("DORA_REQUIRED_LABELS"), "Label groups every merged PR must have, e.g. bug|feature|chore,area/* (implies --labels-report)")
+	incidentsFileFlag := flag.String("incidents-file", os.Getenv("DORA_INCIDENTS_FILE"), "CSV of incidents (start,end,severity,service) for MTTR and incident-linked CFR")
+	incidentLabelsFlag := flag.String("incident-labels", os.Getenv("DORA_INCIDENT_LABELS"), "Comma-separated issue labels (e.g. incident,sev1) whose issues are incidents for MTTR (opened → closed), counted per repository")
+	pagerDutyServicesFlag := flag.String("pagerduty-services", os.Getenv("DORA_PAGERDUTY_SERVICES"), "PagerDuty service IDs whose incidents feed MTTR and incident-linked CFR (P1ABCDE or repo=P1ABCDE, comma-separated)")
+	pagerDutyAPIKeyFlag := flag.String("pagerduty-api-key", os.Getenv("PAGERDUTY_API_KEY"), "PagerDuty REST API key for --pagerduty-services")
+	pagerDutyAddressFlag := flag.String("pagerduty-address", envOr("PAGERDUTY_ADDRESS", "https://api.pagerduty.com"), "PagerDuty REST API address")
+	jiraURLFlag := flag.String("jira-url", os.Getenv("JIRA_URL"), "Jira base URL for --jira-jql (e.g. https://example.atlassian.net)")
+	jiraUserFlag := flag.String("jira-user", os.Getenv("JIRA_USER"), "Jira Cloud account email (leave empty for a Server/Data Center personal access token)")
+	jiraTokenFlag := flag.String("jira-api-token", os.Getenv("JIRA_API_TOKEN"), "Jira API token (Cloud) or personal access token (Server/Data Center)")
+	jiraJQLFlag := flag.String("jira-jql", os.Getenv("DORA_JIRA_JQL"), "JQL whose issues are incidents for MTTR and incident-linked CFR (created → resolved), e.g. type = Incident")
+	jiraRepoFieldFlag := flag.String("jira-repo-field", envOr("DORA_JIRA_REPO_FIELD", "component"), "Jira field that names the repository of an issue: component or label")
+	incidentWindowFlag := flag.Duration("incident-window", 24*time.Hour, "With --incidents-file and a deploy source, an incident starting within this window after a deployment marks that deployment as failed")
+	incidentDeploysFlag := flag.Int("incident-deploys", 1, "Split each incident across up to this many deployments before it within --incident-window, weighting recent ones more")
+	revertWindowFlag := flag.Duration("revert-window", 24*time.Hour, "Reverts merged within this window after the original PR was merged (or deployed) count as quick rollbacks")
+	cfrBasisFlag := flag.String("cfr-basis", envOr("DORA_CFR_BASIS", "auto"), "CFR definition: auto (deployments when a deploy source is set), prs, deployments")
+	outFlag := flag.String("out", "-", "Output file for export / collect / report (- for stdout)")
+	inFlag := flag.String("in", "", "Snapshot file written by collect, for the report subcommand")
+	formatFlag := flag.String("format", os.Getenv("DORA_FORMAT"), "Report format: text (default), html, json, csv, tsv, markdown, prometheus")
+	outputFlag := flag.String("output", "", "Outputs for the report, e.g. terminal,json=report.json,slack (terminal, html, json, csv, tsv, markdown, prometheus, slack, webhook); any other value is an output file, same as --out")
+	slackWebhookFlag := flag.String("slack-webhook", os.Getenv("DORA_SLACK_WEBHOOK"), "Slack Incoming Webhook for --output slack")
+	webhookURLFlag := flag.String("webhook-url", os.Getenv("DORA_WEBHOOK_URL"), "URL that receives the JSON report for --output webhook")
+	listenFlag := flag.String("listen", envOr("DORA_LISTEN", ":8080"), "Listen address for the serve subcommand")
+	tenantsFileFlag := flag.String("tenants-file", os.Getenv("DORA_TENANTS_FILE"), "YAML file defining API tenants for the serve subcommand")
+	storeFlag := flag.String("store", envOr("DORA_STORE", "snapshots"), "Directory where the serve subcommand keeps each tenant's snapshots")
+	scheduleFlag := flag.Duration("schedule", 0, "With serve, collect the last 30 days for every tenant at this interval (e.g. 24h); a tenant's own schedule takes precedence")
+
+	// サブコマンド
+	//   export:  集計せず PR ごとの生データを JSON Lines で出力する
+	//   collect: 収集結果をスナップショットに保存する（トークンが必要）
+	//   report:  スナップショットから集計・表示する（API を呼ばない）
+	//   compare: 2 つのスナップショットの指標を並べて差分を表示する
+	//   merge:   複数のスナップショットを 1 つにまとめる
+	//   serve:   テナントごとにスナップショットを保存・参照する API サーバー
+	//   estimate: PR 数と API リクエスト数・所要時間を見積もる（PR の中身は取得しない）
+	command := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	switch command {
+	case "", "export", "collect", "report", "compare", "merge", "serve", "estimate":
+	default:
+		log.Fatalf("❌ Error: Unknown command %q (want export, collect, report, compare, merge, serve or estimate)", command)
+	}
+	flag.Parse()
+	if *configFlag != "" {
+		values, err := loadConfigFile(*configFlag)
+		if err == nil {
+			err = applyConfig(flag.CommandLine, values)
+		}
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+
+	// --output は出力先の一覧（terminal,json=report.json,slack）か、従来どおりの出力ファイル名
+	format := *formatFlag
+	sinkSpec := format
+	switch {
+	case *outputFlag == "":
+	case isSinkList(*outputFlag):
+		if format != "" && len(splitList(*outputFlag)) > 1 {
+			log.Fatal("❌ Error: --format cannot be combined with a list of outputs in --output")
+		}
+		// 以前の --output は出力形式だった（--format があればそちらを優先する）
+		if format == "" {
+			sinkSpec = *outputFlag
+		}
+	default:
+		*outFlag = *outputFlag
+	}
+	if sinkSpec == "" {
+		sinkSpec = "text"
+	}
+	// 出力先が 1 つだけなら、その形式で --periods などを出す
+	format = ""
+	if items := splitList(sinkSpec); len(items) == 1 && !strings.Contains(items[0], "=") {
+		format = items[0]
+		if format == "terminal" {
+			format = "text"
+		}
+	}
+	var periods []reportPeriod
+	if *periodsFlag != "" || *bucketFlag != "" {
+		trend := "--periods"
+		if *bucketFlag != "" {
+			trend = "--bucket"
+		}
+		if *periodsFlag != "" && *bucketFlag != "" {
+			log.Fatal("❌ Error: --periods and --bucket cannot be combined")
+		}
+		if command != "" && command != "report" {
+			log.Fatalf("❌ Error: %s only works for the default report and report --in", trend)
+		}
+		if !slices.Contains(fileSinkFormats, format) {
+			log.Fatalf("❌ Error: %s writes a single report; use --format", trend)
+		}
+		if format == "html" || format == "prometheus" {
+			log.Fatalf("❌ Error: %s supports text, markdown, json, csv and tsv", trend)
+		}
+	}
+	if *periodsFlag != "" {
+		var err error
+		if periods, err = parsePeriods(*periodsFlag); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		*startFlag, *endFlag = periodsSpan(periods)
+	}
+	if *formatFlag != "" && !slices.Contains(fileSinkFormats, *formatFlag) {
+		log.Fatalf("❌ Error: Unsupported --format %q (want text, html, json, csv, tsv, markdown or prometheus)", *formatFlag)
+	}
+
+	switch *cfrBasisFlag {
+	case "auto", "deployments":
+	case "prs":
+		cfrPRBased = true
+	default:
+		log.Fatalf("❌ Error: Unsupported --cfr-basis %q", *cfrBasisFlag)
+	}
+
+	if command == "merge" {
+		if flag.NArg() < 2 || *outFlag == "-" {
+			log.Fatal("❌ Error: merge requires --out <snapshot> and at least two snapshots")
+		}
+		if err := runMerge(flag.Args(), *outFlag); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		return
+	}
+
+	var snap *snapshot
+	if command == "report" {
+		if *inFlag == "" {
+			log.Fatal("❌ Error: report requires --in <snapshot>")
+		}
+		var err error
+		snap, err = readSnapshot(*inFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	} else if command != "compare" && command != "serve" {
+		if tokenForOrg(*ownerFlag) == "" || *ownerFlag == "" || (*reposFlag == "" && !*discoverFlag) || *startFlag == "" || *endFlag == "" {
+			log.Fatal("❌ Error: Missing required parameters.")
+		}
+		if command == "collect" && *outFlag == "-" {
+			log.Fatal("❌ Error: collect requires --out <snapshot>")
+		}
+	}
+	// --bucket は期間（report ではスナップショットの期間）を週・月に分けた --periods
+	if *bucketFlag != "" {
+		from, to := *startFlag, *endFlag
+		if snap != nil && from == "" && to == "" {
+			from, to = snap.From, snap.To
+		}
+		var err error
+		if periods, err = bucketPeriods(from, to, *bucketFlag); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+	switch *carryoverFlag {
+	case carryoverInclude, carryoverExclude, carryoverSeparate:
+	default:
+		log.Fatalf("❌ Error: Unsupported --carryover %q (want include, exclude or separate)", *carryoverFlag)
+	}
+	switch *clockSkewFlag {
+	case "exclude", "clamp":
+	default:
+		log.Fatalf("❌ Error: Unsupported --clock-skew %q (want exclude or clamp)", *clockSkewFlag)
+	}
+	switch *leadTimeWeightFlag {
+	case "none":
+	case "lines":
+		leadTimeWeighted = true
+	default:
+		log.Fatalf("❌ Error: Unsupported --lead-time-weight %q (want none or lines)", *leadTimeWeightFlag)
+	}
+	percentiles, err := parsePercentiles(*percentilesFlag)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	reportPercentiles = percentiles
+	if histogramBounds, err = parseHistogramBuckets(*histogramBucketsFlag); err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	// report は収集時のタイムゾーンで期間を区切る（--timezone で上書きできる）
+	reportTimezone := *timezoneFlag
+	if reportTimezone == "" && snap != nil {
+		reportTimezone = snap.Timezone
+	}
+	if reportTimezone != "" {
+		if reportLocation, err = time.LoadLocation(reportTimezone); err != nil {
+			log.Fatalf("❌ Error: invalid timezone %q: %v", reportTimezone, err)
+		}
+	}
+	if *concurrencyFlag < 1 || *concurrencyFlag > 50 {
+		log.Fatalf("❌ Error: --concurrency must be between 1 and 50, got %d", *concurrencyFlag)
+	}
+	if *incidentDeploysFlag < 1 {
+		log.Fatalf("❌ Error: --incident-deploys must be at least 1, got %d", *incidentDeploysFlag)
+	}
+	if *repoConcurrencyFlag < 1 || *repoConcurrencyFlag > 20 {
+		log.Fatalf("❌ Error: --repo-concurrency must be between 1 and 20, got %d", *repoConcurrencyFlag)
+	}
+	switch *apiFlag {
+	case "rest", "graphql":
+	default:
+		log.Fatalf("❌ Error: Unsupported --api %q (want rest or graphql)", *apiFlag)
+	}
+	switch *leadTimeUnitFlag {
+	case "pr", "commit":
+	default:
+		log.Fatalf("❌ Error: Unsupported --lead-time-unit %q", *leadTimeUnitFlag)
+	}
+	if *maxPRsFlag > 0 && *sampleFlag != "random" {
+		log.Fatalf("❌ Error: Unsupported --sample strategy %q", *sampleFlag)
+	}
+
+	switch *memberViewFlag {
+	case memberViewAbsolute, memberViewRelative:
+	default:
+		log.Fatalf("❌ Error: Unsupported --member-view %q (want absolute or relative)", *memberViewFlag)
+	}
+	switch *memberRoleFlag {
+	case "all", "admin", "member":
+	default:
+		log.Fatalf("❌ Error: Unsupported --member-role %q (want all, admin or member)", *memberRoleFlag)
+	}
+
+	// --repos all / --discover はクライアントを作ってから一覧を引く
+	discover := *discoverFlag || *reposFlag == allReposKeyword
+	if discover && *providerFlag != "github" {
+		log.Fatalf("❌ Error: --repos %s is only supported for GitHub", allReposKeyword)
+	}
+	var repos []string
+	if !discover {
+		var err error
+		if repos, err = providerRepos(*providerFlag, *ownerFlag, splitList(*reposFlag)); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	telemetry := newAPITelemetry()
+	netOpts := client.HTTPOptions{CACertFile: *caCertFlag, InsecureSkipVerify: *insecureFlag}
+	baseTransport, err := client.NewTransport(netOpts)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	tr := newTracer(*otlpEndpointFlag, envOr("OTEL_SERVICE_NAME", "dora-metrics"), os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), baseTransport)
+	ssoHook := ssoPartialResultsHook()
+	retries := retryPolicy{MaxRetries: *maxRetriesFlag, BaseDelay: time.Second, MaxWait: *maxRateLimitWaitFlag}
+	// キャッシュはネットワークに一番近い位置に置き、計測では 304 をキャッシュヒットとして数える
+	// cacheDir が空ならキャッシュしない
+	newCachedClient := func(token, cacheDir string) (*github.Client, error) {
+		cacheMiddleware := func(next http.RoundTripper) http.RoundTripper { return next }
+		if cacheDir != "" {
+			cache, err := newDiskCache(cacheDir)
+			if err != nil {
+				return nil, err
+			}
+			cacheMiddleware = cache.Middleware
+		}
+		return client.NewClient(ctx, token,
+			client.WithCACert(*caCertFlag),
+			client.WithInsecureSkipVerify(*insecureFlag),
+			client.WithUserAgent(*userAgentFlag),
+			client.WithBaseURL(*githubAPIURLFlag),
+			client.WithResponseHook(ssoHook),
+			client.WithMiddleware(cacheMiddleware),
+			client.WithMiddleware(telemetry.Middleware),
+			client.WithMiddleware(tr.Middleware),
+			// 送り直しの 1 回ごとに計測・トレースされるよう一番外側に置く
+			client.WithMiddleware(retries.Middleware),
+		)
+	}
+	newClient := func(token string) (*github.Client, error) {
+		return newCachedClient(token, *cacheDirFlag)
+	}
+	if *cacheDirFlag != "" {
+		if _, err := newDiskCache(*cacheDirFlag); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+	// Organization ごとにトークンを切り替えられるよう、クライアントは Organization 単位で作る
+	clientFor := func(org string) (*github.Client, error) {
+		return newClient(tokenForOrg(org))
+	}
+	if discover && snap == nil && command != "compare" && command != "serve" {
+		client, err := clientFor(*ownerFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		if repos, err = discoverRepos(ctx, client, *ownerFlag); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		if len(repos) == 0 {
+			log.Fatalf("❌ Error: %s has no repositories to analyze", *ownerFlag)
+		}
+	}
+	// report では --repo-filter をスナップショットのリポジトリに当てる
+	if snap != nil && *repoFilterFlag != "" && len(repos) == 0 {
+		for _, r := range snap.Repos {
+			repos = append(repos, r.Name)
+		}
+	}
+	if *repoFilterFlag != "" && len(repos) > 0 {
+		filtered, err := filterRepos(repos, *repoFilterFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		if len(filtered) == 0 {
+			log.Fatalf("❌ Error: No repositories match --repo-filter %q", *repoFilterFlag)
+		}
+		repos = filtered
+	}
+	if discover && snap == nil && len(repos) > 0 {
+		fmt.Fprintf(os.Stderr, "🔎 Found %d repositories in %s\n", len(repos), *ownerFlag)
+	}
+
+	// 集計・表示の設定（スナップショットを読む report / compare でも同じものを使う）
+	var aliases aliasMap
+	if *aliasesFileFlag != "" {
+		aliases, err = loadAliasesFile(*aliasesFileFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+	newMembers, err := parseNewMembers(*newMembersFlag, aliases)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	// --members org は Organization のメンバー一覧を API で引く
+	memberMap := make(map[string]bool)
+	if *membersFlag != "" {
+		org := *ownerFlag
+		if snap != nil {
+			org = snap.Owner
+		}
+		if memberMap, err = resolveMembers(ctx, clientFor, org, *membersFlag, *memberRoleFlag, aliases); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+	var teams *teamsFile
+	if *teamsFileFlag != "" {
+		teams, err = loadTeamsFile(*teamsFileFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+	// --team は Teams API でメンバーを引き、チーム別の集計に加える（メンバーの絞り込みにも使う）
+	membership := make(map[string][]string)
+	if teams != nil {
+		membership = teams.membership()
+	}
+	if *teamFlag != "" {
+		org := *ownerFlag
+		if snap != nil {
+			org = snap.Owner
+		}
+		apiTeams, err := resolveTeams(ctx, clientFor, org, splitList(*teamFlag), aliases)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		if teams != nil {
+			teams.addTeams(apiTeams)
+			membership = teams.membership()
+		} else {
+			membership = (&teamsFile{Teams: apiTeams}).membership()
+		}
+		for _, t := range apiTeams {
+			for _, m := range t.Members {
+				memberMap[m] = true
+			}
+		}
+	}
+	businessHoursSpec := *businessHoursFlag
+	if businessHoursSpec == "" {
+		businessHoursSpec = defaultBusinessHours
+	} else if workingHours, err = parseBusinessHours(businessHoursSpec, *timezoneFlag); err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	var hours *businessHours
+	if *afterHoursFlag {
+		hours, err = parseBusinessHours(businessHoursSpec, *timezoneFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+	holidayFiles, holidayCountries, err := splitHolidaySources(*holidaysFlag)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	if *holidaysFileFlag != "" {
+		holidayFiles = append(holidayFiles, *holidaysFileFlag)
+	}
+	if *holidayCountryFlag != "" {
+		holidayCountries = append(holidayCountries, *holidayCountryFlag)
+	}
+	for _, path := range holidayFiles {
+		cal, err := loadHolidaysFile(path)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		holidays.merge(cal)
+	}
+	if freezes, err = parseFreezes(*freezeFlag); err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	if len(holidayCountries) > 0 {
+		// 期間が決まっていない compare / serve は直近 3 年分を取る
+		fromYear, toYear := time.Now().Year()-2, time.Now().Year()
+		from, to := *startFlag, *endFlag
+		if snap != nil {
+			from, to = snap.From, snap.To
+		}
+		if start, err := parseDay(from); err == nil {
+			fromYear = start.Year()
+		}
+		if end, err := parseDay(to); err == nil {
+			toYear = end.Year()
+		}
+		// 営業時間で数える場合、期間前に作成された PR のリードタイムにかかる前年の祝日も取る
+		if workingHours != nil {
+			fromYear--
+		}
+		client := &http.Client{Transport: baseTransport, Timeout: 30 * time.Second}
+		for _, country := range holidayCountries {
+			cal, err := fetchCountryHolidays(ctx, client, country, fromYear, toYear)
+			if err != nil {
+				log.Fatalf("❌ Error: %v", err)
+			}
+			holidays.merge(cal)
+		}
+	}
+	if *reviewDigestWebhookFlag != "" {
+		*reviewDigestFlag = true
+	}
+	if *reviewDigestFlag && *reviewSLAFlag <= 0 {
+		log.Fatal("❌ Error: --review-digest requires --review-sla")
+	}
+	var slaHours *businessHours
+	if *reviewSLAFlag > 0 {
+		slaHours, err = parseBusinessHours(businessHoursSpec, *timezoneFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+	var incidents []incident
+	if *incidentsFileFlag != "" {
+		loc := time.Local
+		if *timezoneFlag != "" {
+			if loc, err = time.LoadLocation(*timezoneFlag); err != nil {
+				log.Fatalf("❌ Error: %v", err)
+			}
+		}
+		incidents, err = loadIncidentsFile(*incidentsFileFlag, loc)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		if incidents == nil {
+			incidents = []incident{}
+		}
+		incidentMTTR = true
+	}
+	// --incident-labels はラベルの付いた Issue をリポジトリごとに引いてインシデントに加える
+	if labels := splitList(*incidentLabelsFlag); len(labels) > 0 {
+		if command == "compare" || command == "serve" {
+			log.Fatalf("❌ Error: --incident-labels is not supported by %s; use --incidents-file", command)
+		}
+		owner, from, issueRepos := *ownerFlag, *startFlag, repos
+		if snap != nil {
+			owner, from = snap.Owner, snap.From
+			if len(issueRepos) == 0 {
+				for _, r := range snap.Repos {
+					issueRepos = append(issueRepos, r.Name)
+				}
+			}
+		}
+		since, err := parseDay(from)
+		if err != nil {
+			log.Fatalf("❌ Error: invalid start date %q: %v", from, err)
+		}
+		client, err := clientFor(owner)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		if incidents == nil {
+			incidents = []incident{}
+		}
+		found := 0
+		for _, repo := range issueRepos {
+			if _, _, ok := splitForgeRepo(repo); ok {
+				continue
+			}
+			issues, err := fetchIncidentIssues(ctx, client, owner, repo, labels, since)
+			if err != nil {
+				log.Fatalf("❌ Error: %v", err)
+			}
+			incidents = append(incidents, issues...)
+			found += len(issues)
+		}
+		sort.Slice(incidents, func(i, j int) bool { return incidents[i].Start.Before(incidents[j].Start) })
+		fmt.Fprintf(os.Stderr, "🚨 Found %d incident issues labeled %s\n", found, strings.Join(labels, ", "))
+		incidentMTTR = true
+	}
+	// 外部のインシデント（PagerDuty / Jira）は解析する期間に作成されたものを引く
+	incidentWindow := func() (time.Time, time.Time) {
+		from, to := *startFlag, *endFlag
+		if snap != nil {
+			from, to = snap.From, snap.To
+		}
+		start, err := parseDay(from)
+		if err != nil {
+			log.Fatalf("❌ Error: invalid start date %q: %v", from, err)
+		}
+		end, err := parseDay(to)
+		if err != nil {
+			log.Fatalf("❌ Error: invalid end date %q: %v", to, err)
+		}
+		return start, end.AddDate(0, 0, 1)
+	}
+	// --pagerduty-services は期間内に作成された PagerDuty のインシデントを加える
+	if *pagerDutyServicesFlag != "" {
+		if command == "compare" || command == "serve" {
+			log.Fatalf("❌ Error: --pagerduty-services is not supported by %s; use --incidents-file", command)
+		}
+		pd, err := newPagerDutySource(baseTransport, *pagerDutyAddressFlag, *pagerDutyAPIKeyFlag, *pagerDutyServicesFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		start, end := incidentWindow()
+		found, err := pd.Incidents(ctx, start, end)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		incidents = append(incidents, found...)
+		if incidents == nil {
+			incidents = []incident{}
+		}
+		sort.Slice(incidents, func(i, j int) bool { return incidents[i].Start.Before(incidents[j].Start) })
+		fmt.Fprintf(os.Stderr, "🚨 Found %d PagerDuty incidents\n", len(found))
+		incidentMTTR = true
+	}
+	// --jira-jql は期間内に作成された課題を加える
+	if *jiraJQLFlag != "" {
+		if command == "compare" || command == "serve" {
+			log.Fatalf("❌ Error: --jira-jql is not supported by %s; use --incidents-file", command)
+		}
+		jira, err := newJiraSource(baseTransport, *jiraURLFlag, *jiraUserFlag, *jiraTokenFlag, *jiraJQLFlag, *jiraRepoFieldFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		jiraRepos := repos
+		if snap != nil && len(jiraRepos) == 0 {
+			for _, r := range snap.Repos {
+				jiraRepos = append(jiraRepos, r.Name)
+			}
+		}
+		start, end := incidentWindow()
+		found, err := jira.Incidents(ctx, start, end, jiraRepos)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		incidents = append(incidents, found...)
+		if incidents == nil {
+			incidents = []incident{}
+		}
+		sort.Slice(incidents, func(i, j int) bool { return incidents[i].Start.Before(incidents[j].Start) })
+		fmt.Fprintf(os.Stderr, "🚨 Found %d Jira incidents\n", len(found))
+		incidentMTTR = true
+	}
+	configure := func(a *analyzer) {
+		a.members = memberMap
+		a.aliases = aliases
+		a.membership = membership
+		a.conventional = *conventionalFlag
+		a.insights = *insightsFlag
+		a.excludeBots = *excludeBotsFlag
+		a.excludeUsers = parseExcludeUsers(*excludeUsersFlag)
+		a.memberView = *memberViewFlag
+		a.benchmark = *benchmarkFlag
+		a.histogram = *histogramFlag
+		a.conventionalCFR = *conventionalCFRFlag
+		a.fixWindow = *fixWindowFlag
+		a.failureMarkers = splitList(*failureMarkerFlag)
+		a.markersOnly = *markersOnlyFlag
+		a.governance = *governanceFlag
+		a.hygiene = *hygieneFlag
+		a.deployBranch = *deployBranchFlag
+		a.hygieneMaxLines = *hygieneMaxLinesFlag
+		a.requiredLabels = parseRequiredLabels(*requiredLabelsFlag)
+		a.includeLabels = parseLabelPatterns(*includeLabelsFlag)
+		a.excludeLabels = parseLabelPatterns(*excludeLabelsFlag)
+		a.labelReport = *labelsReportFlag || len(a.requiredLabels) > 0
+		a.hours = hours
+		a.incidents = incidents
+		a.reviewSLA = *reviewSLAFlag
+		a.commitLeadTime = *leadTimeUnitFlag == "commit"
+		a.maxCommitAge = *maxCommitAgeFlag
+		a.clampSkew = *clockSkewFlag == "clamp"
+		a.carryoverMode = *carryoverFlag
+		a.carryoverAge = *carryoverAgeFlag
+		a.reviewMatrix = *reviewMatrixFlag || *reviewMatrixOutFlag != ""
+		a.slaHours = slaHours
+		a.newMembers = newMembers
+		a.incidentWindow = *incidentWindowFlag
+		a.incidentDeploys = *incidentDeploysFlag
+		if incidents != nil {
+			// 全体にはリポジトリに結び付かないインシデントも数える
+			a.team.addIncidents(a.incidentsFor(""))
+		}
+	}
+	repoFilter := make(map[string]bool)
+	for _, r := range repos {
+		repoFilter[r] = true
+	}
+
+	if command == "serve" {
+		if *tenantsFileFlag == "" {
+			log.Fatal("❌ Error: serve requires --tenants-file")
+		}
+		tf, err := loadTenantsFile(*tenantsFileFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		if *scheduleFlag != 0 && *scheduleFlag < minScheduleInterval {
+			log.Fatalf("❌ Error: --schedule must be at least %s, got %s", minScheduleInterval, *scheduleFlag)
+		}
+		// テナントごとにキャッシュを分ける。同じトークンのテナントでもエントリを共有せず、1 つを消しても他に響かない
+		tenantClient := func(tenant, token string) (*github.Client, error) {
+			dir := ""
+			if *cacheDirFlag != "" {
+				dir = filepath.Join(*cacheDirFlag, "tenants", tenant)
+			}
+			return newCachedClient(token, dir)
+		}
+		srv := newMetricsServer(tf.Tenants, *storeFlag, tenantClient, tr)
+		fmt.Printf("🌐 Serving %d tenants on %s\n", len(tf.Tenants), *listenFlag)
+		srv.startSchedules(ctx, *scheduleFlag)
+		log.Fatal((&http.Server{Addr: *listenFlag, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}).ListenAndServe())
+	}
+
+	if command == "compare" {
+		if flag.NArg() != 2 {
+			log.Fatal("❌ Error: compare requires two snapshots: compare <a.db> <b.db>")
+		}
+		var pair [2]*analyzer
+		for i, path := range flag.Args() {
+			s, err := readSnapshot(path)
+			if err != nil {
+				log.Fatalf("❌ Error: %v", err)
+			}
+			pair[i] = newSnapshotAnalyzer(s, tr)
+			configure(pair[i])
+			pair[i].replay(s, repoFilter)
+		}
+		printComparison(pair[0], pair[1])
+		return
+	}
+
+	sinks, err := parseSinks(sinkSpec, sinkConfig{
+		out:          *outFlag,
+		teams:        teams,
+		client:       &http.Client{Transport: baseTransport, Timeout: 30 * time.Second},
+		slackWebhook: *slackWebhookFlag,
+		webhookURL:   *webhookURLFlag,
+	})
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	if rw := newRemoteWriter(*remoteWriteFlag, *remoteWriteHeadersFlag, baseTransport); rw != nil {
+		sinks = append(sinks, remoteWriteSink{rw})
+	}
+	if pg := newPushgateway(*pushgatewayFlag, *pushgatewayJobFlag, baseTransport); pg != nil {
+		sinks = append(sinks, pushgatewaySink{pg})
+	}
+
+	var a *analyzer
+	var client *github.Client
+	if snap != nil {
+		a = newSnapshotAnalyzer(snap, tr)
+		if *funnelFlag {
+			log.Printf("⚠️  --funnel needs a live run: snapshots only contain merged PRs")
+		}
+	} else {
+		client, err = clientFor(*ownerFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		a = newAnalyzer(client, tr, *ownerFlag, *startFlag, *endFlag)
+		a.maxPRs = *maxPRsFlag
+		a.env = *deployEnvFlag
+		a.revertWindow = *revertWindowFlag
+		a.funnel = *funnelFlag
+		a.graphql = *apiFlag == "graphql"
+		a.workers = *concurrencyFlag
+		a.repoWorkers = *repoConcurrencyFlag
+		forgeClient := &http.Client{Transport: retries.Middleware(baseTransport), Timeout: time.Minute}
+		a.forges = map[string]forge{
+			"gitlab":    newGitLabForge(*gitlabURLFlag, os.Getenv("GITLAB_TOKEN"), forgeClient),
+			"bitbucket": newBitbucketForge("https://api.bitbucket.org/2.0", os.Getenv("BITBUCKET_TOKEN"), os.Getenv("BITBUCKET_USERNAME"), os.Getenv("BITBUCKET_APP_PASSWORD"), forgeClient),
+		}
+		// Gitea はホストが決まっていないので、URL を指定したときだけ使える
+		if *giteaURLFlag != "" {
+			a.forges["gitea"] = newGiteaForge(*giteaURLFlag, os.Getenv("GITEA_TOKEN"), forgeClient)
+		}
+		if err := a.validateForgeRepos(repos); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+	}
+	configure(a)
+	runCtx, runSpan := tr.Start(ctx, "dora.run", map[string]any{"dora.owner": a.owner, "dora.from": a.from, "dora.to": a.to})
+	if *insecureFlag {
+		fmt.Fprintln(os.Stderr, "⚠️  TLS certificate verification is disabled")
+	}
+
+	// フラグからデプロイソースを作る（--shadow-definitions では上書きしたフラグで 2 つ目を作る）
+	newDeploySource := func(a *analyzer) deploymentSource {
+		if *deployWorkflowFlag != "" && *deploySourceFlag == "merge" {
+			*deploySourceFlag = "workflow"
+		}
+		switch *deploySourceFlag {
+		case "merge":
+			return nil
+		case "gitops":
+			src, err := newGitOpsSource(client, *ownerFlag, *gitopsRepoFlag, *gitopsPathFlag, *gitopsEnvFlag, *gitopsTagFlag, *startFlag)
+			if err != nil {
+				log.Fatalf("❌ Error: %v", err)
+			}
+			if src.owner != *ownerFlag {
+				if src.client, err = clientFor(src.owner); err != nil {
+					log.Fatalf("❌ Error: %v", err)
+				}
+				src.appClient = client
+			}
+			return src
+		case "terraform":
+			from, _ := a.window()
+			src, err := newTerraformSource(baseTransport, *tfcAddressFlag, envOr("TFC_TOKEN", os.Getenv("TF_API_TOKEN")), *tfcOrgFlag, *tfcWorkspacesFlag, from)
+			if err != nil {
+				log.Fatalf("❌ Error: %v", err)
+			}
+			return src
+		case "changelog":
+			from, _ := a.window()
+			src := newChangelogSource(client, *ownerFlag, *changelogPathFlag, from)
+			if a.deployBranch != anyBranch {
+				src.branch = a.deployBranch
+			}
+			return src
+		case "semver":
+			from, _ := a.window()
+			src := newSemverSource(client, *ownerFlag, from)
+			if a.deployBranch != anyBranch {
+				src.branch = a.deployBranch
+			}
+			return src
+		case "deployments":
+			from, _ := a.window()
+			return newGitHubDeploymentsSource(client, *ownerFlag, from)
+		case "releases":
+			from, _ := a.window()
+			return newReleaseSource(client, *ownerFlag, *releaseTagPatternFlag, false, from)
+		case "tags":
+			from, _ := a.window()
+			pattern := *releaseTagPatternFlag
+			if pattern == "" {
+				pattern = "v*"
+			}
+			return newReleaseSource(client, *ownerFlag, pattern, true, from)
+		case "workflow":
+			if *deployWorkflowFlag == "" {
+				log.Fatal("❌ Error: --deploy-source=workflow requires --deploy-workflow")
+			}
+			from, _ := a.window()
+			return newWorkflowSource(client, *ownerFlag, *deployWorkflowFlag, *workflowFailuresFlag, from)
+		case "audit-log":
+			from, _ := a.window()
+			return newAuditLogSource(client, *ownerFlag, *deployWorkflowFlag, *workflowFailuresFlag, from)
+		default:
+			log.Fatalf("❌ Error: Unsupported --deploy-source %q", *deploySourceFlag)
+		}
+		return nil
+	}
+	if snap == nil {
+		a.deploys = newDeploySource(a)
+	}
+	// 同じ PR を別の定義でも集計し、結果を並べる（定義を切り替える前の検証用）
+	if *shadowFlag != "" {
+		if command != "" || snap != nil || len(periods) > 0 {
+			log.Fatal("❌ Error: --shadow-definitions needs a live analysis (no subcommand, --in, --periods or --bucket)")
+		}
+		for _, r := range repos {
+			if _, _, ok := a.forgeFor(r); ok {
+				log.Fatalf("❌ Error: --shadow-definitions supports GitHub repositories only (got %s)", r)
+			}
+		}
+		overrides, err := loadShadowDefinitions(*shadowFlag)
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		shadow := newAnalyzer(client, tr, *ownerFlag, *startFlag, *endFlag)
+		err = withFlagOverrides(flag.CommandLine, overrides, func() {
+			shadow.maxPRs = a.maxPRs
+			shadow.env = *deployEnvFlag
+			shadow.revertWindow = a.revertWindow
+			configure(shadow)
+			shadow.deploys = newDeploySource(shadow)
+		})
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		a.shadow, a.shadowOverrides = shadow, overrides
+	}
+
+	switch command {
+	case "estimate":
+		if err := runEstimate(runCtx, a, repos); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		return
+	case "export", "collect":
+		fmt.Fprintf(os.Stderr, "🚀 Collecting: %s to %s\n", a.from, a.to)
+		if command == "export" {
+			err = runExport(runCtx, a, repos, *outFlag)
+		} else {
+			err = runCollect(runCtx, a, repos, *outFlag)
+		}
+		if err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		runSpan.End()
+		if err := tr.Flush(ctx); err != nil {
+			log.Printf("⚠️  Failed to export traces: %v", err)
+		}
+		return
+	}
+
+	if *cfrBasisFlag == "deployments" && a.deploys == nil {
+		log.Fatal("❌ Error: --cfr-basis=deployments requires a --deploy-source other than merge")
+	}
+
+	// 全期間を 1 回で取得し、期間ごとに再集計して並べる
+	if len(periods) > 0 {
+		if snap == nil {
+			fmt.Fprintf(os.Stderr, "🚀 Collecting: %s to %s (%d periods)\n", a.from, a.to, len(periods))
+			snap = collectSnapshot(runCtx, a, repos)
+		}
+		runSpan.End()
+		reports := summarizePeriods(snap, periods, tr, configure, repoFilter)
+		if err := writeReportFile(*outFlag, func(w io.Writer) error { return writePeriodsReport(w, reports, format) }); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		if err := tr.Flush(ctx); err != nil {
+			log.Printf("⚠️  Failed to export traces: %v", err)
+		}
+		return
+	}
+
+	if snap != nil {
+		a.replay(snap, repoFilter)
+	} else {
+		// JSON / HTML を標準出力に流せるよう、進捗は text 以外では標準エラーに出す
+		progress := os.Stdout
+		if sinksUseStdout(sinks) {
+			progress = os.Stderr
+		}
+		fmt.Fprintf(progress, "🚀 Analyzing: %s to %s\n", a.from, a.to)
+		a.analyzeRepos(runCtx, repos, progress)
+	}
+	runSpan.End()
+
+	// ファイルへの書き込みの失敗は致命的、送信先の失敗は警告にとどめて他の出力先を続ける
+	for _, s := range sinks {
+		if err := s.Write(ctx, a); err != nil {
+			if _, ok := s.(fileSink); ok {
+				log.Fatalf("❌ Error: %v", err)
+			}
+			log.Printf("⚠️  Failed to send the report to %s: %v", s.Name(), err)
+		}
+	}
+	if *reviewMatrixOutFlag != "" {
+		if err := writeReportFile(*reviewMatrixOutFlag, func(w io.Writer) error { return writeReviewMatrixCSV(w, a.pairs) }); err != nil {
+			log.Printf("⚠️  Failed to write review matrix: %v", err)
+		}
+	}
+	if teams != nil && *notifyFlag {
+		reports := buildTargetReports(a, teams, periodDays(a.from, a.to))
+		if err := notifyTargets(ctx, &http.Client{Transport: baseTransport, Timeout: 30 * time.Second}, a.from, a.to, reports); err != nil {
+			log.Printf("⚠️  Failed to send Slack notification: %v", err)
+		}
+	}
+	// レビュー待ちの催促（計測と同じ実行で、いまオープンな PR を見る）
+	if *reviewDigestFlag {
+		if snap != nil {
+			log.Printf("⚠️  --review-digest needs a live run: snapshots only contain merged PRs")
+		} else if digest, err := a.reviewDigest(ctx, repos, time.Now()); err != nil {
+			log.Printf("⚠️  Failed to build the review digest: %s", describeAPIError(err, a.owner))
+		} else {
+			if hasSink(sinks, "text") {
+				printReviewDigest(a.reviewSLA, digest)
+			}
+			if *reviewDigestWebhookFlag != "" && len(digest) > 0 {
+				if err := postSlack(ctx, &http.Client{Transport: baseTransport, Timeout: 30 * time.Second}, *reviewDigestWebhookFlag, reviewDigestText(a.reviewSLA, digest)); err != nil {
+					log.Printf("⚠️  Failed to post the review digest: %v", err)
+				}
+			}
+		}
+	}
+
+	if err := tr.Flush(ctx); err != nil {
+		log.Printf("⚠️  Failed to export traces: %v", err)
+	}
+	if *telemetryFlag {
+		telemetry.Print()
+	}
+	if *telemetryOutFlag != "" {
+		if err := telemetry.WriteJSON(*telemetryOutFlag); err != nil {
+			log.Printf("⚠️  Failed to write telemetry: %v", err)
+		}
+	}
+}
+
+// 集計結果をコンソールに表示する
+func printReport(a *analyzer, teams *teamsFile) {
+	displayResults(a.from, a.to, a.leadTimeDefinition(), a.team, a.repos, a.users, a.memberView == memberViewRelative)
+	sum := summarize(a)
+	if a.benchmark {
+		printTierSummary(sum)
+	}
+	printPercentileSummary(sum)
+	if a.histogram {
+		printHistogramSummary(sum)
+	}
+	if a.team.ClockSkewedPRs > 0 {
+		fmt.Println(clockSkewNote(a.team, a.clampSkew))
+	}
+	printCarryoverSummary(a)
+	printDeployFrequencySummary(a.from, a.to, a.team, a.repos)
+	printDeployGapSummary(a.team, a.repos)
+	if a.deploys != nil {
+		printDeploymentSummary(a.deploys.Name(), a.from, a.to, a.team, a.repos)
+		if len(a.team.Environments) > 0 {
+			printEnvironmentSummary(a.from, a.to, a.team, a.repos)
+		}
+	}
+	if len(a.teamStats) > 0 {
+		printTeamSummary(a.teamStats, a.deploys == nil)
+	}
+	if teams != nil {
+		printTargetSummary(buildTargetReports(a, teams, periodDays(a.from, a.to)))
+	}
+	if a.governance {
+		printGovernanceSummary(a.team, a.repos, a.users)
+	}
+	if a.team.RevertPRs > 0 || a.team.Relands > 0 {
+		printRevertSummary(a.revertWindow, a.team, a.repos)
+	}
+	if a.team.Funnel != nil {
+		printFunnelSummary(a.team, a.repos)
+	}
+	if a.reviewMatrix {
+		printReviewLoadSummary(a.pairs)
+	}
+	if len(a.newMembers) > 0 {
+		printOnboardingSummary(a)
+	}
+	if a.reviewSLA > 0 {
+		printReviewSLABreaches(a.reviewSLA, a.slaHours, a.breaches, a.team.ReviewChecked)
+	}
+	if a.incidents != nil {
+		printIncidentSummary(a.team, a.repos, a.deploys != nil)
+	}
+	if a.hygiene {
+		printHygieneSummary(a.team, a.repos, a.users)
+	}
+	if a.labelReport {
+		printLabelSummary(a)
+	}
+	if a.hours != nil {
+		printOffHoursSummary(a.hours, a.team, a.repos, a.users, a.deploys == nil)
+	}
+	if a.conventional {
+		printChangeTypeSummary(a.team, a.repos)
+	}
+	if a.duplicates > 0 {
+		fmt.Printf("🔁 %d PRs shared a merge commit with another analyzed repository and were counted once in the team/contributor totals\n", a.duplicates)
+	}
+	if a.maxPRs > 0 {
+		printSamplingSummary(a.team, a.repos)
+	}
+	if a.shadow != nil {
+		printShadowComparison(a)
+	}
+	if a.insights {
+		printInsights(buildInsights(a))
+	}
+}
+
+// path が "-" なら標準出力に書く
+func writeReportFile(path string, write func(io.Writer) error) error {
+	if path == "-" || path == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string) int {
+	v, _ := strconv.Atoi(os.Getenv(key))
+	return v
+}
+
+func envBool(key string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(key))
+	return v
+}
+
+// 失敗（不具合修正）とみなすキーワード（タイトル・ブランチ・ラベル）
+var bugFixKeywords = []string{"bug", "fix", "hotfix", "defect", "incident", "patch", "security", "dependabot", "不具合", "修正"}
+
+func isBugFix(pr *github.PullRequest) bool {
+	title := strings.ToLower(pr.GetTitle())
+	branch := strings.ToLower(pr.GetHead().GetRef())
+	keywords := bugFixKeywords
+
+	for _, l := range pr.Labels {
+		ln := strings.ToLower(l.GetName())
+		for _, k := range keywords {
+			if strings.Contains(ln, k) {
+				return true
+			}
+		}
+	}
+	for _, k := range keywords {
+		if strings.Contains(title, k) || strings.Contains(branch, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func update(s *Stats, r prResult) {
+	s.TotalPRs++
+	s.TotalAdditions += r.Additions
+	s.Sampled = s.Sampled || r.Sampled
+	if !r.IsReland {
+		s.MergeTimes.add(r.MergedAt)
+	}
+	if r.IsFix {
+		s.FailureTimes.add(r.MergedAt)
+	}
+	if r.HasLeadTime {
+		s.LeadTimeCount++
+		samples := r.leadTimeSamples()
+		if s.LeadTimes == nil {
+			s.LeadTimes = newTDigest()
+		}
+		// コミット単位では PR の重みをコミットで等分する
+		w := 1.0
+		if leadTimeWeighted {
+			w = leadTimeWeight(r) / float64(len(samples))
+		}
+		for _, lt := range samples {
+			s.LeadTimeSamples++
+			s.TotalLeadTime += lt
+			s.LeadTimes.addWeighted(lt.Hours(), w)
+			s.LeadTimeSqSum += lt.Hours() * lt.Hours()
+			s.WeightedLeadTime += lt.Hours() * w
+			s.LeadTimeWeight += w
+		}
+	}
+	if r.ChangeType != "" {
+		if s.ChangeTypes == nil {
+			s.ChangeTypes = make(map[string]int)
+		}
+		s.ChangeTypes[r.ChangeType]++
+	}
+	for env, lag := range r.DeployLags {
+		s.env(env).Lags.Add(lag.Hours())
+	}
+	if r.SelfMerged {
+		s.SelfMergedPRs++
+	}
+	if r.AfterHours {
+		s.AfterHoursMerges++
+	}
+	if r.Weekend {
+		s.WeekendMerges++
+	}
+	if r.IsRevert {
+		s.RevertPRs++
+	}
+	// 失敗のシグナル（hotfix PR の作成・取り消し PR の作成）から修正のマージまでを復旧時間とみなす
+	// 取り消し PR は元の変更の出荷から取り消しのマージまで
+	if r.IsFix || r.IsRevert {
+		restore := r.MergedAt.Sub(r.CreatedAt)
+		if r.IsRevert && !r.RevertedShipped.IsZero() {
+			restore = r.MergedAt.Sub(r.RevertedShipped)
+		}
+		if s.FixRestoreTimes == nil {
+			s.FixRestoreTimes = newTDigest()
+		}
+		s.FixRestores++
+		s.FixRestoreSum += restore
+		s.FixRestoreTimes.Add(restore.Hours())
+	}
+	if r.IsReland {
+		s.Relands++
+	}
+	if r.ClockSkewed {
+		s.ClockSkewedPRs++
+	}
+	if r.LabelsChecked {
+		s.LabelChecked++
+		if len(r.Labels) == 0 {
+			s.UnlabeledPRs++
+		}
+		for _, l := range r.Labels {
+			if s.LabelCounts == nil {
+				s.LabelCounts = make(map[string]int)
+			}
+			s.LabelCounts[l]++
+		}
+		if len(r.MissingLabels) > 0 {
+			s.MissingLabelPRs++
+			if len(s.LabelGaps) < maxLabelGaps {
+				s.LabelGaps = append(s.LabelGaps, labelGap{Number: r.Number, Author: r.Author, Missing: r.MissingLabels})
+			}
+		}
+	}
+	if r.Hygiene >= 0 {
+		s.HygieneCount++
+		s.HygieneSum += r.Hygiene
+		if s.HygieneBuckets == nil {
+			s.HygieneBuckets = make(map[int]*hygieneBucket)
+		}
+		b := s.HygieneBuckets[r.Hygiene]
+		if b == nil {
+			b = &hygieneBucket{Pickups: newTDigest()}
+			s.HygieneBuckets[r.Hygiene] = b
+		}
+		b.PRs++
+		if r.Reviews != nil && r.Reviews.FirstReviewAt != nil {
+			b.Pickups.Add(elapsed(r.CreatedAt, *r.Reviews.FirstReviewAt).Hours())
+		}
+	}
+	if r.Reviews != nil {
+		s.ReviewChecked++
+		if len(r.Reviews.Reviewers) == 0 {
+			s.UnreviewedPRs++
+		}
+		if r.Reviews.FirstReviewAt != nil {
+			if s.Pickups == nil {
+				s.Pickups = newTDigest()
+			}
+			s.Pickups.Add(elapsed(r.CreatedAt, *r.Reviews.FirstReviewAt).Hours())
+		}
+	}
+	if r.IsFix {
+		s.BugFixPRs++
+	} else {
+		s.FeaturePRs++
+	}
+}
+
+func displayResults(from, to, leadTime string, team *Stats, repos map[string]*Stats, users map[string]*Stats, relative bool) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("\n%s\n📊 DORA & Contribution Summary (%s - %s)\n%s\n", line, from, to, line)
+	fmt.Printf("Lead time: %s\n", leadTime)
+	fmt.Printf("CFR: %s\n", cfrDefinition(team))
+	fmt.Printf("MTTR: %s\n", mttrDefinition())
+
+	// チーム全体のDORA
+	fmt.Printf("%-25s | %-8s | %-10s | %-10s | %-10s | %-10s | %-10s | %-10s\n", "ENTITY", "PRs", "AvgLT", "MedianLT", "P90LT", "CFR", "MTTR", "AvgSize")
+	printRow("OVERALL TEAM", team, true)
+	fmt.Println(line)
+
+	// リポジトリ別
+	for _, name := range sortedKeys(repos) {
+		printRow(name, repos[name], true)
+	}
+	fmt.Println(line)
+
+	// 個人別（見せ方を変える）
+	if relative {
+		printRelativeMembers(team, users)
+		return
+	}
+	fmt.Printf("%-25s | %-8s | %-10s | %-15s | %-10s\n", "CONTRIBUTOR", "TotalPRs", "NewWork", "Fix/Maintenance", "AvgSize")
+	for _, user := range sortedKeys(users) {
+		s := users[user]
+		newWork := s.FeaturePRs
+		fixes := s.BugFixPRs
+		avgSize := 0
+		if s.TotalPRs > 0 {
+			avgSize = s.TotalAdditions / s.TotalPRs
+		}
+
+		fmt.Printf("%-25s | %8d | %10d | %15d | +%d lines\n",
+			user, s.TotalPRs, newWork, fixes, avgSize)
+	}
+}
+
+func printRow(name string, s *Stats, showCFR bool) {
+	avgLT, cfr, avgAdd := s.AvgLeadTimeHours(), s.CFR(), 0
+	if s.TotalPRs > 0 {
+		avgAdd = s.TotalAdditions / s.TotalPRs
+	}
+	fmt.Printf("%-25s | %8d | %8.1fh | %8.1fh | %8.1fh | %8.1f%% | %8.1fh | +%d\n",
+		name, s.TotalPRs, avgLT, s.LeadTimeQuantile(0.5), s.LeadTimeQuantile(0.9), cfr, s.MTTRHours(), avgAdd)
+}
+
+func periodDays(from, to string) float64 {
+	start, err1 := time.Parse("2006-01-02", from)
+	end, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil {
+		return 1
+	}
+	// 祝日・フリーズ期間はデプロイが無くて当然なので分母から除く
+	holidayDays, frozenDays := excludedDays(from, to)
+	return max(end.Sub(start).Hours()/24+1-float64(holidayDays+frozenDays), 1)
+}
+
+func printDeploymentSummary(source, from, to string, team *Stats, repos map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	days := periodDays(from, to)
+
+	fmt.Printf("%s\n🚚 Deployments (source: %s)\n%s\n", line, source, line)
+	if n := holidays.countBetween(from, to); n > 0 {
+		fmt.Printf("Deploys/day excludes %d holiday(s) in the period\n", n)
+	}
+	for _, f := range freezesBetween(from, to) {
+		fmt.Printf("Deploys/day excludes the %s freeze\n", f)
+	}
+	fmt.Printf("%-25s | %-8s | %-12s | %-8s | %-10s | %-12s\n", "ENTITY", "Deploys", "Deploys/day", "Failed", "DeployCFR", "Undeployed")
+	printDeployRow := func(name string, s *Stats) {
+		cfr := s.DeployCFR()
+		fmt.Printf("%-25s | %8d | %12.2f | %8d | %9.1f%% | %12d\n",
+			name, s.Deployments, float64(s.Deployments)/days, s.FailedDeployments, cfr, s.TotalPRs-s.LeadTimeCount)
+	}
+	printDeployRow("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		printDeployRow(name, repos[name])
+	}
+
+	// semver ソースの場合はリリース種別ごとの頻度
+	if len(team.ReleaseTypes) > 0 {
+		fmt.Println(line)
+		fmt.Printf("%-25s | %-10s | %-8s | %-12s\n", "ENTITY", "Release", "Deploys", "Deploys/week")
+		printReleaseRows := func(name string, s *Stats) {
+			for _, kind := range []string{"major", "minor", "patch", "prerelease", "initial"} {
+				if n := s.ReleaseTypes[kind]; n > 0 {
+					fmt.Printf("%-25s | %-10s | %8d | %12.2f\n", name, kind, n, float64(n)/days*7)
+				}
+			}
+		}
+		printReleaseRows("OVERALL TEAM", team)
+		for _, name := range sortedKeys(repos) {
+			printReleaseRows(name, repos[name])
+		}
+	}
+}
+
+// 環境ごとのデプロイ頻度とマージ→デプロイの遅延
+func printEnvironmentSummary(from, to string, team *Stats, repos map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	days := periodDays(from, to)
+	fmt.Printf("%s\n🌍 Deployments per Environment\n%s\n", line, line)
+	fmt.Printf("%-25s | %-12s | %-8s | %-12s | %-12s | %-12s | %-9s | %-9s\n", "ENTITY", "Environment", "Deploys", "Deploys/day", "MedianLag", "P90Lag", "Rollbacks", "Rollback%")
+	printEnvRows := func(name string, s *Stats) {
+		envs := make([]string, 0, len(s.Environments))
+		for env := range s.Environments {
+			envs = append(envs, env)
+		}
+		sort.Strings(envs)
+		for _, env := range envs {
+			es := s.Environments[env]
+			rate := 0.0
+			if es.Deployments > 0 {
+				rate = float64(es.Rollbacks) / float64(es.Deployments) * 100
+			}
+			fmt.Printf("%-25s | %-12s | %8d | %12.2f | %11.1fh | %11.1fh | %9d | %8.1f%%\n",
+				name, env, es.Deployments, float64(es.Deployments)/days, es.Lags.Quantile(0.5), es.Lags.Quantile(0.9), es.Rollbacks, rate)
+		}
+	}
+	printEnvRows("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		printEnvRows(name, repos[name])
+	}
+}
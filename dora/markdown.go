@@ -0,0 +1,75 @@
+package dora
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// --format markdown 用。GitHub の Issue・Wiki や Slack にそのまま貼れる表で出す
+func writeMarkdownReport(w io.Writer, a *analyzer) error {
+	sum := summarize(a)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 📊 DORA Four Keys: %s (%s – %s)\n\n", mdEscape(sum.Owner), sum.From, sum.To)
+
+	b.WriteString("## Overall\n\n| Metric | Value |\n|---|---:|\n")
+	o := sum.Overall
+	fmt.Fprintf(&b, "| Merged PRs | %d |\n", o.MergedPRs)
+	fmt.Fprintf(&b, "| Deployments | %d |\n", o.Deployments)
+	fmt.Fprintf(&b, "| Deploys/day | %.2f |\n", o.DeploymentsPerDay)
+	if o.DeployFrequency != "" {
+		fmt.Fprintf(&b, "| Deploy frequency | %s |\n", o.DeployFrequency)
+	}
+	fmt.Fprintf(&b, "| Avg lead time | %.1fh |\n", o.AvgLeadTimeHours)
+	fmt.Fprintf(&b, "| Median lead time | %.1fh |\n", o.MedianLeadTimeHours)
+	fmt.Fprintf(&b, "| P90 lead time | %.1fh |\n", o.P90LeadTimeHours)
+	fmt.Fprintf(&b, "| Trimmed mean lead time (10%%) | %.1fh |\n", o.TrimmedLeadTimeHours)
+	fmt.Fprintf(&b, "| Change failure rate | %.1f%% |\n", o.CFRPercent)
+	fmt.Fprintf(&b, "| MTTR | %.1fh |\n", o.MTTRHours)
+	fmt.Fprintf(&b, "\n- Deploy source: `%s`\n- Lead time: %s\n- MTTR: %s\n", sum.DeploySource, sum.LeadTimeDef, sum.MTTRDef)
+
+	if sum.Overall.Tiers != nil {
+		writeMarkdownTiers(&b, sum)
+	}
+	writeMarkdownEntities(&b, "Repositories", "Repository", sum.Repos)
+	writeMarkdownPercentiles(&b, sum)
+	if sum.Overall.LeadTimeHistogram != nil || sum.Overall.FirstReviewHistogram != nil {
+		writeMarkdownHistograms(&b, sum)
+	}
+	if len(sum.Teams) > 0 {
+		writeMarkdownEntities(&b, "Teams", "Team", sum.Teams)
+	}
+
+	if sum.Shadow != nil {
+		writeMarkdownShadow(&b, sum)
+	}
+	if sum.MembersRel != nil {
+		writeMarkdownRelativeMembers(&b, sum.MembersRel)
+		writeMarkdownPRLinks(&b, sum.PullRequests)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	b.WriteString("\n## 👤 Members\n\n| Member | PRs | New work | Fix / maintenance | Avg LT | CFR | Avg size |\n|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, name := range sortedKeys(sum.Members) {
+		m := sum.Members[name]
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %.1fh | %.1f%% | +%.0f |\n",
+			mdEscape(name), m.MergedPRs, m.FeaturePRs, m.FailurePRs, m.AvgLeadTimeHours, m.CFRPercent, m.AvgAdditions)
+	}
+	writeMarkdownPRLinks(&b, sum.PullRequests)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownEntities(b *strings.Builder, title, header string, stats map[string]statsSummary) {
+	fmt.Fprintf(b, "\n## %s\n\n| %s | PRs | Deploys | Deploys/day | Median LT | P90 LT | CFR | MTTR |\n|---|---:|---:|---:|---:|---:|---:|---:|\n", title, header)
+	for _, name := range sortedKeys(stats) {
+		s := stats[name]
+		fmt.Fprintf(b, "| %s | %d | %d | %.2f | %.1fh | %.1fh | %.1f%% | %.1fh |\n",
+			mdEscape(name), s.MergedPRs, s.Deployments, s.DeploymentsPerDay, s.MedianLeadTimeHours, s.P90LeadTimeHours, s.CFRPercent, s.MTTRHours)
+	}
+}
+
+// 表のセルを壊さないよう | をエスケープする
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,115 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// --members の特別な値。Organization のメンバー一覧で置き換える
+const orgMembersKeyword = "org"
+
+// --members を展開する。"org" は Organization のメンバー（role で絞り込み）に置き換え、
+// それ以外のユーザー名はそのまま加える
+func resolveMembers(ctx context.Context, clientFor func(string) (*github.Client, error), org, list, role string, aliases aliasMap) (map[string]bool, error) {
+	members := make(map[string]bool)
+	for _, m := range splitList(list) {
+		if m != orgMembersKeyword {
+			members[m] = true
+			continue
+		}
+		client, err := clientFor(org)
+		if err != nil {
+			return nil, err
+		}
+		logins, err := listOrgMembers(ctx, client, org, role)
+		if err != nil {
+			return nil, fmt.Errorf("list members of %s: %w", org, err)
+		}
+		for _, login := range logins {
+			members[aliases.canonical(login)] = true
+		}
+	}
+	return members, nil
+}
+
+// Organization のメンバー（外部コラボレーターは含まれない）
+func listOrgMembers(ctx context.Context, client *github.Client, org, role string) ([]string, error) {
+	var out []string
+	opts := &github.ListMembersOptions{Role: role, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Organizations.ListMembers(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range page {
+			out = append(out, u.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// --team の "org/slug"（org を省略すると owner）を Teams API でメンバーに展開する
+// チーム名は slug。別名は aliases で解決する
+func resolveTeams(ctx context.Context, clientFor func(string) (*github.Client, error), owner string, specs []string, aliases aliasMap) ([]teamConfig, error) {
+	var teams []teamConfig
+	for _, spec := range specs {
+		org, slug, ok := strings.Cut(spec, "/")
+		if !ok {
+			org, slug = owner, spec
+		}
+		client, err := clientFor(org)
+		if err != nil {
+			return nil, err
+		}
+		logins, err := listTeamMembers(ctx, client, org, slug)
+		if err != nil {
+			return nil, fmt.Errorf("list members of team %s/%s: %w", org, slug, err)
+		}
+		t := teamConfig{Name: slug}
+		for _, login := range logins {
+			t.Members = append(t.Members, aliases.canonical(login))
+		}
+		teams = append(teams, t)
+	}
+	return teams, nil
+}
+
+// チームのメンバー（子チームのメンバーも含む）
+func listTeamMembers(ctx context.Context, client *github.Client, org, slug string) ([]string, error) {
+	var out []string
+	opts := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range page {
+			out = append(out, u.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// API で引いたチームをチーム定義ファイルに加える
+// ファイルに同じ名前でメンバーの無いチームがあれば、目標値・通知先はそのままにメンバーだけ埋める
+func (f *teamsFile) addTeams(teams []teamConfig) {
+	for _, t := range teams {
+		i := slices.IndexFunc(f.Teams, func(c teamConfig) bool { return c.Name == t.Name })
+		switch {
+		case i < 0:
+			f.Teams = append(f.Teams, t)
+		case len(f.Teams[i].Members) == 0:
+			f.Teams[i].Members = t.Members
+		}
+	}
+}
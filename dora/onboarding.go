@@ -0,0 +1,108 @@
+package dora
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 新メンバーの立ち上がり。期間内の最初の活動（コミット・PR 作成）から、
+// 最初のマージ・最初のレビューまでにかかった時間を見る
+type onboardingStats struct {
+	Joined        time.Time
+	FirstActivity time.Time // 最初のコミットまたは PR 作成（マージされた PR のもの）
+	FirstMerged   time.Time
+	FirstReview   time.Time // 他人の PR への最初のレビュー
+	MergedPRs     int
+	Reviews       int
+}
+
+// "alice=2025-01-06,bob=2025-02-01" 形式の参加日
+func parseNewMembers(s string, aliases aliasMap) (map[string]time.Time, error) {
+	out := make(map[string]time.Time)
+	for _, item := range splitList(s) {
+		login, date, ok := strings.Cut(item, "=")
+		joined, err := parseDay(strings.TrimSpace(date))
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid --new-members entry %q (want login=YYYY-MM-DD)", item)
+		}
+		out[aliases.canonical(strings.TrimSpace(login))] = joined
+	}
+	return out, nil
+}
+
+// a.mu を保持して呼ぶ
+func (a *analyzer) addOnboarding(r prResult) {
+	if len(a.newMembers) == 0 {
+		return
+	}
+	if a.onboarding == nil {
+		a.onboarding = make(map[string]*onboardingStats)
+	}
+	member := func(login string) *onboardingStats {
+		joined, ok := a.newMembers[login]
+		if !ok {
+			return nil
+		}
+		if a.onboarding[login] == nil {
+			a.onboarding[login] = &onboardingStats{Joined: joined}
+		}
+		return a.onboarding[login]
+	}
+	earliest := func(cur *time.Time, t time.Time) {
+		if cur.IsZero() || t.Before(*cur) {
+			*cur = t
+		}
+	}
+	if o := member(r.Author); o != nil {
+		o.MergedPRs++
+		earliest(&o.FirstActivity, r.CreatedAt)
+		for _, t := range r.CommitTimes {
+			// 参加前のコミット（他ブランチからの取り込みなど）は活動に数えない
+			if !t.Before(o.Joined) {
+				earliest(&o.FirstActivity, t)
+			}
+		}
+		earliest(&o.FirstMerged, r.MergedAt)
+	}
+	if r.Reviews != nil {
+		for reviewer, at := range r.Reviews.FirstBy {
+			if o := member(reviewer); o != nil {
+				o.Reviews++
+				earliest(&o.FirstReview, at)
+			}
+		}
+	}
+}
+
+// 始点から終点までの日数（どちらかが無ければ "-"。終点が先なら 0 日）
+func fmtOnboardingDays(from, to time.Time) string {
+	if from.IsZero() || to.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fd", max(to.Sub(from).Hours(), 0)/24)
+}
+
+func printOnboardingSummary(a *analyzer) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🌱 Onboarding (new members)\n%s\n", line, line)
+	fmt.Printf("%-20s | %-10s | %-10s | %-12s | %-12s | %-12s | %-5s | %s\n", "MEMBER", "Joined", "FirstWork", "Join→Work", "→1stMerge", "→1stReview", "PRs", "Reviews")
+	for _, name := range sortedKeys(a.newMembers) {
+		o := a.onboarding[name]
+		if o == nil {
+			fmt.Printf("%-20s | %-10s | %-10s | %-12s | %-12s | %-12s | %5d | %d\n", name, a.newMembers[name].Format("2006-01-02"), "-", "-", "-", "-", 0, 0)
+			continue
+		}
+		first := "-"
+		if !o.FirstActivity.IsZero() {
+			first = o.FirstActivity.Format("2006-01-02")
+		}
+		fmt.Printf("%-20s | %-10s | %-10s | %-12s | %-12s | %-12s | %5d | %d\n",
+			name, o.Joined.Format("2006-01-02"), first,
+			fmtOnboardingDays(o.Joined, o.FirstActivity),
+			fmtOnboardingDays(o.FirstActivity, o.FirstMerged),
+			fmtOnboardingDays(o.FirstActivity, o.FirstReview),
+			o.MergedPRs, o.Reviews)
+	}
+	fmt.Println("FirstWork = first commit or PR opened among the member's PRs merged in the period; reviews count PRs reviewed")
+}
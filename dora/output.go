@@ -0,0 +1,223 @@
+package dora
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// 集計結果の出力先。1 回の実行で複数の出力先に書ける（--output terminal,json=report.json,slack）
+type reportSink interface {
+	Name() string
+	Write(ctx context.Context, a *analyzer) error
+}
+
+// ファイル（または標準出力）に書く形式。terminal は text の別名
+var fileSinkFormats = []string{"text", "terminal", "html", "json", "csv", "tsv", "markdown", "prometheus"}
+
+// 集計結果を送る先（URL はそれぞれのフラグで指定する）
+var remoteSinkNames = []string{"slack", "webhook"}
+
+// --output の値が出力先の一覧なら true（そうでなければ従来どおりファイル名とみなす）
+func isSinkList(spec string) bool {
+	items := splitList(spec)
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		name, _, _ := strings.Cut(item, "=")
+		if !slices.Contains(fileSinkFormats, name) && !slices.Contains(remoteSinkNames, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// 出力先を組み立てる先の設定
+type sinkConfig struct {
+	out          string // パスを付けないファイル形式の書き先（- は標準出力）
+	teams        *teamsFile
+	client       *http.Client
+	slackWebhook string
+	webhookURL   string
+}
+
+// "terminal,json=report.json,slack" を出力先に変換する。標準出力に書く出力先は 1 つまで
+func parseSinks(spec string, cfg sinkConfig) ([]reportSink, error) {
+	var sinks []reportSink
+	stdout := 0
+	for _, item := range splitList(spec) {
+		name, path, hasPath := strings.Cut(item, "=")
+		switch {
+		case name == "slack":
+			if cfg.slackWebhook == "" {
+				return nil, fmt.Errorf("--output slack requires --slack-webhook")
+			}
+			sinks = append(sinks, slackSink{webhook: cfg.slackWebhook, client: cfg.client})
+		case name == "webhook":
+			if cfg.webhookURL == "" {
+				return nil, fmt.Errorf("--output webhook requires --webhook-url")
+			}
+			sinks = append(sinks, webhookSink{url: cfg.webhookURL, client: cfg.client})
+		case slices.Contains(fileSinkFormats, name):
+			if name == "terminal" {
+				name = "text"
+			}
+			if !hasPath {
+				path = cfg.out
+			}
+			if name == "text" {
+				path = "-"
+			}
+			if path == "-" || path == "" {
+				stdout++
+			}
+			sinks = append(sinks, fileSink{format: name, path: path, teams: cfg.teams})
+		default:
+			return nil, fmt.Errorf("unsupported output %q (want one of %s)", name, strings.Join(append(slices.Clone(fileSinkFormats), remoteSinkNames...), ", "))
+		}
+	}
+	if stdout > 1 {
+		return nil, fmt.Errorf("more than one output writes to stdout; give the others a file, e.g. json=report.json")
+	}
+	return sinks, nil
+}
+
+// 標準出力に書く出力先があるか（進捗表示の出し先を決める）
+func sinksUseStdout(sinks []reportSink) bool {
+	for _, s := range sinks {
+		if f, ok := s.(fileSink); ok && f.format != "text" && (f.path == "-" || f.path == "") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSink(sinks []reportSink, format string) bool {
+	for _, s := range sinks {
+		if s.Name() == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ファイル（または標準出力）への出力
+type fileSink struct {
+	format string
+	path   string
+	teams  *teamsFile
+}
+
+func (s fileSink) Name() string { return s.format }
+
+func (s fileSink) Write(ctx context.Context, a *analyzer) error {
+	switch s.format {
+	case "text":
+		printReport(a, s.teams)
+		return nil
+	case "html":
+		return writeReportFile(s.path, func(w io.Writer) error { return writeHTMLReport(w, a) })
+	case "json":
+		return writeReportFile(s.path, func(w io.Writer) error { return writeJSONReport(w, a) })
+	case "markdown":
+		return writeReportFile(s.path, func(w io.Writer) error { return writeMarkdownReport(w, a) })
+	case "prometheus":
+		return writeReportFile(s.path, func(w io.Writer) error { return writePrometheusText(w, prometheusSamples(summarize(a))) })
+	case "csv", "tsv":
+		comma := ','
+		if s.format == "tsv" {
+			comma = '\t'
+		}
+		return writeCSVReport(s.path, a, comma)
+	}
+	return fmt.Errorf("unsupported format %q", s.format)
+}
+
+// Slack の Incoming Webhook に全体とリポジトリごとの要約を送る
+type slackSink struct {
+	webhook string
+	client  *http.Client
+}
+
+func (s slackSink) Name() string { return "slack" }
+
+func (s slackSink) Write(ctx context.Context, a *analyzer) error {
+	return postSlack(ctx, s.client, s.webhook, slackSummaryText(summarize(a)))
+}
+
+func slackSummaryText(sum reportSummary) string {
+	line := func(name string, s statsSummary) string {
+		freq := s.DeployFrequency
+		if freq == "" {
+			freq = "no deployments"
+		}
+		return fmt.Sprintf("%s: %d PRs · %.2f deploys/day (%s) · median lead time %.1fh · CFR %.1f%% · MTTR %.1fh",
+			name, s.MergedPRs, s.DeploymentsPerDay, freq, s.MedianLeadTimeHours, s.CFRPercent, s.MTTRHours)
+	}
+	lines := []string{
+		fmt.Sprintf("📊 DORA Four Keys for %s (%s to %s)", slackEscape(sum.Owner), sum.From, sum.To),
+		line("*Overall*", sum.Overall),
+	}
+	for _, name := range sortedKeys(sum.Repos) {
+		lines = append(lines, line("• "+slackEscape(name), sum.Repos[name]))
+	}
+	if len(sum.Insights) > 0 {
+		lines = append(lines, "💡 *Insights*")
+		for _, in := range sum.Insights {
+			lines = append(lines, "• "+slackEscape(in.Message))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 任意の URL に JSON レポート（--format json と同じ内容）を POST する
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s webhookSink) Name() string { return "webhook" }
+
+func (s webhookSink) Write(ctx context.Context, a *analyzer) error {
+	var body bytes.Buffer
+	if err := writeJSONReport(&body, a); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// Prometheus の remote write
+type remoteWriteSink struct{ w *remoteWriter }
+
+func (s remoteWriteSink) Name() string { return "remote-write" }
+
+func (s remoteWriteSink) Write(ctx context.Context, a *analyzer) error {
+	return s.w.Push(ctx, prometheusSamples(summarize(a)))
+}
+
+// Prometheus の Pushgateway
+type pushgatewaySink struct{ p *pushgateway }
+
+func (s pushgatewaySink) Name() string { return "pushgateway" }
+
+func (s pushgatewaySink) Write(ctx context.Context, a *analyzer) error {
+	return s.p.Push(ctx, a.owner, prometheusSamples(summarize(a)))
+}
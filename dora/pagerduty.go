@@ -0,0 +1,122 @@
+package dora
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PagerDuty のインシデントを MTTR・CFR のインシデントとして読む
+// サービスは "P1ABCDE" か "repo=P1ABCDE"。リポジトリ名を付けなければ PagerDuty のサービス名をリポジトリ名と照合する
+type pagerDutySource struct {
+	client   *http.Client
+	address  string
+	token    string
+	services map[string]string // サービス ID -> リポジトリ名（空ならサービス名）
+}
+
+func newPagerDutySource(transport http.RoundTripper, address, token, services string) (*pagerDutySource, error) {
+	if token == "" {
+		return nil, fmt.Errorf("PAGERDUTY_API_KEY (or --pagerduty-api-key) is required for --pagerduty-services")
+	}
+	mapping := make(map[string]string)
+	for _, s := range splitList(services) {
+		repo, id, ok := strings.Cut(s, "=")
+		if !ok {
+			repo, id = "", s
+		}
+		mapping[strings.TrimSpace(id)] = strings.TrimSpace(repo)
+	}
+	if len(mapping) == 0 {
+		return nil, fmt.Errorf("--pagerduty-services has no service IDs")
+	}
+	return &pagerDutySource{
+		client:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		address:  strings.TrimRight(address, "/"),
+		token:    token,
+		services: mapping,
+	}, nil
+}
+
+type pdIncident struct {
+	CreatedAt          time.Time  `json:"created_at"`
+	ResolvedAt         *time.Time `json:"resolved_at"`
+	LastStatusChangeAt time.Time  `json:"last_status_change_at"`
+	Status             string     `json:"status"`
+	Urgency            string     `json:"urgency"`
+	Priority           *struct {
+		Summary string `json:"summary"`
+	} `json:"priority"`
+	Service struct {
+		ID      string `json:"id"`
+		Summary string `json:"summary"`
+	} `json:"service"`
+}
+
+// [from, to) に作成されたインシデント（作成日時 → 解決日時）
+func (p *pagerDutySource) Incidents(ctx context.Context, from, to time.Time) ([]incident, error) {
+	q := url.Values{}
+	for _, id := range sortedKeys(p.services) {
+		q.Add("service_ids[]", id)
+	}
+	q.Set("since", from.UTC().Format(time.RFC3339))
+	q.Set("until", to.UTC().Format(time.RFC3339))
+	q.Set("time_zone", "UTC")
+	q.Set("limit", "100")
+
+	var out []incident
+	for offset := 0; ; {
+		q.Set("offset", strconv.Itoa(offset))
+		var page struct {
+			Incidents []pdIncident `json:"incidents"`
+			More      bool         `json:"more"`
+		}
+		if err := p.get(ctx, "/incidents?"+q.Encode(), &page); err != nil {
+			return nil, err
+		}
+		for _, pi := range page.Incidents {
+			in := incident{Start: pi.CreatedAt, Severity: pi.Urgency, Service: p.services[pi.Service.ID]}
+			if in.Service == "" {
+				in.Service = pi.Service.Summary
+			}
+			if pi.Priority != nil && pi.Priority.Summary != "" {
+				in.Severity = pi.Priority.Summary
+			}
+			switch {
+			case pi.ResolvedAt != nil:
+				in.End = *pi.ResolvedAt
+			case pi.Status == "resolved":
+				in.End = pi.LastStatusChangeAt
+			}
+			out = append(out, in)
+		}
+		if !page.More || len(page.Incidents) == 0 {
+			break
+		}
+		offset += len(page.Incidents)
+	}
+	return out, nil
+}
+
+func (p *pagerDutySource) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token token="+p.token)
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pagerduty API %s: %s", strings.SplitN(path, "?", 2)[0], resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
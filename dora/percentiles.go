@@ -0,0 +1,146 @@
+package dora
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// リードタイム・最初のレビューまでの時間について出す分位点（--percentiles、起動時に設定）
+var reportPercentiles = []float64{50, 75, 90, 95}
+
+// "50,75,90,95" / "p75,p99.9"
+func parsePercentiles(spec string) ([]float64, error) {
+	seen := make(map[float64]bool)
+	var out []float64
+	for _, item := range splitList(spec) {
+		p, err := strconv.ParseFloat(strings.TrimPrefix(strings.ToLower(item), "p"), 64)
+		if err != nil || p <= 0 || p >= 100 {
+			return nil, fmt.Errorf("invalid percentile %q (want a number between 0 and 100, e.g. 75 or p95)", item)
+		}
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("--percentiles has no values")
+	}
+	sort.Float64s(out)
+	return out, nil
+}
+
+// 75 → "p75"、99.9 → "p99.9"
+func percentileKey(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// 分位点ごとの値（時間）。分布が無ければ nil
+func digestPercentiles(d *tdigest) map[string]float64 {
+	if d == nil || d.Count() == 0 {
+		return nil
+	}
+	out := make(map[string]float64, len(reportPercentiles))
+	for _, p := range reportPercentiles {
+		out[percentileKey(p)] = d.Quantile(p / 100)
+	}
+	return out
+}
+
+// "12.5h" / "-"（求まらない）
+func formatPercentile(values map[string]float64, p float64) string {
+	v, ok := values[percentileKey(p)]
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fh", v)
+}
+
+// 分位点を出す時間の指標（リードタイム・最初のレビューまで）
+type percentileSeries struct {
+	label  string
+	values map[string]float64
+}
+
+// 値の無い指標（レビューを取得していないなど）は除く
+func durationSeries(leadTime, firstReview map[string]float64) []percentileSeries {
+	var out []percentileSeries
+	if leadTime != nil {
+		out = append(out, percentileSeries{"Lead time", leadTime})
+	}
+	if firstReview != nil {
+		out = append(out, percentileSeries{"First review", firstReview})
+	}
+	return out
+}
+
+// CSV の列（lead_time_p75_hours, first_review_p75_hours, ...）
+func csvPercentileColumns() []string {
+	var cols []string
+	for _, prefix := range []string{"lead_time", "first_review"} {
+		for _, p := range reportPercentiles {
+			cols = append(cols, prefix+"_"+percentileKey(p)+"_hours")
+		}
+	}
+	return cols
+}
+
+func csvPercentileValues(s statsSummary) []string {
+	var out []string
+	for _, values := range []map[string]float64{s.LeadTimePercentiles, s.FirstReviewPercentiles} {
+		for _, p := range reportPercentiles {
+			v, ok := values[percentileKey(p)]
+			if !ok {
+				out = append(out, "")
+				continue
+			}
+			out = append(out, strconv.FormatFloat(v, 'f', 2, 64))
+		}
+	}
+	return out
+}
+
+func printPercentileSummary(sum reportSummary) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n⏱️  Duration Percentiles (hours)\n%s\n", line, line)
+	fmt.Printf("%-25s | %-12s", "ENTITY", "Metric")
+	for _, p := range reportPercentiles {
+		fmt.Printf(" | %8s", percentileKey(p))
+	}
+	fmt.Println()
+	row := func(name string, s statsSummary) {
+		for _, m := range durationSeries(s.LeadTimePercentiles, s.FirstReviewPercentiles) {
+			fmt.Printf("%-25s | %-12s", name, m.label)
+			for _, p := range reportPercentiles {
+				fmt.Printf(" | %8s", formatPercentile(m.values, p))
+			}
+			fmt.Println()
+		}
+	}
+	row("OVERALL TEAM", sum.Overall)
+	for _, name := range sortedKeys(sum.Repos) {
+		row(name, sum.Repos[name])
+	}
+}
+
+func writeMarkdownPercentiles(b *strings.Builder, sum reportSummary) {
+	b.WriteString("\n## ⏱️ Duration percentiles\n\n| Entity | Metric |")
+	for _, p := range reportPercentiles {
+		fmt.Fprintf(b, " %s |", percentileKey(p))
+	}
+	b.WriteString("\n|---|---|" + strings.Repeat("---:|", len(reportPercentiles)) + "\n")
+	row := func(name string, s statsSummary) {
+		for _, m := range durationSeries(s.LeadTimePercentiles, s.FirstReviewPercentiles) {
+			fmt.Fprintf(b, "| %s | %s |", mdEscape(name), m.label)
+			for _, p := range reportPercentiles {
+				fmt.Fprintf(b, " %s |", formatPercentile(m.values, p))
+			}
+			b.WriteString("\n")
+		}
+	}
+	row("Overall", sum.Overall)
+	for _, name := range sortedKeys(sum.Repos) {
+		row(name, sum.Repos[name])
+	}
+}
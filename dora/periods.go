@@ -0,0 +1,277 @@
+package dora
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 期間の日付（--start / --end、--periods、--bucket）を区切るタイムゾーン（--timezone、起動時に設定）
+var reportLocation = time.UTC
+
+// "2006-01-02" を reportLocation のその日の 0:00 として解釈する
+// 日付どうしの計算（日数・週の区切り・期間の分割）は夏時間で 1 日が 24 時間でなくなるので、UTC の time.Parse のまま行う
+func parseDay(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, reportLocation)
+}
+
+// reportLocation での日付
+func dayOf(t time.Time) string {
+	return t.In(reportLocation).Format("2006-01-02")
+}
+
+// 検索の日付範囲（from..to）。UTC 以外では両端を時刻とオフセット付きで渡す
+func searchRange(from, to string) string {
+	start, err1 := parseDay(from)
+	end, err2 := parseDay(to)
+	if reportLocation == time.UTC || err1 != nil || err2 != nil {
+		return from + ".." + to
+	}
+	return start.Format(time.RFC3339) + ".." + end.AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339)
+}
+
+// --periods で指定する集計期間の 1 つ
+type reportPeriod struct {
+	Name     string
+	From, To string
+}
+
+// 2024-Q1, 2024-H2, 2024-03, 2024, 2024-01-01..2024-02-15 のカンマ区切り
+func parsePeriods(s string) ([]reportPeriod, error) {
+	var out []reportPeriod
+	for _, name := range splitList(s) {
+		start, end, err := periodRange(name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, reportPeriod{Name: name, From: start.Format("2006-01-02"), To: end.Format("2006-01-02")})
+	}
+	return out, nil
+}
+
+// 期間の初日と最終日
+func periodRange(name string) (time.Time, time.Time, error) {
+	if from, to, ok := strings.Cut(name, ".."); ok {
+		start, err1 := time.Parse("2006-01-02", from)
+		end, err2 := time.Parse("2006-01-02", to)
+		if err1 != nil || err2 != nil || end.Before(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q (want YYYY-MM-DD..YYYY-MM-DD)", name)
+		}
+		return start, end, nil
+	}
+	year, rest, _ := strings.Cut(name, "-")
+	y, err := strconv.Atoi(year)
+	if err != nil || len(year) != 4 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q (want e.g. 2024-Q1, 2024-H1, 2024-03 or 2024)", name)
+	}
+	months := func(first, n int) (time.Time, time.Time, error) {
+		start := time.Date(y, time.Month(first), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, n, -1), nil
+	}
+	switch {
+	case rest == "":
+		return months(1, 12)
+	case len(rest) == 2 && rest[0] == 'Q' && rest[1] >= '1' && rest[1] <= '4':
+		return months(int(rest[1]-'1')*3+1, 3)
+	case len(rest) == 2 && rest[0] == 'H' && (rest[1] == '1' || rest[1] == '2'):
+		return months(int(rest[1]-'1')*6+1, 6)
+	}
+	if m, err := strconv.Atoi(rest); err == nil && len(rest) == 2 && m >= 1 && m <= 12 {
+		return months(m, 1)
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q (want e.g. 2024-Q1, 2024-H1, 2024-03 or 2024)", name)
+}
+
+// --bucket の値
+const (
+	bucketWeek  = "week"
+	bucketMonth = "month"
+)
+
+// from〜to を週（月曜始まり、ISO 週番号の名前）または月に分ける。両端の週・月は期間内に切り詰める
+func bucketPeriods(from, to, unit string) ([]reportPeriod, error) {
+	start, err1 := time.Parse("2006-01-02", from)
+	end, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil || end.Before(start) {
+		return nil, fmt.Errorf("--bucket needs a valid --start and --end, got %q to %q", from, to)
+	}
+	var out []reportPeriod
+	for cur := start; !cur.After(end); {
+		var name string
+		var next time.Time
+		switch unit {
+		case bucketWeek:
+			// 月曜まで戻して週の区切りにそろえる
+			monday := cur.AddDate(0, 0, -(int(cur.Weekday())+6)%7)
+			next = monday.AddDate(0, 0, 7)
+			y, w := monday.ISOWeek()
+			name = fmt.Sprintf("%d-W%02d", y, w)
+		case bucketMonth:
+			next = time.Date(cur.Year(), cur.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			name = cur.Format("2006-01")
+		default:
+			return nil, fmt.Errorf("unsupported --bucket %q (want week or month)", unit)
+		}
+		last := next.AddDate(0, 0, -1)
+		if last.After(end) {
+			last = end
+		}
+		out = append(out, reportPeriod{Name: name, From: cur.Format("2006-01-02"), To: last.Format("2006-01-02")})
+		cur = next
+	}
+	return out, nil
+}
+
+// 全期間をまとめた範囲（1 回の収集で全期間を賄う）
+func periodsSpan(periods []reportPeriod) (string, string) {
+	from, to := periods[0].From, periods[0].To
+	for _, p := range periods[1:] {
+		from, to = min(from, p.From), max(to, p.To)
+	}
+	return from, to
+}
+
+// 全期間分を 1 回だけ取得し、スナップショットとしてメモリに持つ
+func collectSnapshot(ctx context.Context, a *analyzer, repos []string) *snapshot {
+	snap := newSnapshot(a)
+	a.onRepo = func(r snapshotRepo) {
+		snap.Repos = append(snap.Repos, r)
+	}
+	collectPRRecords(ctx, a, repos, os.Stderr, func(r PRRecord) {
+		snap.PRs = append(snap.PRs, r)
+	})
+	return snap
+}
+
+// 期間ごとの集計結果（JSON では期間名と reportSummary を並べる）
+type periodReport struct {
+	Period string `json:"period"`
+	reportSummary
+}
+
+// スナップショットを期間ごとに再集計する
+func summarizePeriods(snap *snapshot, periods []reportPeriod, tr *tracer, configure func(*analyzer), repos map[string]bool) []periodReport {
+	out := make([]periodReport, 0, len(periods))
+	for _, p := range periods {
+		if p.From < snap.From || p.To > snap.To {
+			log.Printf("⚠️  Period %s is not fully covered by the snapshot (%s to %s)", p.Name, snap.From, snap.To)
+		}
+		a := newSnapshotAnalyzer(snap, tr)
+		a.from, a.to = p.From, p.To
+		configure(a)
+		a.replay(snap, repos)
+		out = append(out, periodReport{Period: p.Name, reportSummary: summarize(a)})
+	}
+	return out
+}
+
+func writePeriodsReport(w io.Writer, reports []periodReport, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Periods []periodReport `json:"periods"`
+		}{reports})
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		cw.Write(append([]string{"period", "kind", "name", "from", "to"}, csvColumns()...))
+		for _, r := range reports {
+			row := func(kind, name string, s statsSummary) {
+				cw.Write(append([]string{r.Period, kind, name, r.From, r.To}, csvMetricValues(s)...))
+			}
+			row("overall", "OVERALL TEAM", r.Overall)
+			for _, name := range sortedKeys(r.Repos) {
+				row("repo", name, r.Repos[name])
+			}
+			for _, name := range sortedKeys(r.Teams) {
+				row("team", name, r.Teams[name])
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "markdown":
+		var b strings.Builder
+		fmt.Fprintf(&b, "# 📈 DORA Four Keys trend: %s\n", mdEscape(reports[0].Owner))
+		if notes := periodFreezeNotes(reports); len(notes) > 0 {
+			fmt.Fprintf(&b, "\n- ❄️ %s\n", strings.Join(notes, "\n- ❄️ "))
+		}
+		for _, entity := range periodEntities(reports) {
+			fmt.Fprintf(&b, "\n## %s\n\n| Period | PRs | Deploys | Deploys/day | Frequency | Median LT | P90 LT | CFR | MTTR |\n|---|---:|---:|---:|---|---:|---:|---:|---:|\n", mdEscape(entity))
+			for _, r := range reports {
+				if s, ok := r.entity(entity); ok {
+					fmt.Fprintf(&b, "| %s | %d | %d | %.2f | %s | %.1fh | %.1fh | %.1f%% | %.1fh |\n",
+						r.Period, s.MergedPRs, s.Deployments, s.DeploymentsPerDay, s.DeployFrequency, s.MedianLeadTimeHours, s.P90LeadTimeHours, s.CFRPercent, s.MTTRHours)
+				}
+			}
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	line := strings.Repeat("-", 100)
+	fmt.Fprintf(w, "\n%s\n📈 DORA Trend (%s, %d periods)\n%s\n", line, reports[0].Owner, len(reports), line)
+	for _, note := range periodFreezeNotes(reports) {
+		fmt.Fprintf(w, "❄️  %s\n", note)
+	}
+	for _, entity := range periodEntities(reports) {
+		fmt.Fprintf(w, "%s\n%-15s | %-6s | %-7s | %-11s | %-17s | %-9s | %-9s | %-7s | %s\n", entity, "PERIOD", "PRs", "Deploys", "Deploys/day", "Frequency", "MedianLT", "P90LT", "CFR", "MTTR")
+		for _, r := range reports {
+			if s, ok := r.entity(entity); ok {
+				fmt.Fprintf(w, "%-15s | %6d | %7d | %11.2f | %-17s | %8.1fh | %8.1fh | %6.1f%% | %.1fh\n",
+					r.Period, s.MergedPRs, s.Deployments, s.DeploymentsPerDay, s.DeployFrequency, s.MedianLeadTimeHours, s.P90LeadTimeHours, s.CFRPercent, s.MTTRHours)
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+// フリーズ期間を含む期間の注記（デプロイ頻度が落ちて見える理由）
+func periodFreezeNotes(reports []periodReport) []string {
+	var notes []string
+	for _, r := range reports {
+		for _, f := range freezesBetween(r.From, r.To) {
+			notes = append(notes, fmt.Sprintf("%s includes the %s freeze", r.Period, f))
+		}
+	}
+	return notes
+}
+
+// 全体、全期間に出てくるリポジトリ、チームの順
+func periodEntities(reports []periodReport) []string {
+	entities := []string{"OVERALL TEAM"}
+	seen := make(map[string]bool)
+	for _, r := range reports {
+		for name := range r.Repos {
+			seen["repo: "+name] = true
+		}
+		for name := range r.Teams {
+			seen["team: "+name] = true
+		}
+	}
+	return append(entities, sortedKeys(seen)...)
+}
+
+func (r periodReport) entity(name string) (statsSummary, bool) {
+	switch {
+	case name == "OVERALL TEAM":
+		return r.Overall, true
+	case strings.HasPrefix(name, "repo: "):
+		s, ok := r.Repos[strings.TrimPrefix(name, "repo: ")]
+		return s, ok
+	case strings.HasPrefix(name, "team: "):
+		s, ok := r.Teams[strings.TrimPrefix(name, "team: ")]
+		return s, ok
+	}
+	return statsSummary{}, false
+}
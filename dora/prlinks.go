@@ -0,0 +1,98 @@
+package dora
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// 指標に効いた PR へのリンク。JSON・Markdown のレポートから個々の PR をたどれるようにする
+type prLink struct {
+	Repo   string  `json:"repo"`
+	Number int     `json:"number"`
+	Title  string  `json:"title"`
+	URL    string  `json:"url"`
+	Author string  `json:"author"`
+	Hours  float64 `json:"hours,omitempty"` // 一覧ごとの値（リードタイム、レビュー待ちの営業時間）
+}
+
+type prLinks struct {
+	SlowestLeadTime   []prLink `json:"slowest_lead_time,omitempty"`   // リードタイムの長い順
+	Failures          []prLink `json:"failures,omitempty"`            // CFR に数えた PR
+	Reverts           []prLink `json:"reverts,omitempty"`             // 取り消しの PR
+	ReviewSLABreaches []prLink `json:"review_sla_breaches,omitempty"` // 最初のレビューが SLA を超えた PR（超過の大きい順）
+}
+
+// リードタイムの長い PR を何件まで残すか
+const slowestPRLinks = 10
+
+func newPRLink(r prResult) prLink {
+	return prLink{Repo: r.Repo, Number: r.Number, Title: r.Title, URL: r.URL, Author: r.Author}
+}
+
+// 全体の集計に加えた PR を一覧に入れる。a.mu を保持して呼ぶ
+func (a *analyzer) addLinks(r prResult) {
+	if r.IsFix {
+		a.links.Failures = append(a.links.Failures, newPRLink(r))
+	}
+	if r.IsRevert {
+		a.links.Reverts = append(a.links.Reverts, newPRLink(r))
+	}
+	if !r.HasLeadTime {
+		return
+	}
+	l := newPRLink(r)
+	l.Hours = r.leadTime().Hours()
+	// 同じ長さなら先に集計した PR を前に置く（集計の順はリポジトリ・検索結果の順で決まる）
+	i := sort.Search(len(a.links.SlowestLeadTime), func(i int) bool { return a.links.SlowestLeadTime[i].Hours < l.Hours })
+	if i < slowestPRLinks {
+		a.links.SlowestLeadTime = slices.Insert(a.links.SlowestLeadTime, i, l)
+		if len(a.links.SlowestLeadTime) > slowestPRLinks {
+			a.links.SlowestLeadTime = a.links.SlowestLeadTime[:slowestPRLinks]
+		}
+	}
+}
+
+// レポート用の一覧（SLA 超過は集計後に並べて加える）
+func (a *analyzer) prLinks() *prLinks {
+	out := a.links
+	breaches := slices.Clone(a.breaches)
+	sort.SliceStable(breaches, func(i, j int) bool { return breaches[i].Waited > breaches[j].Waited })
+	for _, b := range breaches {
+		out.ReviewSLABreaches = append(out.ReviewSLABreaches, prLink{Repo: b.Repo, Number: b.Number, Title: b.Title, URL: b.URL, Author: b.Author, Hours: b.Waited.Hours()})
+	}
+	if len(out.SlowestLeadTime)+len(out.Failures)+len(out.Reverts)+len(out.ReviewSLABreaches) == 0 {
+		return nil
+	}
+	return &out
+}
+
+// Markdown の箇条書き（"- [repo#123](url) title — author"）
+func writeMarkdownPRLinks(b *strings.Builder, links *prLinks) {
+	if links == nil {
+		return
+	}
+	b.WriteString("\n## 🔎 Pull requests behind the numbers\n")
+	section := func(title string, list []prLink, hours string) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "\n### %s\n\n", title)
+		for _, l := range list {
+			ref := fmt.Sprintf("%s#%d", l.Repo, l.Number)
+			if l.URL != "" {
+				ref = fmt.Sprintf("[%s](%s)", ref, l.URL)
+			}
+			fmt.Fprintf(b, "- %s %s — %s", ref, mdEscape(l.Title), mdEscape(l.Author))
+			if hours != "" {
+				fmt.Fprintf(b, " ("+hours+")", l.Hours)
+			}
+			b.WriteString("\n")
+		}
+	}
+	section("Slowest lead time", links.SlowestLeadTime, "%.1fh")
+	section("Failures (counted in CFR)", links.Failures, "")
+	section("Reverts", links.Reverts, "")
+	section("First-review SLA breaches", links.ReviewSLABreaches, "waited %.1f business hours")
+}
@@ -0,0 +1,101 @@
+package dora
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Prometheus のテキスト形式（node_exporter の textfile collector・Pushgateway・/metrics のスクレイプ用）
+// remote write と同じ dora_<指標名>{owner, scope, name} の系列を gauge として出す
+func writePrometheusText(w io.Writer, samples []promSample) error {
+	byName := make(map[string][]promSample)
+	var names []string
+	for _, s := range samples {
+		name := s.Labels["__name__"]
+		if byName[name] == nil {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], s)
+	}
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		fmt.Fprintf(bw, "# HELP %s DORA metric %s for the report period\n# TYPE %s gauge\n", name, strings.TrimPrefix(name, "dora_"), name)
+		for _, s := range byName[name] {
+			fmt.Fprintf(bw, "%s{%s} %g\n", name, promLabels(s.Labels), s.Value)
+		}
+	}
+	return bw.Flush()
+}
+
+// __name__ 以外のラベルを名前順に並べる
+func promLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[k])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, k, v))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Pushgateway に送る。owner ごとのグループを PUT で置き換えるので、消えたリポジトリの系列は残らない
+type pushgateway struct {
+	url    string
+	job    string
+	client *http.Client
+}
+
+func newPushgateway(rawURL, job string, transport http.RoundTripper) *pushgateway {
+	if rawURL == "" {
+		return nil
+	}
+	return &pushgateway{url: strings.TrimSuffix(rawURL, "/"), job: job, client: &http.Client{Transport: transport, Timeout: 30 * time.Second}}
+}
+
+func (p *pushgateway) Push(ctx context.Context, owner string, samples []promSample) error {
+	// owner はグループのラベルとして URL に入るので、系列側のラベルからは外す
+	grouped := make([]promSample, 0, len(samples))
+	for _, s := range samples {
+		labels := make(map[string]string, len(s.Labels))
+		for k, v := range s.Labels {
+			if k != "owner" {
+				labels[k] = v
+			}
+		}
+		grouped = append(grouped, promSample{Labels: labels, Value: s.Value})
+	}
+	var body bytes.Buffer
+	if err := writePrometheusText(&body, grouped); err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/metrics/job/%s/owner/%s", p.url, url.PathEscape(p.job), url.PathEscape(owner))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pushgateway: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
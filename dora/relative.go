@@ -0,0 +1,73 @@
+package dora
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --member-view の値
+const (
+	memberViewAbsolute = "absolute"
+	memberViewRelative = "relative" // メンバーをチームの中央値との差で出す（人同士の順位付けに使われないように）
+)
+
+// メンバーのチームとの差（%）。チーム側が 0 またはメンバーの値が求まらないときは nil
+type memberRelative struct {
+	MergedPRs            int      `json:"merged_prs"`
+	MedianLeadTimeVsTeam *float64 `json:"median_lead_time_vs_team_percent,omitempty"`
+	P90LeadTimeVsTeam    *float64 `json:"p90_lead_time_vs_team_percent,omitempty"`
+	AvgAdditionsVsTeam   *float64 `json:"avg_additions_vs_team_percent,omitempty"`
+	CFRVsTeamPoints      *float64 `json:"cfr_vs_team_points,omitempty"` // 失敗 PR の割合のポイント差（メンバーにはデプロイが無いので PR 単位で比べる）
+}
+
+func summarizeRelative(s, team *Stats) memberRelative {
+	out := memberRelative{MergedPRs: s.TotalPRs}
+	if s.LeadTimeCount > 0 {
+		out.MedianLeadTimeVsTeam = percentDiff(s.LeadTimeQuantile(0.5), team.LeadTimeQuantile(0.5))
+		out.P90LeadTimeVsTeam = percentDiff(s.LeadTimeQuantile(0.9), team.LeadTimeQuantile(0.9))
+	}
+	if s.TotalPRs > 0 && team.TotalPRs > 0 {
+		out.AvgAdditionsVsTeam = percentDiff(float64(s.TotalAdditions)/float64(s.TotalPRs), float64(team.TotalAdditions)/float64(team.TotalPRs))
+		cfr := 100 * (float64(s.BugFixPRs)/float64(s.TotalPRs) - float64(team.BugFixPRs)/float64(team.TotalPRs))
+		out.CFRVsTeamPoints = &cfr
+	}
+	return out
+}
+
+func percentDiff(v, base float64) *float64 {
+	if base == 0 {
+		return nil
+	}
+	d := 100 * (v - base) / base
+	return &d
+}
+
+// "+20%" / "-15%" / "-"（求まらない）
+func formatRelative(p *float64, unit string) string {
+	if p == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.0f%s", *p, unit)
+}
+
+// 端末のメンバー表（名前順。絶対値は PR 数のみ）
+func printRelativeMembers(team *Stats, users map[string]*Stats) {
+	fmt.Printf("Members relative to the team (median lead time %.1fh); names are listed alphabetically, not ranked\n", team.LeadTimeQuantile(0.5))
+	fmt.Printf("%-25s | %-8s | %-12s | %-12s | %-12s | %-10s\n", "CONTRIBUTOR", "PRs", "MedianLT", "P90LT", "AvgSize", "CFR")
+	for _, user := range sortedKeys(users) {
+		r := summarizeRelative(users[user], team)
+		fmt.Printf("%-25s | %8d | %12s | %12s | %12s | %10s\n", user, r.MergedPRs,
+			formatRelative(r.MedianLeadTimeVsTeam, "%"), formatRelative(r.P90LeadTimeVsTeam, "%"),
+			formatRelative(r.AvgAdditionsVsTeam, "%"), formatRelative(r.CFRVsTeamPoints, "pt"))
+	}
+}
+
+func writeMarkdownRelativeMembers(b *strings.Builder, members map[string]memberRelative) {
+	b.WriteString("\n## 👤 Members (relative to the team)\n\n| Member | PRs | Median LT | P90 LT | Avg size | CFR |\n|---|---:|---:|---:|---:|---:|\n")
+	for _, name := range sortedKeys(members) {
+		m := members[name]
+		fmt.Fprintf(b, "| %s | %d | %s | %s | %s | %s |\n", mdEscape(name), m.MergedPRs,
+			formatRelative(m.MedianLeadTimeVsTeam, "%"), formatRelative(m.P90LeadTimeVsTeam, "%"),
+			formatRelative(m.AvgAdditionsVsTeam, "%"), formatRelative(m.CFRVsTeamPoints, "pt"))
+	}
+}
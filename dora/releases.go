@@ -0,0 +1,130 @@
+package dora
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// GitHub Releases（または パターンに一致するタグ）をデプロイとして扱う
+// マージのたびではなくリリースを切ってから出すチーム向け
+type releaseSource struct {
+	client   *github.Client
+	owner    string
+	pattern  string // タグ名の glob（空なら全て）
+	tagsOnly bool   // Releases を使わずタグだけを見る
+	from     time.Time
+}
+
+// 正式リリースは production、プレリリースは prerelease 環境へのデプロイとみなす
+const (
+	releaseEnvironment    = "production"
+	prereleaseEnvironment = "prerelease"
+)
+
+func newReleaseSource(client *github.Client, owner, pattern string, tagsOnly bool, from time.Time) *releaseSource {
+	return &releaseSource{client: client, owner: owner, pattern: pattern, tagsOnly: tagsOnly, from: from}
+}
+
+func (r *releaseSource) Name() string {
+	if r.tagsOnly {
+		return "tags:" + r.pattern
+	}
+	return "releases"
+}
+
+func (r *releaseSource) Deployments(ctx context.Context, repo string) ([]deployment, error) {
+	if r.tagsOnly {
+		return r.tagDeployments(ctx, repo)
+	}
+	return r.releaseDeployments(ctx, repo)
+}
+
+func (r *releaseSource) matches(tag string) bool {
+	if r.pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(r.pattern, tag)
+	return ok
+}
+
+// 公開済みのリリースを新しい順に辿り、期間前のリリースに達したら 1 つだけ基準として残して止める
+func (r *releaseSource) releaseDeployments(ctx context.Context, repo string) ([]deployment, error) {
+	var out []deployment
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := r.client.Repositories.ListReleases(ctx, r.owner, repo, opts)
+		if err != nil {
+			return out, err
+		}
+		for _, rel := range releases {
+			if rel.GetDraft() || !r.matches(rel.GetTagName()) {
+				continue
+			}
+			// target_commitish はブランチ名のことが多いので、タグからコミットを引く
+			sha, _, err := r.client.Repositories.GetCommitSHA1(ctx, r.owner, repo, "refs/tags/"+rel.GetTagName(), "")
+			if err != nil {
+				return out, err
+			}
+			env := releaseEnvironment
+			if rel.GetPrerelease() {
+				env = prereleaseEnvironment
+			}
+			out = append(out, deployment{SHA: sha, Time: rel.GetPublishedAt().Time, Environment: env, Ref: rel.GetTagName()})
+			if rel.GetPublishedAt().Before(r.from) {
+				return out, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// パターンに一致するタグ。注釈付きタグはタグを打った日時、軽量タグはコミット日時をデプロイ時刻とする
+func (r *releaseSource) tagDeployments(ctx context.Context, repo string) ([]deployment, error) {
+	var out []deployment
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		tags, resp, err := r.client.Repositories.ListTags(ctx, r.owner, repo, opts)
+		if err != nil {
+			return out, err
+		}
+		for _, t := range tags {
+			if !r.matches(t.GetName()) {
+				continue
+			}
+			at, err := r.tagTime(ctx, repo, t)
+			if err != nil {
+				return out, err
+			}
+			out = append(out, deployment{SHA: t.GetCommit().GetSHA(), Time: at, Environment: releaseEnvironment, Ref: t.GetName()})
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (r *releaseSource) tagTime(ctx context.Context, repo string, t *github.RepositoryTag) (time.Time, error) {
+	ref, _, err := r.client.Git.GetRef(ctx, r.owner, repo, "tags/"+t.GetName())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ref.GetObject().GetType() == "tag" {
+		tag, _, err := r.client.Git.GetTag(ctx, r.owner, repo, ref.GetObject().GetSHA())
+		if err != nil {
+			return time.Time{}, err
+		}
+		return tag.GetTagger().GetDate().Time, nil
+	}
+	commit, _, err := r.client.Git.GetCommit(ctx, r.owner, repo, t.GetCommit().GetSHA())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return commit.GetCommitter().GetDate().Time, nil
+}
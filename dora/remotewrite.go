@@ -0,0 +1,154 @@
+package dora
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Prometheus remote-write（Mimir / Thanos Receive / VictoriaMetrics など）に集計結果を送る。
+// 依存を増やさないよう、WriteRequest の protobuf と snappy は最小限を手で組み立てる
+type remoteWriter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newRemoteWriter(url, headers string, transport http.RoundTripper) *remoteWriter {
+	if url == "" {
+		return nil
+	}
+	return &remoteWriter{url: url, headers: parseHeaderList(headers), client: &http.Client{Transport: transport, Timeout: 30 * time.Second}}
+}
+
+type promSample struct {
+	Labels map[string]string // __name__ を含む
+	Value  float64
+}
+
+// 全体・リポジトリ・チームの各指標を dora_<指標名>{owner, scope, name} の系列にする
+func prometheusSamples(sum reportSummary) []promSample {
+	var out []promSample
+	add := func(scope, name string, s statsSummary) {
+		values := summaryValues(s)
+		for _, m := range grafanaMetrics {
+			out = append(out, promSample{
+				Labels: map[string]string{"__name__": "dora_" + m, "owner": sum.Owner, "scope": scope, "name": name},
+				Value:  values[m],
+			})
+		}
+		// 分位点は Prometheus の summary と同じく quantile ラベルで分ける
+		for _, series := range []struct {
+			name   string
+			values map[string]float64
+		}{{"dora_lead_time_hours", s.LeadTimePercentiles}, {"dora_first_review_hours", s.FirstReviewPercentiles}} {
+			for _, p := range reportPercentiles {
+				v, ok := series.values[percentileKey(p)]
+				if !ok {
+					continue
+				}
+				out = append(out, promSample{
+					Labels: map[string]string{"__name__": series.name, "owner": sum.Owner, "scope": scope, "name": name, "quantile": strconv.FormatFloat(p/100, 'f', -1, 64)},
+					Value:  v,
+				})
+			}
+		}
+	}
+	add("overall", "overall", sum.Overall)
+	for _, name := range sortedKeys(sum.Repos) {
+		add("repo", name, sum.Repos[name])
+	}
+	for _, name := range sortedKeys(sum.Teams) {
+		add("team", name, sum.Teams[name])
+	}
+	return out
+}
+
+// 時刻は送信時刻（期間末を使うと古すぎるサンプルとして拒否されやすい）
+func (w *remoteWriter) Push(ctx context.Context, samples []promSample) error {
+	body := snappyEncode(encodeWriteRequest(samples, time.Now()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// prometheus.WriteRequest
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(samples []promSample, at time.Time) []byte {
+	var req []byte
+	for _, s := range samples {
+		var ts []byte
+		// ラベルは名前順で送る決まり
+		names := make([]string, 0, len(s.Labels))
+		for k := range s.Labels {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			var label []byte
+			label = protoBytes(label, 1, []byte(k))
+			label = protoBytes(label, 2, []byte(s.Labels[k]))
+			ts = protoBytes(ts, 1, label)
+		}
+		var sample []byte
+		sample = binary.AppendUvarint(sample, 1<<3|1) // fixed64
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.Value))
+		sample = binary.AppendUvarint(sample, 2<<3|0) // varint
+		sample = binary.AppendUvarint(sample, uint64(at.UnixMilli()))
+		ts = protoBytes(ts, 2, sample)
+		req = protoBytes(req, 1, ts)
+	}
+	return req
+}
+
+// length-delimited フィールド
+func protoBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// snappy のブロック形式。圧縮はせずリテラルだけで組み立てる（受信側の展開には十分）
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		n := min(len(src), 1<<16)
+		if n <= 60 {
+			dst = append(dst, byte(n-1)<<2)
+		} else {
+			// タグ 61: 長さ - 1 を続く 2 バイトに持つ
+			dst = append(dst, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		dst = append(dst, src[:n]...)
+		src = src[n:]
+	}
+	return dst
+}
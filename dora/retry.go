@@ -0,0 +1,138 @@
+package dora
+
+import (
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// レート制限と一時的なエラーで長い実行が途中で止まらないよう、待ってから同じリクエストを送り直す
+//   - 403/429 で残り 0（X-RateLimit-Remaining）: X-RateLimit-Reset まで待つ
+//   - 403/429 で Retry-After あり（セカンダリレート制限）: 指定秒数待つ
+//   - 429・5xx・通信エラー: 指数バックオフ（ジッター付き）
+//
+// 成功したレスポンスで残りが 0 になった場合も、返す前にリセットまで待つ
+// （go-github は残り 0 を覚えるとリセットまでリクエストを送らずにエラーを返すため）
+type retryPolicy struct {
+	MaxRetries int           // 送り直す回数の上限（0 なら送り直さない）
+	BaseDelay  time.Duration // バックオフの初回の待ち時間
+	MaxWait    time.Duration // 1 回の待ち時間の上限。リセットがこれより先なら待たずにエラーを返す
+}
+
+func (p retryPolicy) Middleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		for attempt := 0; ; attempt++ {
+			resp, err := next.RoundTrip(req)
+			if req.Context().Err() != nil {
+				return resp, err
+			}
+			wait, ok := p.delay(resp, err, attempt)
+			if !ok || attempt >= p.MaxRetries {
+				if err == nil && resp.StatusCode < 400 {
+					if err := p.waitForReset(req, resp); err != nil {
+						resp.Body.Close()
+						return nil, err
+					}
+				}
+				return resp, err
+			}
+			// 本文を送り直せないリクエストはそのまま返す
+			if req.Body != nil && req.Body != http.NoBody {
+				if req.GetBody == nil {
+					return resp, err
+				}
+				body, berr := req.GetBody()
+				if berr != nil {
+					return resp, err
+				}
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
+			if resp != nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			if wait >= time.Minute {
+				log.Printf("⏳ GitHub rate limit reached; waiting %s before retrying %s", wait.Round(time.Second), req.URL.Path)
+			}
+			if err := sleepContext(req, wait); err != nil {
+				return nil, err
+			}
+		}
+	})
+}
+
+// 残りが 0 のレスポンスなら、リセットまで待ってから返す
+func (p retryPolicy) waitForReset(req *http.Request, resp *http.Response) error {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return nil
+	}
+	wait, ok := p.capped(time.Until(time.Unix(reset, 0)) + time.Second)
+	if !ok || wait <= time.Second {
+		return nil
+	}
+	log.Printf("⏳ GitHub rate limit used up; waiting %s for it to reset", wait.Round(time.Second))
+	return sleepContext(req, wait)
+}
+
+func sleepContext(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// 送り直すまでの待ち時間（送り直さない場合は ok=false）
+func (p retryPolicy) delay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		return p.backoff(attempt), true
+	}
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		if s, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil {
+			return p.capped(time.Duration(s) * time.Second)
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			reset, perr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+			if perr != nil {
+				return 0, false
+			}
+			// 時計のずれを見込んで 1 秒余分に待つ
+			return p.capped(time.Until(time.Unix(reset, 0)) + time.Second)
+		}
+		// レート制限の兆候が無い 403 は権限の問題なので送り直さない
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return p.backoff(attempt), true
+		}
+	case resp.StatusCode >= 500:
+		return p.backoff(attempt), true
+	}
+	return 0, false
+}
+
+func (p retryPolicy) capped(wait time.Duration) (time.Duration, bool) {
+	if p.MaxWait > 0 && wait > p.MaxWait {
+		return 0, false
+	}
+	return max(wait, 0), true
+}
+
+// BaseDelay × 2^attempt の後半から一様に選ぶ
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if p.MaxWait > 0 {
+		d = min(d, p.MaxWait)
+	}
+	return d/2 + rand.N(d/2+1)
+}
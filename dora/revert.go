@@ -0,0 +1,124 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// GitHub の Revert ボタンで作られる PR の本文（"Reverts owner/repo#123"）
+var revertsPattern = regexp.MustCompile(`(?m)^Reverts\s+(?:[\w.-]+/[\w.-]+)?#(\d+)`)
+
+// git revert のコミットメッセージ（"This reverts commit <sha>."）
+var revertCommitPattern = regexp.MustCompile(`(?i)\bThis reverts commit ([0-9a-f]{7,40})\b`)
+
+// 手で作り直した再マージ PR（"Reland #123" / "Reapply #123"）
+var relandPattern = regexp.MustCompile(`(?i)\b(?:re-?land|re-?apply|re-?merge)(?:s|ed)?\b[^#\n]*#(\d+)`)
+
+// 取り消し対象の PR 番号（分からなければ 0）
+// タイトルが "revert" で始まるだけの PR は、本文で取り消し対象が分からなければ取り消しとみなさない
+func revertedPRNumber(pr *github.PullRequest) (int, bool) {
+	if !strings.HasPrefix(pr.GetTitle(), `Revert "`) && !revertsPattern.MatchString(pr.GetBody()) && revertedCommitSHA(pr) == "" {
+		return 0, false
+	}
+	m := revertsPattern.FindStringSubmatch(pr.GetBody())
+	if m == nil {
+		return 0, true
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n, true
+}
+
+// 本文の "This reverts commit <sha>" から取り消したコミット（無ければ空）
+func revertedCommitSHA(pr *github.PullRequest) string {
+	if m := revertCommitPattern.FindStringSubmatch(pr.GetBody()); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return ""
+}
+
+// 取り消されたコミットを含むマージ済みの PR 番号（見つからなければ 0）
+func (a *analyzer) prForCommit(ctx context.Context, repoName, sha string) (int, error) {
+	prs, _, err := a.client.PullRequests.ListPullRequestsWithCommit(ctx, a.owner, repoName, sha, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, pr := range prs {
+		if pr.MergedAt != nil {
+			return pr.GetNumber(), nil
+		}
+	}
+	return 0, nil
+}
+
+// 再マージ PR が作り直した元の PR 番号（分からなければ 0）
+func relandedPRNumber(pr *github.PullRequest) (int, bool) {
+	for _, s := range []string{pr.GetTitle(), pr.GetBody()} {
+		if m := relandPattern.FindStringSubmatch(s); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// 取り消し PR の対象を調べた結果
+type revertInfo struct {
+	Reland   bool      // 取り消しの取り消し（元の PR の再マージ）
+	Original int       // 再マージなら元の PR 番号（分からなければ 0）
+	Author   string    // 取り消された PR の作成者
+	Quick    bool      // window 以内の取り消し
+	Shipped  time.Time // 取り消された PR のマージ（デプロイソースがあればデプロイ）日時。MTTR の起点
+}
+
+// 取り消された PR がマージ（デプロイソースがあればデプロイ）から window 以内に取り消されたかを調べる。
+// 対象自体が取り消し PR なら、元の変更の再マージとみなす
+func (a *analyzer) inspectRevert(ctx context.Context, repoName string, index *deployIndex, revert *github.PullRequest, num int) (revertInfo, error) {
+	info := revertInfo{Reland: strings.HasPrefix(revert.GetTitle(), `Revert "Revert "`)}
+	if num == 0 {
+		return info, nil
+	}
+	target, _, err := a.client.PullRequests.Get(ctx, a.owner, repoName, num)
+	if err != nil {
+		return info, err
+	}
+	if n, ok := revertedPRNumber(target); ok {
+		info.Reland, info.Original = true, n
+		return info, nil
+	}
+	if info.Reland || target.MergedAt == nil {
+		return info, nil
+	}
+	info.Author = a.aliases.canonical(target.GetUser().GetLogin())
+	shipped := target.GetMergedAt().Time
+	if index != nil {
+		d, err := index.FirstContaining(ctx, target.GetMergeCommitSHA(), shipped)
+		if err != nil {
+			return info, err
+		}
+		if d != nil {
+			shipped = d.Time
+		}
+	}
+	info.Quick = revert.GetMergedAt().Sub(shipped) <= a.revertWindow
+	info.Shipped = shipped
+	return info, nil
+}
+
+func printRevertSummary(window time.Duration, team *Stats, repos map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n⏪ Fast Reverts (within %s)\n%s\n", line, window, line)
+	fmt.Printf("%-25s | %-8s | %-8s | %-12s | %-14s | %-8s\n", "ENTITY", "PRs", "Reverts", "QuickReverts", "QuickRollback%", "Relands")
+	printRevertRow := func(name string, s *Stats) {
+		fmt.Printf("%-25s | %8d | %8d | %12d | %13.1f%% | %8d\n", name, s.TotalPRs, s.RevertPRs, s.QuickReverts, s.QuickRevertRate(), s.Relands)
+	}
+	printRevertRow("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		printRevertRow(name, repos[name])
+	}
+}
@@ -0,0 +1,107 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// まだ誰もレビューしていない、SLA を超えて待っているオープンな PR
+type pendingReview struct {
+	Repo   string
+	Number int
+	Title  string
+	URL    string
+	Author string
+	Waited time.Duration // 作成から現在までの営業時間
+}
+
+// レビュー依頼が残っていない PR の宛先
+const noReviewerRequested = "(no reviewer requested)"
+
+// 依頼されたレビュアー（チームは @slug）ごとの、SLA を超えて待っている PR。待ちの長い順
+func (a *analyzer) reviewDigest(ctx context.Context, repos []string, now time.Time) (map[string][]pendingReview, error) {
+	digest := make(map[string][]pendingReview)
+	for _, repo := range repos {
+		// 未レビューの一覧は GitHub のリポジトリだけ
+		if _, _, ok := splitForgeRepo(repo); ok {
+			continue
+		}
+		opts := &github.PullRequestListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			prs, resp, err := a.client.PullRequests.List(ctx, a.owner, repo, opts)
+			if err != nil {
+				return digest, fmt.Errorf("%s: %w", repo, err)
+			}
+			for _, pr := range prs {
+				author := a.aliases.canonical(pr.GetUser().GetLogin())
+				if pr.GetDraft() || (len(a.members) > 0 && !a.members[author]) || a.excludedAccount(pr.GetUser()) {
+					continue
+				}
+				waited := a.slaHours.Duration(pr.GetCreatedAt().Time, now)
+				if waited <= a.reviewSLA {
+					continue
+				}
+				rs, err := a.fetchReviews(ctx, repo, pr)
+				if err != nil {
+					return digest, fmt.Errorf("%s#%d: %w", repo, pr.GetNumber(), err)
+				}
+				if rs.FirstReviewAt != nil {
+					continue
+				}
+				p := pendingReview{Repo: repo, Number: pr.GetNumber(), Title: pr.GetTitle(), URL: pr.GetHTMLURL(), Author: author, Waited: waited}
+				reviewers := rs.Requested
+				if len(reviewers) == 0 {
+					reviewers = []string{noReviewerRequested}
+				}
+				for _, r := range reviewers {
+					digest[r] = append(digest[r], p)
+				}
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	for _, prs := range digest {
+		sort.Slice(prs, func(i, j int) bool { return prs[i].Waited > prs[j].Waited })
+	}
+	return digest, nil
+}
+
+func printReviewDigest(sla time.Duration, digest map[string][]pendingReview) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n👀 Waiting for a first review (over %s of business hours)\n%s\n", line, fmtHours(sla), line)
+	if len(digest) == 0 {
+		fmt.Println("No open PRs are over the SLA")
+		return
+	}
+	for _, reviewer := range sortedKeys(digest) {
+		fmt.Printf("%s (%d)\n", reviewer, len(digest[reviewer]))
+		for _, p := range digest[reviewer] {
+			fmt.Printf("  %-25s | #%-5d | %-15s | %9s | %s\n", p.Repo, p.Number, p.Author, fmtHours(p.Waited), p.URL)
+		}
+	}
+}
+
+// Slack 用の本文（レビュアーごとに PR へのリンクを並べる）
+func reviewDigestText(sla time.Duration, digest map[string][]pendingReview) string {
+	lines := []string{fmt.Sprintf("👀 PRs waiting for a first review for more than %s of business hours", fmtHours(sla))}
+	for _, reviewer := range sortedKeys(digest) {
+		lines = append(lines, "", fmt.Sprintf("*%s* (%d)", reviewer, len(digest[reviewer])))
+		for _, p := range digest[reviewer] {
+			lines = append(lines, fmt.Sprintf("• <%s|%s#%d> %s (by %s, waiting %s)", p.URL, p.Repo, p.Number, slackEscape(p.Title), p.Author, fmtHours(p.Waited)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Slack の mrkdwn で制御文字になる &, <, > をエスケープする
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,167 @@
+package dora
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 作成者×レビュアーの組ごとのレビュー件数と応答時間（PR 作成→そのレビュアーの最初のレビュー）
+type reviewPairKey struct {
+	Author, Reviewer string
+}
+
+type reviewPair struct {
+	Reviews   int
+	Responses *tdigest // 時間
+}
+
+// a.mu を保持して呼ぶ
+func (a *analyzer) addReviewPairs(r prResult) {
+	if r.Reviews == nil {
+		return
+	}
+	if a.pairs == nil {
+		a.pairs = make(map[reviewPairKey]*reviewPair)
+	}
+	for _, reviewer := range r.Reviews.Reviewers {
+		k := reviewPairKey{r.Author, reviewer}
+		p := a.pairs[k]
+		if p == nil {
+			p = &reviewPair{Responses: newTDigest()}
+			a.pairs[k] = p
+		}
+		p.Reviews++
+		if at, ok := r.Reviews.FirstBy[reviewer]; ok {
+			p.Responses.Add(elapsed(r.CreatedAt, at).Hours())
+		}
+	}
+}
+
+// レビュアーごとの合計（件数の多い順）
+type reviewerLoad struct {
+	Reviewer string
+	Reviews  int
+	Authors  int // レビューした相手の人数
+	Share    float64
+	Median   float64 // 応答時間が分からなければ -1
+}
+
+func reviewerLoads(pairs map[reviewPairKey]*reviewPair) []reviewerLoad {
+	byReviewer := make(map[string]*reviewerLoad)
+	digests := make(map[string]*tdigest)
+	total := 0
+	for k, p := range pairs {
+		l := byReviewer[k.Reviewer]
+		if l == nil {
+			l = &reviewerLoad{Reviewer: k.Reviewer}
+			byReviewer[k.Reviewer] = l
+			digests[k.Reviewer] = newTDigest()
+		}
+		l.Reviews += p.Reviews
+		l.Authors++
+		digests[k.Reviewer].Merge(p.Responses)
+		total += p.Reviews
+	}
+	var out []reviewerLoad
+	for name, l := range byReviewer {
+		l.Share = float64(l.Reviews) / float64(total) * 100
+		l.Median = -1
+		if digests[name].Count() > 0 {
+			l.Median = digests[name].Quantile(0.5)
+		}
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Reviews != out[j].Reviews {
+			return out[i].Reviews > out[j].Reviews
+		}
+		return out[i].Reviewer < out[j].Reviewer
+	})
+	return out
+}
+
+// 一部の人にレビューが集中していないかを見る
+func printReviewLoadSummary(pairs map[reviewPairKey]*reviewPair) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🔍 Review Load (by reviewer)\n%s\n", line, line)
+	fmt.Printf("%-25s | %8s | %8s | %8s | %12s\n", "REVIEWER", "Reviews", "Share", "Authors", "MedianResp")
+	for _, l := range reviewerLoads(pairs) {
+		median := "-"
+		if l.Median >= 0 {
+			median = fmtHours(time.Duration(l.Median * float64(time.Hour)))
+		}
+		fmt.Printf("%-25s | %8d | %7.1f%% | %8d | %12s\n", l.Reviewer, l.Reviews, l.Share, l.Authors, median)
+	}
+	fmt.Println("Full author × reviewer matrix: --output html or --review-matrix-out <file.csv>")
+}
+
+// 1 行 1 組の CSV（表計算でピボットしやすい縦持ち）
+func writeReviewMatrixCSV(w io.Writer, pairs map[reviewPairKey]*reviewPair) error {
+	keys := make([]reviewPairKey, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Author != keys[j].Author {
+			return keys[i].Author < keys[j].Author
+		}
+		return keys[i].Reviewer < keys[j].Reviewer
+	})
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"author", "reviewer", "reviews", "median_response_hours"})
+	for _, k := range keys {
+		p := pairs[k]
+		median := ""
+		if p.Responses.Count() > 0 {
+			median = strconv.FormatFloat(p.Responses.Quantile(0.5), 'f', 1, 64)
+		}
+		cw.Write([]string{k.Author, k.Reviewer, strconv.Itoa(p.Reviews), median})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// HTML 用の表（行: 作成者、列: レビュアー）
+type htmlMatrix struct {
+	Reviewers []string
+	Rows      []htmlMatrixRow
+}
+
+type htmlMatrixRow struct {
+	Author string
+	Cells  []string // "件数 / 中央値h"（組が無ければ空）
+}
+
+func buildReviewMatrix(pairs map[reviewPairKey]*reviewPair) *htmlMatrix {
+	if len(pairs) == 0 {
+		return nil
+	}
+	m := &htmlMatrix{}
+	authors := make(map[string]bool)
+	for _, l := range reviewerLoads(pairs) {
+		m.Reviewers = append(m.Reviewers, l.Reviewer)
+	}
+	for k := range pairs {
+		authors[k.Author] = true
+	}
+	for _, author := range sortedKeys(authors) {
+		row := htmlMatrixRow{Author: author}
+		for _, reviewer := range m.Reviewers {
+			cell := ""
+			if p := pairs[reviewPairKey{author, reviewer}]; p != nil {
+				cell = strconv.Itoa(p.Reviews)
+				if p.Responses.Count() > 0 {
+					cell += fmt.Sprintf(" / %.1fh", p.Responses.Quantile(0.5))
+				}
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		m.Rows = append(m.Rows, row)
+	}
+	return m
+}
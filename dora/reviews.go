@@ -0,0 +1,99 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// PR のレビュー状況（作成者以外のレビューのみ）
+type reviewSummary struct {
+	Reviewers     []string             // 別名解決後のレビュアー
+	Requested     []string             // レビュー依頼が残っているレビュアー（チームは @slug）
+	FirstBy       map[string]time.Time // レビュアーごとの最初のレビュー
+	FirstReviewAt *time.Time
+	ApprovedAt    *time.Time
+}
+
+func (a *analyzer) fetchReviews(ctx context.Context, repoName string, pr *github.PullRequest) (reviewSummary, error) {
+	// --api graphql では一覧の取得時にレビューも取ってある
+	if p := a.prefetch.get(repoName, pr.GetNumber()); p != nil && p.reviews != nil {
+		return a.summarizeReviews(pr, p.reviews), nil
+	}
+	var all []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := a.client.PullRequests.ListReviews(ctx, a.owner, repoName, pr.GetNumber(), opts)
+		if err != nil {
+			return reviewSummary{}, err
+		}
+		all = append(all, reviews...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return a.summarizeReviews(pr, all), nil
+}
+
+func (a *analyzer) summarizeReviews(pr *github.PullRequest, reviews []*github.PullRequestReview) reviewSummary {
+	var rs reviewSummary
+	author := a.aliases.canonical(pr.GetUser().GetLogin())
+	reviewers := make(map[string]bool)
+	for _, rv := range reviews {
+		login := a.aliases.canonical(rv.GetUser().GetLogin())
+		// 未送信（PENDING）のレビュー、作成者自身のコメント、bot などのレビューは除く
+		if login == "" || login == author || rv.GetSubmittedAt().IsZero() || a.excludedAccount(rv.GetUser()) {
+			continue
+		}
+		reviewers[login] = true
+		at := rv.GetSubmittedAt().Time
+		if rs.FirstBy == nil {
+			rs.FirstBy = make(map[string]time.Time)
+		}
+		if first, ok := rs.FirstBy[login]; !ok || at.Before(first) {
+			rs.FirstBy[login] = at
+		}
+		if rs.FirstReviewAt == nil || at.Before(*rs.FirstReviewAt) {
+			rs.FirstReviewAt = &at
+		}
+		if rv.GetState() == "APPROVED" && (rs.ApprovedAt == nil || at.Before(*rs.ApprovedAt)) {
+			rs.ApprovedAt = &at
+		}
+	}
+	for login := range reviewers {
+		rs.Reviewers = append(rs.Reviewers, login)
+	}
+	sort.Strings(rs.Reviewers)
+	// レビューすると依頼は外れるので、ここに残るのは応答しなかった相手
+	for _, u := range pr.RequestedReviewers {
+		rs.Requested = append(rs.Requested, a.aliases.canonical(u.GetLogin()))
+	}
+	for _, t := range pr.RequestedTeams {
+		rs.Requested = append(rs.Requested, "@"+t.GetSlug())
+	}
+	return rs
+}
+
+// レビューなしでマージされた PR・作成者自身がマージした PR の割合（ガバナンス指標）
+func printGovernanceSummary(team *Stats, repos map[string]*Stats, users map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🛡️  Governance\n%s\n", line, line)
+	fmt.Printf("%-25s | %-8s | %-10s | %-12s | %-10s | %-12s\n", "ENTITY", "PRs", "Unreviewed", "Unreviewed%", "SelfMerged", "SelfMerged%")
+	printGovRow := func(name string, s *Stats) {
+		fmt.Printf("%-25s | %8d | %10d | %11.1f%% | %10d | %11.1f%%\n",
+			name, s.TotalPRs, s.UnreviewedPRs, s.UnreviewedRate(), s.SelfMergedPRs, s.SelfMergeRate())
+	}
+	printGovRow("OVERALL TEAM", team)
+	for _, name := range sortedKeys(repos) {
+		printGovRow(name, repos[name])
+	}
+	fmt.Println(line)
+	for _, name := range sortedKeys(users) {
+		printGovRow(name, users[name])
+	}
+}
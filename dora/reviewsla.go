@@ -0,0 +1,73 @@
+package dora
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 最初のレビューが SLA を超えた PR（個別にフォローするための一覧）
+type slaBreach struct {
+	Repo      string
+	Number    int
+	Title     string
+	URL       string
+	Author    string
+	Waited    time.Duration // 作成から最初のレビュー（無ければマージ）までの営業時間
+	Reviewed  bool          // false ならレビューなしでマージされた
+	Reviewers []string      // レビューした人と、依頼されたまま応答しなかった人
+}
+
+func (b slaBreach) Over(sla time.Duration) time.Duration {
+	return b.Waited - sla
+}
+
+// レビュー状況が分かる PR について、最初のレビューまでの営業時間を SLA と比べる
+func (a *analyzer) checkReviewSLA(repo, title, url string, r prResult) {
+	if a.reviewSLA <= 0 || r.Reviews == nil {
+		return
+	}
+	end, reviewed := r.MergedAt, false
+	if r.Reviews.FirstReviewAt != nil && r.Reviews.FirstReviewAt.Before(end) {
+		end, reviewed = *r.Reviews.FirstReviewAt, true
+	}
+	waited := a.slaHours.Duration(r.CreatedAt, end)
+	if waited <= a.reviewSLA {
+		return
+	}
+	b := slaBreach{Repo: repo, Number: r.Number, Title: title, URL: url, Author: r.Author, Waited: waited, Reviewed: reviewed}
+	b.Reviewers = append(append(b.Reviewers, r.Reviews.Reviewers...), r.Reviews.Requested...)
+	a.mu.Lock()
+	a.breaches = append(a.breaches, b)
+	a.mu.Unlock()
+}
+
+// 超過の大きい順に並べる
+func printReviewSLABreaches(sla time.Duration, b *businessHours, breaches []slaBreach, checked int) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n⏰ First-review SLA breaches (SLA %s of business hours, %s-%s %s)\n%s\n", line, fmtHours(sla), fmtClock(b.start), fmtClock(b.end), b.loc, line)
+	fmt.Printf("%d of %d PRs waited longer than the SLA for a first review\n", len(breaches), checked)
+	if len(breaches) == 0 {
+		return
+	}
+	sort.Slice(breaches, func(i, j int) bool { return breaches[i].Waited > breaches[j].Waited })
+	fmt.Printf("%-25s | %-6s | %-15s | %9s | %9s | %s\n", "REPOSITORY", "PR", "AUTHOR", "Waited", "Over", "REVIEWERS")
+	for _, br := range breaches {
+		waited := fmtHours(br.Waited)
+		if !br.Reviewed {
+			waited += "*"
+		}
+		reviewers := strings.Join(br.Reviewers, ", ")
+		if reviewers == "" {
+			reviewers = "(none requested)"
+		}
+		fmt.Printf("%-25s | #%-5d | %-15s | %9s | %9s | %s\n", br.Repo, br.Number, br.Author, waited, "+"+fmtHours(br.Over(sla)), reviewers)
+		fmt.Printf("%-25s   %s\n", "", br.URL)
+	}
+	fmt.Println("* merged without a review; waited until the merge")
+}
+
+func fmtHours(d time.Duration) string {
+	return fmt.Sprintf("%.1fh", d.Hours())
+}
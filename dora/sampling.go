@@ -0,0 +1,72 @@
+package dora
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// 母集団が大きいときに PR をランダムに抽出する（reservoir sampling）
+type reservoir struct {
+	size  int
+	seen  int
+	items []int
+	rng   *rand.Rand
+}
+
+func newReservoir(size int, seed int64) *reservoir {
+	return &reservoir{size: size, rng: rand.New(rand.NewSource(seed))}
+}
+
+// v が標本に入れば true。入れ替えで標本から外れた値があれば evicted に返す（無ければ 0）
+func (r *reservoir) Offer(v int) (kept bool, evicted int) {
+	r.seen++
+	if len(r.items) < r.size {
+		r.items = append(r.items, v)
+		return true, 0
+	}
+	if j := r.rng.Intn(r.seen); j < r.size {
+		evicted, r.items[j] = r.items[j], v
+		return true, evicted
+	}
+	return false, 0
+}
+
+func (r *reservoir) Items() []int {
+	return r.items
+}
+
+// 平均リードタイム（時間）の 95% 信頼区間
+func leadTimeCI(s *Stats) (lo, hi float64) {
+	return meanCI(s.LeadTimeSamples, s.TotalLeadTime.Hours(), s.LeadTimeSqSum, finitePopulationCorrection(s.LeadTimeCount, s.Population))
+}
+
+// CFR（%）の 95% 信頼区間（正規近似）
+func cfrCI(s *Stats) (lo, hi float64) {
+	lo, hi = proportionCI(s.BugFixPRs, s.TotalPRs, finitePopulationCorrection(s.TotalPRs, s.Population))
+	return lo * 100, hi * 100
+}
+
+func printSamplingSummary(team *Stats, repos map[string]*Stats) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🎯 Sampling (95%% confidence intervals)\n%s\n", line, line)
+	fmt.Printf("%-25s | %-15s | %-22s | %-20s\n", "ENTITY", "Sampled/Total", "AvgLT CI", "CFR CI")
+
+	printCI := func(name string, s *Stats) {
+		ltLo, ltHi := leadTimeCI(s)
+		cfrLo, cfrHi := cfrCI(s)
+		fmt.Printf("%-25s | %15s | %8.1fh - %8.1fh | %7.1f%% - %7.1f%%\n",
+			name, fmt.Sprintf("%d/%d", s.TotalPRs, s.Population), ltLo, ltHi, cfrLo, cfrHi)
+	}
+	printCI("OVERALL TEAM", team)
+
+	names := make([]string, 0, len(repos))
+	for name := range repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		printCI(name, repos[name])
+	}
+}
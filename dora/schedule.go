@@ -0,0 +1,121 @@
+package dora
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// 定期収集の間隔の下限（収集 1 回でレート制限をかなり使うため）
+const minScheduleInterval = 10 * time.Minute
+
+// テナントごとの定期収集の状態
+type tenantSchedule struct {
+	Every   string    `json:"every"`
+	NextRun time.Time `json:"next_run"`
+}
+
+// schedule（無ければ every）の間隔でテナントごとに直近 30 日を収集する。
+// 開始時刻はテナント間でずらし（最短の間隔をテナント数で割った分ずつ）、API の使用を平準化する
+// 前回の収集が間隔より新しければ、その次の回まで待つ（再起動のたびに取り直さない）
+func (s *metricsServer) startSchedules(ctx context.Context, every time.Duration) {
+	var scheduled []*tenantConfig
+	shortest := time.Duration(0)
+	for i := range s.tenants {
+		t := &s.tenants[i]
+		if t.every == 0 {
+			t.every = every
+		}
+		if t.every <= 0 {
+			continue
+		}
+		scheduled = append(scheduled, t)
+		if shortest == 0 || t.every < shortest {
+			shortest = t.every
+		}
+	}
+	if len(scheduled) == 0 {
+		return
+	}
+	spread := shortest / time.Duration(len(scheduled))
+	now := time.Now()
+	for k, t := range scheduled {
+		next := now.Add(time.Duration(k) * spread)
+		if last := s.lastCollected(t); last.Add(t.every).After(next) {
+			next = last.Add(t.every)
+		}
+		s.setNextRun(t, next)
+		log.Printf("🗓️  %s: collecting every %s, next at %s", t.Name, t.every, next.Local().Format("2006-01-02 15:04"))
+		go s.runSchedule(ctx, t, next)
+	}
+}
+
+func (s *metricsServer) runSchedule(ctx context.Context, t *tenantConfig, next time.Time) {
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		now := time.Now().In(reportLocation)
+		// 手動の更新や別プロセスの収集と重なった場合は、この回を飛ばす
+		if _, err := s.startRefresh(t, now.AddDate(0, 0, -29).Format("2006-01-02"), now.Format("2006-01-02")); err != nil {
+			log.Printf("⚠️  %s: scheduled refresh skipped: %v", t.Name, err)
+		}
+		// 間隔はずらした位相のまま保つ（遅れが 1 回分を超えたら今から数え直す）
+		next = next.Add(t.every)
+		if next.Before(time.Now()) {
+			next = time.Now().Add(t.every)
+		}
+		s.setNextRun(t, next)
+	}
+}
+
+func (s *metricsServer) setNextRun(t *tenantConfig, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedule[t.Name] = &tenantSchedule{Every: t.every.String(), NextRun: next.UTC()}
+}
+
+// 保存済みのスナップショットで最も新しい収集日時（無ければゼロ値）
+func (s *metricsServer) lastCollected(t *tenantConfig) time.Time {
+	infos, err := s.snapshots(t)
+	if err != nil || len(infos) == 0 {
+		return time.Time{}
+	}
+	return infos[len(infos)-1].CollectedAt
+}
+
+// テナントの収集状況。最後に成功した収集は保存先から求めるので、保存先を共有する他のプロセスの収集も含む
+type tenantStatus struct {
+	Tenant      string          `json:"tenant"`
+	LastSuccess *snapshotInfo   `json:"last_success,omitempty"`
+	LastRefresh *refreshJob     `json:"last_refresh,omitempty"` // このプロセスで最後に始めた収集
+	Schedule    *tenantSchedule `json:"schedule,omitempty"`
+}
+
+func (s *metricsServer) handleStatus(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	infos, err := s.snapshots(t)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	status := tenantStatus{Tenant: t.Name}
+	if len(infos) > 0 {
+		status.LastSuccess = &infos[len(infos)-1]
+	}
+	s.mu.Lock()
+	if job := s.jobs[t.Name]; job != nil {
+		j := *job
+		status.LastRefresh = &j
+	}
+	if sc := s.schedule[t.Name]; sc != nil {
+		c := *sc
+		status.Schedule = &c
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,183 @@
+package dora
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// semantic-release / standard-version のタグとリリースコミットをデプロイとして扱い、
+// 直前のバージョンとの比較で major / minor / patch に分類する
+type semverSource struct {
+	client *github.Client
+	owner  string
+	from   time.Time
+	branch string // 空ならリポジトリの既定ブランチ
+}
+
+type semver struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+var (
+	semverPattern        = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+	releaseCommitPattern = regexp.MustCompile(`^chore\(release\):\s*(v?\d+\.\d+\.\d+\S*)`)
+)
+
+func parseSemver(s string) (semver, bool) {
+	m := semverPattern.FindStringSubmatch(s)
+	if m == nil {
+		return semver{}, false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return semver{Major: major, Minor: minor, Patch: patch, Pre: m[4]}, true
+}
+
+func (v semver) Less(o semver) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	if v.Patch != o.Patch {
+		return v.Patch < o.Patch
+	}
+	// プレリリースは正式版より前
+	if (v.Pre == "") != (o.Pre == "") {
+		return v.Pre != ""
+	}
+	return v.Pre < o.Pre
+}
+
+func releaseType(prev, cur semver) string {
+	switch {
+	case cur.Pre != "":
+		return "prerelease"
+	case cur.Major != prev.Major:
+		return "major"
+	case cur.Minor != prev.Minor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+func newSemverSource(client *github.Client, owner string, from time.Time) *semverSource {
+	return &semverSource{client: client, owner: owner, from: from}
+}
+
+func (s *semverSource) Name() string {
+	return "semver"
+}
+
+type semverRelease struct {
+	version semver
+	deploy  deployment
+}
+
+func (s *semverSource) Deployments(ctx context.Context, repo string) ([]deployment, error) {
+	releases, err := s.tagReleases(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := s.releaseCommits(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	// タグを打たない運用のリポジトリ向けに、タグの無いリリースコミットも拾う
+	seen := make(map[semver]bool)
+	for _, r := range releases {
+		seen[r.version] = true
+	}
+	for _, r := range commits {
+		if !seen[r.version] {
+			seen[r.version] = true
+			releases = append(releases, r)
+		}
+	}
+
+	sort.Slice(releases, func(i, j int) bool { return releases[i].version.Less(releases[j].version) })
+	var out []deployment
+	for i, r := range releases {
+		// 期間前の基準バージョンも含める（期間外なのでデプロイ数には数えられない）
+		r.deploy.ReleaseType = "initial"
+		if i > 0 {
+			r.deploy.ReleaseType = releaseType(releases[i-1].version, r.version)
+		}
+		out = append(out, r.deploy)
+	}
+	return out, nil
+}
+
+// semver タグを新しい順に辿り、期間開始より前のタグに達したら 1 つだけ基準として残して止める
+func (s *semverSource) tagReleases(ctx context.Context, repo string) ([]semverRelease, error) {
+	var tags []semverRelease
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := s.client.Repositories.ListTags(ctx, s.owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page {
+			if v, ok := parseSemver(t.GetName()); ok {
+				tags = append(tags, semverRelease{version: v, deploy: deployment{SHA: t.GetCommit().GetSHA(), Ref: t.GetName()}})
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[j].version.Less(tags[i].version) })
+
+	var out []semverRelease
+	for _, t := range tags {
+		commit, _, err := s.client.Repositories.GetCommit(ctx, s.owner, repo, t.deploy.SHA, &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return nil, err
+		}
+		t.deploy.Time = commit.GetCommit().GetCommitter().GetDate().Time
+		out = append(out, t)
+		if t.deploy.Time.Before(s.from) {
+			break
+		}
+	}
+	return out, nil
+}
+
+// "chore(release): 1.2.3" 形式のリリースコミット
+func (s *semverSource) releaseCommits(ctx context.Context, repo string) ([]semverRelease, error) {
+	var out []semverRelease
+	opts := &github.CommitsListOptions{SHA: s.branch, Since: s.from, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		commits, resp, err := s.client.Repositories.ListCommits(ctx, s.owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range commits {
+			m := releaseCommitPattern.FindStringSubmatch(c.GetCommit().GetMessage())
+			if m == nil {
+				continue
+			}
+			if v, ok := parseSemver(m[1]); ok {
+				out = append(out, semverRelease{version: v, deploy: deployment{
+					SHA:  c.GetSHA(),
+					Time: c.GetCommit().GetCommitter().GetDate().Time,
+					Ref:  m[1],
+				}})
+			}
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,504 @@
+package dora
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"gopkg.in/yaml.v3"
+)
+
+// serve モードのテナント定義
+//
+//	tenants:
+//	  - name: backend
+//	    api_token: ${BACKEND_API_TOKEN}     # API 呼び出し時の Bearer トークン
+//	    owner: your-org
+//	    repos: [api, worker]
+//	    members: [alice, bob]               # 任意
+//	    deploy_source: deployments          # merge（既定）/ semver / deployments / releases
+//	    github_token: ${BACKEND_GH_TOKEN}   # 任意。無ければ GITHUB_TOKEN_<ORG> / GITHUB_TOKEN
+//	    schedule: 24h                       # 任意。この間隔で直近 30 日を収集する（--schedule より優先）
+//	    teams:                              # 任意。チーム別の集計・推移に使う
+//	      - name: payments
+//	        members: [alice]
+type tenantsFile struct {
+	Tenants []tenantConfig `yaml:"tenants"`
+}
+
+type tenantConfig struct {
+	Name         string       `yaml:"name"`
+	APIToken     string       `yaml:"api_token"`
+	Owner        string       `yaml:"owner"`
+	Repos        []string     `yaml:"repos"`
+	Members      []string     `yaml:"members"`
+	DeploySource string       `yaml:"deploy_source"`
+	GitHubToken  string       `yaml:"github_token"`
+	Teams        []teamConfig `yaml:"teams"`
+	Schedule     string       `yaml:"schedule"`
+
+	every time.Duration // Schedule を解釈したもの（0 なら --schedule に従う）
+}
+
+var tenantNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func loadTenantsFile(path string) (*tenantsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f tenantsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	seen := make(map[string]bool)
+	for i := range f.Tenants {
+		t := &f.Tenants[i]
+		// トークンは秘密情報なので環境変数で渡せるようにする
+		t.APIToken = os.ExpandEnv(t.APIToken)
+		t.GitHubToken = os.ExpandEnv(t.GitHubToken)
+		switch {
+		case !tenantNamePattern.MatchString(t.Name):
+			return nil, fmt.Errorf("%s: tenant #%d has an invalid name %q", path, i+1, t.Name)
+		case seen[t.Name]:
+			return nil, fmt.Errorf("%s: duplicate tenant %q", path, t.Name)
+		case t.APIToken == "":
+			return nil, fmt.Errorf("%s: tenant %q has no api_token", path, t.Name)
+		case t.Owner == "" || len(t.Repos) == 0:
+			return nil, fmt.Errorf("%s: tenant %q needs owner and repos", path, t.Name)
+		}
+		switch t.DeploySource {
+		case "", "merge", "semver", "deployments", "releases":
+		default:
+			return nil, fmt.Errorf("%s: tenant %q: unsupported deploy_source %q", path, t.Name, t.DeploySource)
+		}
+		if t.Schedule != "" {
+			every, err := time.ParseDuration(t.Schedule)
+			if err != nil || every < minScheduleInterval {
+				return nil, fmt.Errorf("%s: tenant %q: schedule %q must be a duration of at least %s (e.g. 24h)", path, t.Name, t.Schedule, minScheduleInterval)
+			}
+			t.every = every
+		}
+		seen[t.Name] = true
+	}
+	return &f, nil
+}
+
+// テナントごとのスナップショットを保存し、API で参照・更新させる
+type metricsServer struct {
+	tenants   []tenantConfig
+	store     string // スナップショットの保存先（<store>/<tenant>/<id>.db）
+	newClient func(tenant, token string) (*github.Client, error)
+	tracer    *tracer
+
+	mu       sync.Mutex
+	jobs     map[string]*refreshJob // テナント -> 最新の更新ジョブ
+	schedule map[string]*tenantSchedule
+
+	cacheMu sync.Mutex
+	cache   map[string]*snapshotCacheEntry // スナップショットのパス -> メタデータと履歴の集計
+}
+
+// スナップショットのファイルから読んだ値。ファイルの更新日時・サイズが変わるまで使い回す
+// （書き込みは一時ファイルからの rename なので、取り直せば必ず変わる）
+type snapshotCacheEntry struct {
+	modTime time.Time
+	size    int64
+	info    snapshotInfo
+	err     error         // 読めないファイル（毎回読み直して警告しないよう覚えておく）
+	point   *historyPoint // 履歴の集計（まだ求めていなければ nil）
+}
+
+type refreshJob struct {
+	From       string     `json:"from"`
+	To         string     `json:"to"`
+	Status     string     `json:"status"` // running / done / failed
+	Snapshot   string     `json:"snapshot,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type snapshotInfo struct {
+	ID          string    `json:"id"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	CollectedAt time.Time `json:"collected_at"`
+}
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+var snapshotIDPattern = regexp.MustCompile(`^[0-9A-Za-z_.-]+$`)
+
+func newMetricsServer(tenants []tenantConfig, store string, newClient func(tenant, token string) (*github.Client, error), tr *tracer) *metricsServer {
+	return &metricsServer{tenants: tenants, store: store, newClient: newClient, tracer: tr, jobs: make(map[string]*refreshJob), schedule: make(map[string]*tenantSchedule), cache: make(map[string]*snapshotCacheEntry)}
+}
+
+func (s *metricsServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	// ダッシュボード自体は静的ページ。データは API トークンを付けて /api/v1/history から取る
+	mux.HandleFunc("GET /dashboard", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboardHTML)
+	})
+	mux.HandleFunc("GET /api/v1/snapshots", s.authed(s.handleSnapshots))
+	mux.HandleFunc("GET /api/v1/history", s.authed(s.handleHistory))
+	mux.HandleFunc("GET /api/v1/metrics", s.authed(s.handleMetrics))
+	mux.HandleFunc("POST /api/v1/refresh", s.authed(s.handleRefresh))
+	mux.HandleFunc("GET /api/v1/refresh", s.authed(s.handleRefreshStatus))
+	mux.HandleFunc("GET /api/v1/status", s.authed(s.handleStatus))
+	s.grafanaRoutes(mux)
+	return mux
+}
+
+// Bearer トークンからテナントを決める。他テナントのデータには触れられない
+func (s *metricsServer) authed(h func(http.ResponseWriter, *http.Request, *tenantConfig)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for i := range s.tenants {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(s.tenants[i].APIToken)) == 1 {
+					h(w, r, &s.tenants[i])
+					return
+				}
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="dora-metrics"`)
+		writeJSONError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+	}
+}
+
+func (s *metricsServer) handleSnapshots(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	infos, err := s.snapshots(t)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// ?snapshot=<id>（省略時は最新）を再集計して返す。?members= / ?repos= で絞り込める
+func (s *metricsServer) handleMetrics(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	id := r.URL.Query().Get("snapshot")
+	if id == "" {
+		infos, err := s.snapshots(t)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if len(infos) == 0 {
+			writeJSONError(w, http.StatusNotFound, "no snapshots yet; POST /api/v1/refresh to collect one")
+			return
+		}
+		id = infos[len(infos)-1].ID
+	}
+	if !snapshotIDPattern.MatchString(id) {
+		writeJSONError(w, http.StatusBadRequest, "invalid snapshot id")
+		return
+	}
+	snap, err := readSnapshot(filepath.Join(s.store, t.Name, id+".db"))
+	if os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, "snapshot not found")
+		return
+	} else if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a := newSnapshotAnalyzer(snap, s.tracer)
+	a.membership = (&teamsFile{Teams: t.Teams}).membership()
+	a.members = make(map[string]bool)
+	for _, m := range splitList(r.URL.Query().Get("members")) {
+		a.members[m] = true
+	}
+	repos := make(map[string]bool)
+	for _, repo := range splitList(r.URL.Query().Get("repos")) {
+		repos[repo] = true
+	}
+	a.replay(snap, repos)
+	if r.URL.Query().Get("format") == "prometheus" {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writePrometheusText(w, prometheusSamples(summarize(a)))
+		return
+	}
+	writeJSON(w, http.StatusOK, summarize(a))
+}
+
+// 履歴の 1 点（スナップショット 1 つ分の集計）
+type historyPoint struct {
+	snapshotInfo
+	Overall      statsSummary            `json:"overall"`
+	Repos        map[string]statsSummary `json:"repos"`
+	Teams        map[string]statsSummary `json:"teams,omitempty"`
+	OverallTiers map[string]string       `json:"overall_tiers"`
+}
+
+// ?since= / ?until=（YYYY-MM-DD、スナップショットの期間末で絞る）の範囲の推移を返す
+func (s *metricsServer) handleHistory(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	points, err := s.history(t, r.URL.Query().Get("since"), r.URL.Query().Get("until"))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tenant": t.Name, "tiers": doraTiers, "points": points})
+}
+
+// 期間末が since〜until（空なら無制限）のスナップショットを期間末の順に集計する。
+// 同じ期間を取り直したものは最新の収集だけを使う
+func (s *metricsServer) history(t *tenantConfig, since, until string) ([]historyPoint, error) {
+	infos, err := s.snapshots(t)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]snapshotInfo)
+	for _, info := range infos {
+		if (since != "" && info.To < since) || (until != "" && info.To > until) {
+			continue
+		}
+		latest[info.From+"_"+info.To] = info
+	}
+	infos = infos[:0]
+	for _, info := range latest {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].To != infos[j].To {
+			return infos[i].To < infos[j].To
+		}
+		return infos[i].From < infos[j].From
+	})
+	points := []historyPoint{}
+	for _, info := range infos {
+		path := filepath.Join(s.store, t.Name, info.ID+".db")
+		entry, err := s.snapshotEntry(path)
+		if err != nil {
+			continue
+		}
+		s.cacheMu.Lock()
+		point := entry.point
+		s.cacheMu.Unlock()
+		if point != nil {
+			points = append(points, *point)
+			continue
+		}
+		snap, err := readSnapshot(path)
+		if err != nil {
+			continue
+		}
+		a := newSnapshotAnalyzer(snap, s.tracer)
+		a.membership = (&teamsFile{Teams: t.Teams}).membership()
+		a.replay(snap, nil)
+		sum := summarize(a)
+		point = &historyPoint{
+			snapshotInfo: info,
+			Overall:      sum.Overall,
+			Repos:        sum.Repos,
+			Teams:        sum.Teams,
+			OverallTiers: map[string]string{
+				"deployments_per_day":    tierOf("deployments_per_day", sum.Overall.DeploymentsPerDay),
+				"median_lead_time_hours": tierOf("median_lead_time_hours", sum.Overall.MedianLeadTimeHours),
+				"cfr_percent":            tierOf("cfr_percent", sum.Overall.CFRPercent),
+				"mttr_hours":             tierOf("mttr_hours", sum.Overall.MTTRHours),
+			},
+		}
+		s.cacheMu.Lock()
+		entry.point = point
+		s.cacheMu.Unlock()
+		points = append(points, *point)
+	}
+	return points, nil
+}
+
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD（省略時は直近 30 日）で収集を開始する
+func (s *metricsServer) handleRefresh(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = dayOf(time.Now())
+	}
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		end, _ := parseDay(to)
+		from = end.AddDate(0, 0, -29).Format("2006-01-02")
+	}
+	start, err1 := parseDay(from)
+	end, err2 := parseDay(to)
+	if err1 != nil || err2 != nil || end.Before(start) {
+		writeJSONError(w, http.StatusBadRequest, "from/to must be YYYY-MM-DD with from <= to")
+		return
+	}
+
+	job, err := s.startRefresh(t, from, to)
+	if job != nil && err != nil {
+		writeJSON(w, http.StatusConflict, job)
+		return
+	} else if err != nil {
+		writeJSONError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// バックグラウンドで収集を始める。収集中ならそのジョブとエラーを返す
+func (s *metricsServer) startRefresh(t *tenantConfig, from, to string) (*refreshJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job := s.jobs[t.Name]; job != nil && job.Status == "running" {
+		return job, fmt.Errorf("a refresh is already running for %s", t.Name)
+	}
+	// 同じ保存先を共有する別プロセスの収集とも重ならないようにする
+	unlock, err := lockTenantStore(filepath.Join(s.store, t.Name))
+	if err != nil {
+		return nil, err
+	}
+	job := &refreshJob{From: from, To: to, Status: "running", StartedAt: time.Now().UTC()}
+	s.jobs[t.Name] = job
+
+	go func() {
+		defer unlock()
+		s.refresh(t, job)
+	}()
+	return job, nil
+}
+
+func (s *metricsServer) handleRefreshStatus(w http.ResponseWriter, r *http.Request, t *tenantConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[t.Name]
+	if job == nil {
+		writeJSONError(w, http.StatusNotFound, "no refresh has been requested")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *metricsServer) refresh(t *tenantConfig, job *refreshJob) {
+	id := fmt.Sprintf("%s_%s_%s", job.From, job.To, job.StartedAt.Format("20060102T150405Z"))
+	err := s.collect(t, job.From, job.To, id)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	job.FinishedAt = &now
+	if err != nil {
+		job.Status, job.Error = "failed", err.Error()
+		log.Printf("⚠️  %s: refresh failed: %v", t.Name, err)
+		return
+	}
+	job.Status, job.Snapshot = "done", id
+}
+
+func (s *metricsServer) collect(t *tenantConfig, from, to, id string) error {
+	token := t.GitHubToken
+	if token == "" {
+		token = tokenForOrg(t.Owner)
+	}
+	client, err := s.newClient(t.Name, token)
+	if err != nil {
+		return err
+	}
+	a := newAnalyzer(client, s.tracer, t.Owner, from, to)
+	a.revertWindow = 24 * time.Hour
+	if len(t.Members) > 0 {
+		a.members = make(map[string]bool)
+		for _, m := range t.Members {
+			a.members[m] = true
+		}
+	}
+	start, _ := a.window()
+	if a.deploys, err = simpleDeploySource(client, t.Owner, t.DeploySource, start); err != nil {
+		return err
+	}
+	dir := filepath.Join(s.store, t.Name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return runCollect(context.Background(), a, t.Repos, filepath.Join(dir, id+".db"))
+}
+
+// テナントのスナップショットを収集日時の古い順に返す
+// 読むのは前回から増えた・変わったファイルだけ
+func (s *metricsServer) snapshots(t *tenantConfig) ([]snapshotInfo, error) {
+	dir := filepath.Join(s.store, t.Name)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.db"))
+	if err != nil {
+		return nil, err
+	}
+	infos := []snapshotInfo{}
+	present := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		present[p] = true
+		entry, err := s.snapshotEntry(p)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, entry.info)
+	}
+	// 消されたスナップショットは忘れる
+	s.cacheMu.Lock()
+	for p := range s.cache {
+		if filepath.Dir(p) == dir && !present[p] {
+			delete(s.cache, p)
+		}
+	}
+	s.cacheMu.Unlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].CollectedAt.Before(infos[j].CollectedAt) })
+	return infos, nil
+}
+
+// path のメタデータ。前回読んだときからファイルが変わっていなければ読み直さない
+func (s *metricsServer) snapshotEntry(path string) (*snapshotCacheEntry, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheMu.Lock()
+	entry := s.cache[path]
+	s.cacheMu.Unlock()
+	if entry != nil && entry.modTime.Equal(st.ModTime()) && entry.size == st.Size() {
+		return entry, entry.err
+	}
+
+	entry = &snapshotCacheEntry{modTime: st.ModTime(), size: st.Size()}
+	if snap, err := readSnapshot(path); err != nil {
+		log.Printf("⚠️  Skipping %s: %v", path, err)
+		entry.err = err
+	} else {
+		entry.info = snapshotInfo{
+			ID:          strings.TrimSuffix(filepath.Base(path), ".db"),
+			From:        snap.From,
+			To:          snap.To,
+			CollectedAt: snap.CollectedAt,
+		}
+	}
+	s.cacheMu.Lock()
+	s.cache[path] = entry
+	s.cacheMu.Unlock()
+	return entry, entry.err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("⚠️  Failed to write response: %v", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
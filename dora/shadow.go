@@ -0,0 +1,184 @@
+package dora
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// --shadow-definitions で上書きできるフラグ（指標の定義に関わるもの）
+// 書式は --config と同じ（キーはフラグ名）
+//
+//	lead-time-unit: commit
+//	deploy:
+//	  source: deployments
+//	failure-markers: [incident]
+var shadowDefinitionKeys = map[string]bool{
+	"lead-time-unit":           true,
+	"max-commit-age":           true,
+	"clock-skew":               true,
+	"deploy-source":            true,
+	"deploy-workflow":          true,
+	"deploy-workflow-failures": true,
+	"deploy-environment":       true,
+	"release-tag-pattern":      true,
+	"gitops-repo":              true,
+	"gitops-path":              true,
+	"gitops-env-pattern":       true,
+	"gitops-tag-pattern":       true,
+	"tfc-workspaces":           true,
+	"changelog-path":           true,
+	"failure-markers":          true,
+	"failure-markers-only":     true,
+	"conventional-cfr":         true,
+	"fix-window":               true,
+	"incident-window":          true,
+	"incident-deploys":         true,
+}
+
+func loadShadowDefinitions(path string) (map[string]string, error) {
+	values, err := loadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for key := range values {
+		if !shadowDefinitionKeys[key] {
+			return nil, fmt.Errorf("%s: %q is not a metric definition (allowed: %s)", path, key, strings.Join(sortedKeys(shadowDefinitionKeys), ", "))
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s: no definitions to override", path)
+	}
+	return values, nil
+}
+
+// フラグを一時的に上書きして fn を呼び、元の値に戻す
+// fn の中でフラグが書き換えられることもある（--deploy-workflow による --deploy-source など）ので、すべてのフラグを戻す
+func withFlagOverrides(fs *flag.FlagSet, values map[string]string, fn func()) error {
+	saved := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) { saved[f.Name] = f.Value.String() })
+	defer func() {
+		fs.VisitAll(func(f *flag.Flag) {
+			if v := saved[f.Name]; f.Value.String() != v {
+				f.Value.Set(v)
+			}
+		})
+	}()
+	for _, name := range sortedKeys(values) {
+		if err := fs.Set(name, values[name]); err != nil {
+			return fmt.Errorf("shadow %s: %w", name, err)
+		}
+	}
+	fn()
+	return nil
+}
+
+// 現在の定義で求めた PR の結果をもとに、shadow の定義（b）での結果を作る
+// レビュー・取り消しの連鎖は定義によらないので引き継ぎ、失敗判定とリードタイムだけを求め直す
+func (b *analyzer) shadowResult(ctx context.Context, repoName string, index *deployIndex, pr *github.PullRequest, author, changeType string, current prResult) prResult {
+	if changeType == "" && (b.conventional || b.conventionalCFR) {
+		changeType = b.changeType(ctx, repoName, pr)
+	}
+	r, reasons := b.classifyPR(repoName, index, pr, author, changeType)
+	r.Reviews = current.Reviews
+	r.IsRevert, r.Reverts, r.RevertedAuthor, r.RevertedBy = current.IsRevert, current.Reverts, current.RevertedAuthor, current.RevertedBy
+	r.RevertedShipped = current.RevertedShipped
+	r.IsReland, r.RelandOf = current.IsReland, current.RelandOf
+	if r.IsReland && !slices.Contains(reasons, "marker") {
+		r.IsFix = false
+	}
+	if index != nil {
+		d, _ := index.FirstContaining(ctx, pr.GetMergeCommitSHA(), pr.GetMergedAt().Time)
+		if d != nil {
+			r.LeadTime = d.Time.Sub(pr.GetCreatedAt().Time)
+			r.Incidents = index.IncidentsFor(d)
+		} else {
+			r.HasLeadTime = false
+		}
+	}
+	r.CommitTimes = current.CommitTimes
+	if b.commitLeadTime && r.CommitTimes == nil {
+		r.CommitTimes, _ = b.fetchCommitTimes(ctx, repoName, pr.GetNumber())
+	}
+	return r
+}
+
+// JSON の shadow（現在の定義と並べる別定義の結果）
+type shadowSummary struct {
+	Overrides     map[string]string       `json:"overrides"`
+	DeploySource  string                  `json:"deploy_source"`
+	CFRDefinition string                  `json:"cfr_definition"`
+	LeadTimeDef   string                  `json:"lead_time_definition"`
+	Overall       statsSummary            `json:"overall"`
+	Repos         map[string]statsSummary `json:"repos"`
+}
+
+func summarizeShadow(a *analyzer) *shadowSummary {
+	b := a.shadow
+	if b == nil {
+		return nil
+	}
+	out := &shadowSummary{
+		Overrides:     a.shadowOverrides,
+		DeploySource:  "merge",
+		CFRDefinition: cfrDefinition(b.team),
+		LeadTimeDef:   b.leadTimeDefinition(),
+		Overall:       summarizeStats(b.team, b.from, b.to, b.deploys == nil),
+		Repos:         make(map[string]statsSummary),
+	}
+	if b.deploys != nil {
+		out.DeploySource = b.deploys.Name()
+	}
+	for _, name := range sortedKeys(b.repos) {
+		out.Repos[name] = summarizeStats(b.repos[name], b.from, b.to, b.deploys == nil)
+	}
+	return out
+}
+
+func printShadowComparison(a *analyzer) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🌗 Shadow definitions: %s\n%s\n", line, formatOverrides(a.shadowOverrides), line)
+	fmt.Printf("Lead time (current): %s\nLead time (shadow):  %s\n", a.leadTimeDefinition(), a.shadow.leadTimeDefinition())
+	fmt.Printf("CFR (current): %s\nCFR (shadow):  %s\n", cfrDefinition(a.team), cfrDefinition(a.shadow.team))
+	printComparedEntities(a, a.shadow, "CURRENT", "SHADOW")
+}
+
+func writeMarkdownShadow(b *strings.Builder, sum reportSummary) {
+	sh := sum.Shadow
+	fmt.Fprintf(b, "\n## 🌗 Shadow definitions\n\nOverrides: `%s`\n\n| Metric | Current | Shadow | Δ%% |\n|---|---:|---:|---:|\n", formatOverrides(sh.Overrides))
+	cur, alt := sum.Overall, sh.Overall
+	rows := []struct {
+		name     string
+		cur, alt float64
+		format   string
+	}{
+		{"Deployments", float64(cur.Deployments), float64(alt.Deployments), "%.0f"},
+		{"Median lead time", cur.MedianLeadTimeHours, alt.MedianLeadTimeHours, "%.1fh"},
+		{"P90 lead time", cur.P90LeadTimeHours, alt.P90LeadTimeHours, "%.1fh"},
+		{"Change failure rate", cur.CFRPercent, alt.CFRPercent, "%.1f%%"},
+		{"MTTR", cur.MTTRHours, alt.MTTRHours, "%.1fh"},
+	}
+	for _, r := range rows {
+		pct := "-"
+		if r.cur != 0 {
+			pct = fmt.Sprintf("%+.1f%%", (r.alt-r.cur)/math.Abs(r.cur)*100)
+		}
+		fmt.Fprintf(b, "| %s | "+r.format+" | "+r.format+" | %s |\n", r.name, r.cur, r.alt, pct)
+	}
+	fmt.Fprintf(b, "\n- Lead time (shadow): %s\n- CFR (shadow): %s\n", sh.LeadTimeDef, sh.CFRDefinition)
+}
+
+// "deploy-source=deployments, lead-time-unit=commit"
+func formatOverrides(values map[string]string) string {
+	keys := sortedKeys(values)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + values[k]
+	}
+	return strings.Join(parts, ", ")
+}
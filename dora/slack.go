@@ -0,0 +1,63 @@
+package dora
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Slack の Incoming Webhook に投稿する
+func postSlack(ctx context.Context, client *http.Client, webhook, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// 目標の評価結果を通知先ごとにまとめて送る
+func notifyTargets(ctx context.Context, client *http.Client, from, to string, reports []targetReport) error {
+	messages := make(map[string][]string) // webhook -> 本文
+	var order []string
+	for _, r := range reports {
+		if r.Webhook == "" || len(r.Results) == 0 {
+			continue
+		}
+		if _, ok := messages[r.Webhook]; !ok {
+			order = append(order, r.Webhook)
+		}
+		status := "✅ all targets met"
+		if n := failedTargets(r.Results); n > 0 {
+			status = fmt.Sprintf("❌ %d target(s) missed", n)
+		}
+		lines := []string{fmt.Sprintf("*%s* (%s): %s", r.Name, r.Kind, status)}
+		for _, res := range r.Results {
+			lines = append(lines, "• "+res.String())
+		}
+		messages[r.Webhook] = append(messages[r.Webhook], strings.Join(lines, "\n"))
+	}
+
+	for _, webhook := range order {
+		text := fmt.Sprintf("📊 DORA targets (%s - %s)\n\n%s", from, to, strings.Join(messages[webhook], "\n\n"))
+		if err := postSlack(ctx, client, webhook, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+package dora
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// collect で保存し report で再集計する収集結果（gzip 圧縮した JSON）
+// 失敗判定・リードタイムは収集時の設定で確定し、メンバー・チーム・別名の絞り込みは report 時に行う
+type snapshot struct {
+	Version      int            `json:"version"`
+	Owner        string         `json:"owner"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	CollectedAt  time.Time      `json:"collected_at"`
+	Timezone     string         `json:"timezone,omitempty"`      // 期間の日付を区切ったタイムゾーン（空なら UTC）
+	DeploySource string         `json:"deploy_source,omitempty"` // 空ならマージをデプロイとみなした
+	Environment  string         `json:"environment,omitempty"`
+	MaxPRs       int            `json:"max_prs,omitempty"`
+	RevertWindow time.Duration  `json:"revert_window"`
+	Repos        []snapshotRepo `json:"repos"`
+	PRs          []PRRecord     `json:"prs"`
+}
+
+type snapshotRepo struct {
+	Name        string       `json:"name"`
+	Population  int          `json:"population"` // サンプリング前の、絞り込みに残ったマージ済み PR 数
+	Deployments []deployment `json:"deployments,omitempty"`
+}
+
+const snapshotVersion = 1
+
+// 同じディレクトリの一時ファイルに書いてから置き換えるので、読み手が書きかけのファイルを見ることはない
+func writeSnapshot(path string, s *snapshot) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // 置き換え後は存在しないので何もしない
+	zw := gzip.NewWriter(f)
+	if err := json.NewEncoder(zw).Encode(s); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	// CreateTemp は 0600 で作るため、os.Create と同じ権限に揃える
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func readSnapshot(path string) (*snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a snapshot: %w", path, err)
+	}
+	var s snapshot
+	if err := json.NewDecoder(zr).Decode(&s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("%s: unsupported snapshot version %d", path, s.Version)
+	}
+	return &s, nil
+}
+
+// a の期間・設定を記録した空のスナップショット
+func newSnapshot(a *analyzer) *snapshot {
+	snap := &snapshot{
+		Version:      snapshotVersion,
+		Owner:        a.owner,
+		From:         a.from,
+		To:           a.to,
+		CollectedAt:  time.Now().UTC(),
+		Timezone:     reportLocation.String(),
+		Environment:  a.env,
+		MaxPRs:       a.maxPRs,
+		RevertWindow: a.revertWindow,
+	}
+	if a.deploys != nil {
+		snap.DeploySource = a.deploys.Name()
+	}
+	return snap
+}
+
+// collect サブコマンド本体。レポートに必要な情報をすべて取得して保存する
+func runCollect(ctx context.Context, a *analyzer, repos []string, out string) error {
+	snap := newSnapshot(a)
+	// report 側でどの表示を選んでも良いよう、任意の指標もすべて求めておく
+	a.conventional = true
+	a.governance = true
+	a.hygiene = true
+	a.timeline = true
+	// bot・除外ユーザーは report 時に除く（スナップショットには残す）
+	a.excludeBots, a.excludeUsers = false, nil
+	a.onRepo = func(r snapshotRepo) {
+		snap.Repos = append(snap.Repos, r)
+	}
+	collectPRRecords(ctx, a, repos, os.Stderr, func(r PRRecord) {
+		snap.PRs = append(snap.PRs, r)
+	})
+	if err := writeSnapshot(out, snap); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "📦 Collected %d PRs from %d repositories into %s\n", len(snap.PRs), len(snap.Repos), out)
+	return nil
+}
+
+// スナップショットを再集計するための analyzer（API は呼ばない）
+func newSnapshotAnalyzer(snap *snapshot, tr *tracer) *analyzer {
+	a := newAnalyzer(nil, tr, snap.Owner, snap.From, snap.To)
+	a.maxPRs = snap.MaxPRs
+	a.env = snap.Environment
+	a.revertWindow = snap.RevertWindow
+	if snap.DeploySource != "" {
+		a.deploys = snapshotSource{name: snap.DeploySource}
+	}
+	return a
+}
+
+// report 時のデプロイソース（名前だけを持ち、デプロイはスナップショットから渡す）
+type snapshotSource struct {
+	name string
+}
+
+func (s snapshotSource) Name() string {
+	return s.name
+}
+
+func (s snapshotSource) Deployments(ctx context.Context, repo string) ([]deployment, error) {
+	return nil, fmt.Errorf("deployments are read from the snapshot")
+}
+
+// スナップショットを再集計する。repos が空でなければそのリポジトリだけ
+// a の期間外にマージされた PR は数えない（--periods で 1 つのスナップショットを期間ごとに集計する）
+func (a *analyzer) replay(snap *snapshot, repos map[string]bool) {
+	from, to := a.window()
+	prs := make(map[string][]PRRecord)
+	for _, rec := range snap.PRs {
+		prs[rec.Repo] = append(prs[rec.Repo], rec)
+	}
+	for _, repo := range snap.Repos {
+		if len(repos) > 0 && !repos[repo.Name] {
+			continue
+		}
+		repoStats := &Stats{Sampled: a.maxPRs > 0 && repo.Population > a.maxPRs}
+		if a.deploys != nil {
+			a.deployStats(repo.Name, repoStats, repo.Deployments)
+		}
+		inWindow := 0
+		for _, rec := range prs[repo.Name] {
+			if rec.MergedAt.Before(from) || !rec.MergedAt.Before(to) {
+				continue
+			}
+			r := a.replayResult(rec)
+			if (len(a.members) > 0 && !a.members[r.Author]) || a.excludedUser(rec.AuthorLogin) || !a.labelsAllowed(rec.Labels) {
+				continue
+			}
+			inWindow++
+			a.checkReviewSLA(repo.Name, rec.Title, rec.URL, r)
+			a.record(repoStats, rec.MergeCommitSHA, r)
+		}
+		// 母数は収集した範囲全体・収集時の絞り込みのものなので、期間内で絞り込みに残った割合で按分する
+		// （抽出しなかった PR は記録に無いので、標本での割合を母集団にも当てはめる）
+		population := repo.Population
+		if n := len(prs[repo.Name]); n > 0 && inWindow < n {
+			population = population * inWindow / n
+		}
+		a.addRepo(repo.Name, repoStats, population)
+	}
+}
+
+// 保存された PR の生データを集計用の結果に戻す（別名・営業時間は report 時の設定で解決する）
+func (a *analyzer) replayResult(rec PRRecord) prResult {
+	author := a.aliases.canonical(rec.AuthorLogin)
+	r := prResult{
+		Repo:        rec.Repo,
+		Number:      rec.Number,
+		Title:       rec.Title,
+		URL:         rec.URL,
+		CreatedAt:   rec.CreatedAt,
+		MergedAt:    rec.MergedAt,
+		Author:      author,
+		IsFix:       rec.Failure,
+		Additions:   rec.Additions,
+		Deletions:   rec.Deletions,
+		ChangeType:  rec.ChangeType,
+		SelfMerged:  a.aliases.canonical(rec.MergedBy) == author,
+		Hygiene:     -1,
+		IsRevert:    rec.IsRevert,
+		Reverts:     rec.Reverts,
+		IsReland:    rec.IsReland,
+		RelandOf:    rec.RelandOf,
+		CommitTimes: rec.CommitTimes,
+	}
+	if rec.LeadTimeHours != nil {
+		r.HasLeadTime = true
+		r.LeadTime = time.Duration(*rec.LeadTimeHours * float64(time.Hour))
+	}
+	if len(rec.EnvDeployedAt) > 0 {
+		r.DeployLags = make(map[string]time.Duration)
+		for env, t := range rec.EnvDeployedAt {
+			r.DeployLags[env] = t.Sub(rec.MergedAt)
+		}
+	}
+	if rec.ReviewsFetched && a.needsReviews() {
+		r.Reviews = a.replayReviews(rec)
+	}
+	if rec.Hygiene != nil && a.hygiene {
+		r.Hygiene = *rec.Hygiene
+	}
+	if !a.conventional {
+		r.ChangeType = ""
+	}
+	if a.labelReport {
+		r.LabelsChecked, r.Labels = true, rec.Labels
+		r.MissingLabels = missingLabels(rec.Labels, a.requiredLabels)
+	}
+	if rec.QuickRevertOf != "" {
+		r.RevertedBy = a.aliases.canonical(rec.QuickRevertOf)
+	}
+	if rec.RevertedAuthor != "" {
+		r.RevertedAuthor = a.aliases.canonical(rec.RevertedAuthor)
+	}
+	if rec.OriginShipped != nil {
+		r.RevertedShipped = *rec.OriginShipped
+	}
+	if a.hours != nil {
+		r.AfterHours, r.Weekend = a.hours.classify(rec.MergedAt)
+	}
+	return r
+}
+
+// 保存されたレビュー状況。除外するレビュアーがいれば、レビュアーごとの最初のレビューから求め直す
+// 承認日時は誰の承認か記録していないので、そのまま使う
+func (a *analyzer) replayReviews(rec PRRecord) *reviewSummary {
+	rs := &reviewSummary{Reviewers: rec.Reviewers, Requested: rec.Requested, FirstReviewAt: rec.FirstReviewAt, ApprovedAt: rec.ApprovedAt, FirstBy: rec.ReviewerFirst}
+	if !slices.ContainsFunc(rec.Reviewers, a.excludedUser) {
+		return rs
+	}
+	rs.Reviewers, rs.FirstBy, rs.FirstReviewAt = nil, make(map[string]time.Time), nil
+	for _, login := range rec.Reviewers {
+		if a.excludedUser(login) {
+			continue
+		}
+		rs.Reviewers = append(rs.Reviewers, login)
+		if at, ok := rec.ReviewerFirst[login]; ok {
+			rs.FirstBy[login] = at
+			if rs.FirstReviewAt == nil || at.Before(*rs.FirstReviewAt) {
+				rs.FirstReviewAt = &at
+			}
+		}
+	}
+	if len(rs.Reviewers) == 0 {
+		rs.ApprovedAt = nil
+	}
+	return rs
+}
+
+// 複数のスナップショット（別々のトークン・Organization で収集したもの）を 1 つにまとめる
+// Organization が混在する場合、リポジトリ名は "owner/repo" にする
+// 同じリポジトリ・PR が複数にある場合は新しく収集したほうを使う
+func mergeSnapshots(snaps []*snapshot) (*snapshot, []string) {
+	var warnings []string
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].CollectedAt.Before(snaps[j].CollectedAt) })
+
+	owners := make(map[string]bool)
+	sources := make(map[string]bool)
+	for _, s := range snaps {
+		owners[s.Owner] = true
+		sources[s.DeploySource] = true
+	}
+	qualify := len(owners) > 1
+
+	out := &snapshot{Version: snapshotVersion, CollectedAt: time.Now().UTC()}
+	repoIndex := make(map[string]int)
+	prIndex := make(map[string]int)
+	for _, s := range snaps {
+		name := func(repo string) string {
+			if qualify && !strings.Contains(repo, "/") {
+				return s.Owner + "/" + repo
+			}
+			return repo
+		}
+		if out.From == "" || s.From < out.From {
+			out.From = s.From
+		}
+		if s.To > out.To {
+			out.To = s.To
+		}
+		if s.MaxPRs > out.MaxPRs {
+			out.MaxPRs = s.MaxPRs
+		}
+		if out.RevertWindow == 0 {
+			out.RevertWindow = s.RevertWindow
+		}
+		// 期間の日付の区切りが違うと、境界の PR が重複したり抜けたりする
+		if out.Timezone == "" {
+			out.Timezone = s.Timezone
+		} else if s.Timezone != "" && s.Timezone != out.Timezone {
+			warnings = append(warnings, fmt.Sprintf("snapshots were collected in different timezones (%s, %s); PRs near the period boundaries may be missing or counted in the wrong day", out.Timezone, s.Timezone))
+		}
+		if out.Environment == "" {
+			out.Environment = s.Environment
+		} else if s.Environment != "" && s.Environment != out.Environment {
+			warnings = append(warnings, fmt.Sprintf("snapshots target different environments (%s, %s)", out.Environment, s.Environment))
+		}
+		for _, r := range s.Repos {
+			r.Name = name(r.Name)
+			if i, ok := repoIndex[r.Name]; ok {
+				out.Repos[i] = r
+				continue
+			}
+			repoIndex[r.Name] = len(out.Repos)
+			out.Repos = append(out.Repos, r)
+		}
+		for _, pr := range s.PRs {
+			pr.Repo = name(pr.Repo)
+			key := fmt.Sprintf("%s#%d", pr.Repo, pr.Number)
+			if i, ok := prIndex[key]; ok {
+				out.PRs[i] = pr
+				continue
+			}
+			prIndex[key] = len(out.PRs)
+			out.PRs = append(out.PRs, pr)
+		}
+	}
+
+	if len(owners) == 1 {
+		out.Owner = snaps[0].Owner
+	} else {
+		list := make([]string, 0, len(owners))
+		for o := range owners {
+			list = append(list, o)
+		}
+		sort.Strings(list)
+		out.Owner = strings.Join(list, ",")
+	}
+	var names []string
+	for src := range sources {
+		if src != "" {
+			names = append(names, src)
+		}
+	}
+	sort.Strings(names)
+	out.DeploySource = strings.Join(names, "+")
+	if sources[""] && out.DeploySource != "" {
+		warnings = append(warnings, "some snapshots used merges as deployments; their repositories report no deployments in the combined view")
+	}
+	return out, warnings
+}
+
+// merge サブコマンド本体
+func runMerge(paths []string, out string) error {
+	var snaps []*snapshot
+	for _, p := range paths {
+		s, err := readSnapshot(p)
+		if err != nil {
+			return err
+		}
+		snaps = append(snaps, s)
+	}
+	merged, warnings := mergeSnapshots(snaps)
+	for _, w := range warnings {
+		log.Printf("⚠️  %s", w)
+	}
+	if err := writeSnapshot(out, merged); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "📦 Merged %d snapshots (%d repositories, %d PRs) into %s\n", len(snaps), len(merged.Repos), len(merged.PRs), out)
+	return nil
+}
@@ -0,0 +1,84 @@
+package dora
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// SAML SSO が有効な Organization で、トークンが SSO 認可されていないときの 403 を判別する
+// GitHub は "X-GitHub-SSO: required; url=https://github.com/orgs/ORG/sso?authorization_request=..." を返す
+func ssoAuthorizationURL(err error) (string, bool) {
+	var er *github.ErrorResponse
+	if !errors.As(err, &er) || er.Response == nil || er.Response.StatusCode != http.StatusForbidden {
+		return "", false
+	}
+	h := er.Response.Header.Get("X-GitHub-SSO")
+	if !strings.HasPrefix(h, "required") {
+		return "", false
+	}
+	_, url, _ := strings.Cut(h, "url=")
+	return strings.TrimSpace(url), true
+}
+
+var ssoOrgPattern = regexp.MustCompile(`/orgs/([^/]+)/sso`)
+
+// API エラーを利用者が対処できるメッセージにする
+func describeAPIError(err error, org string) string {
+	url, ok := ssoAuthorizationURL(err)
+	if !ok {
+		return err.Error()
+	}
+	// 認可 URL（/orgs/<org>/sso）に含まれる Organization を優先する（GitOps リポジトリなど別 Organization の場合）
+	if m := ssoOrgPattern.FindStringSubmatch(url); m != nil {
+		org = m[1]
+	}
+	msg := fmt.Sprintf("the token is not authorized for SAML SSO in %q", org)
+	if url != "" {
+		msg += fmt.Sprintf("; authorize it at %s", url)
+	}
+	return msg + fmt.Sprintf(" or set %s to a token authorized for this organization", tokenEnvName(org))
+}
+
+// Organization ごとのトークン（GITHUB_TOKEN_<ORG>）、無ければ GITHUB_TOKEN
+func tokenForOrg(org string) string {
+	if t := os.Getenv(tokenEnvName(org)); t != "" {
+		return t
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+func tokenEnvName(org string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, org)
+	return "GITHUB_TOKEN_" + name
+}
+
+// 一部の Organization が SSO 未認可のため検索結果が欠けている場合に一度だけ警告する
+func ssoPartialResultsHook() func(*http.Response, error) {
+	var once sync.Once
+	return func(resp *http.Response, err error) {
+		if err != nil || resp == nil {
+			return
+		}
+		if strings.HasPrefix(resp.Header.Get("X-GitHub-SSO"), "partial-results") {
+			once.Do(func() {
+				log.Printf("⚠️  Some results were omitted because the token is not SAML SSO-authorized for every organization")
+			})
+		}
+	}
+}
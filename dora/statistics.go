@@ -0,0 +1,56 @@
+package dora
+
+import (
+	"math"
+	"time"
+)
+
+// 指標の計算で共有する統計の関数。分位点・切り詰め平均はサンプルを保持しない t-digest（tdigest.go）で求め、
+// ここには件数の少ない整列済みの列に使う分位点と、平均・割合の信頼区間を置く
+
+// 正規近似の 95% 信頼区間の係数
+const z95 = 1.96
+
+// 切り詰め平均で上下それぞれから除く割合
+const trimFraction = 0.1
+
+// 整列済みの列の分位点（最近接順位法。補間しないので値は必ず列の要素になる）
+func sortedQuantile[T int | time.Duration](sorted []T, q float64) T {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// 有限母集団修正（抽出率が高いほど区間が狭くなる）
+func finitePopulationCorrection(n, population int) float64 {
+	if population <= 1 || n >= population {
+		return 0
+	}
+	return math.Sqrt(float64(population-n) / float64(population-1))
+}
+
+// 平均の 95% 信頼区間（n 件の合計と二乗和から求める。下限は 0 で切る）
+func meanCI(n int, sum, sqSum, fpc float64) (lo, hi float64) {
+	if n == 0 {
+		return 0, 0
+	}
+	mean := sum / float64(n)
+	if n < 2 {
+		return mean, mean
+	}
+	variance := (sqSum - float64(n)*mean*mean) / float64(n-1)
+	margin := z95 * math.Sqrt(math.Max(variance, 0)/float64(n)) * fpc
+	return math.Max(mean-margin, 0), mean + margin
+}
+
+// 割合（0〜1）の 95% 信頼区間（正規近似）
+func proportionCI(k, n int, fpc float64) (lo, hi float64) {
+	if n == 0 {
+		return 0, 0
+	}
+	p := float64(k) / float64(n)
+	margin := z95 * math.Sqrt(p*(1-p)/float64(n)) * fpc
+	return math.Max(p-margin, 0), math.Min(p+margin, 1)
+}
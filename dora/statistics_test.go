@@ -0,0 +1,121 @@
+package dora
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSortedQuantile(t *testing.T) {
+	ints := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for _, tc := range []struct {
+		q    float64
+		want int
+	}{
+		{0, 1},
+		{0.1, 1},
+		{0.5, 5},
+		{0.75, 8},
+		{0.9, 9},
+		{0.95, 10},
+		{1, 10},
+	} {
+		if got := sortedQuantile(ints, tc.q); got != tc.want {
+			t.Errorf("sortedQuantile(1..10, %v) = %d, want %d", tc.q, got, tc.want)
+		}
+	}
+
+	durations := []time.Duration{time.Hour, 2 * time.Hour, 30 * time.Hour}
+	for _, tc := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.5, 2 * time.Hour},
+		{0.9, 30 * time.Hour},
+	} {
+		if got := sortedQuantile(durations, tc.q); got != tc.want {
+			t.Errorf("sortedQuantile(durations, %v) = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+
+	if got := sortedQuantile([]int{}, 0.5); got != 0 {
+		t.Errorf("sortedQuantile(empty) = %d, want 0", got)
+	}
+	if got := sortedQuantile([]int{42}, 0.9); got != 42 {
+		t.Errorf("sortedQuantile([42], 0.9) = %d, want 42", got)
+	}
+}
+
+func TestFinitePopulationCorrection(t *testing.T) {
+	for _, tc := range []struct {
+		n, population int
+		want          float64
+	}{
+		{100, 100, 0}, // 全数調査なので誤差なし
+		{120, 100, 0}, // 母集団より多い場合も全数とみなす
+		{1, 1, 0},
+		{10, 0, 0}, // 母集団が不明
+		{50, 101, math.Sqrt(51.0 / 100)},
+		{1, 1000001, math.Sqrt(1000000.0 / 1000000)},
+	} {
+		if got := finitePopulationCorrection(tc.n, tc.population); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("finitePopulationCorrection(%d, %d) = %v, want %v", tc.n, tc.population, got, tc.want)
+		}
+	}
+}
+
+func TestMeanCI(t *testing.T) {
+	// 2, 4, 4, 4, 5, 5, 7, 9: 平均 5、標本分散 32/7
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	sum, sqSum := 0.0, 0.0
+	for _, v := range values {
+		sum += v
+		sqSum += v * v
+	}
+	margin := z95 * math.Sqrt(32.0/7/8)
+
+	for _, tc := range []struct {
+		name           string
+		n              int
+		sum, sqSum     float64
+		fpc            float64
+		wantLo, wantHi float64
+	}{
+		{"known dataset", len(values), sum, sqSum, 1, 5 - margin, 5 + margin},
+		{"half corrected", len(values), sum, sqSum, 0.5, 5 - margin/2, 5 + margin/2},
+		{"whole population", len(values), sum, sqSum, finitePopulationCorrection(8, 8), 5, 5},
+		{"single sample", 1, 3.5, 12.25, 1, 3.5, 3.5},
+		{"empty", 0, 0, 0, 1, 0, 0},
+		{"lower bound clipped at zero", 2, 1, 1, 1, 0, 0.5 + z95*math.Sqrt(0.5/2)},
+	} {
+		lo, hi := meanCI(tc.n, tc.sum, tc.sqSum, tc.fpc)
+		if math.Abs(lo-tc.wantLo) > 1e-9 || math.Abs(hi-tc.wantHi) > 1e-9 {
+			t.Errorf("%s: meanCI = (%v, %v), want (%v, %v)", tc.name, lo, hi, tc.wantLo, tc.wantHi)
+		}
+	}
+}
+
+func TestProportionCI(t *testing.T) {
+	margin := z95 * math.Sqrt(0.2*0.8/50)
+	for _, tc := range []struct {
+		name           string
+		k, n           int
+		fpc            float64
+		wantLo, wantHi float64
+	}{
+		{"k=0", 0, 40, 1, 0, 0},
+		{"k=n", 40, 40, 1, 1, 1},
+		{"known proportion", 10, 50, 1, 0.2 - margin, 0.2 + margin},
+		{"whole population", 10, 50, finitePopulationCorrection(50, 50), 0.2, 0.2},
+		{"clipped to [0, 1]", 1, 3, 1, 0, 1.0/3 + z95*math.Sqrt(1.0/3*2/3/3)},
+		{"empty", 0, 0, 1, 0, 0},
+	} {
+		lo, hi := proportionCI(tc.k, tc.n, tc.fpc)
+		if math.Abs(lo-tc.wantLo) > 1e-9 || math.Abs(hi-tc.wantHi) > 1e-9 {
+			t.Errorf("%s: proportionCI(%d, %d) = (%v, %v), want (%v, %v)", tc.name, tc.k, tc.n, lo, hi, tc.wantLo, tc.wantHi)
+		}
+		if lo < 0 || hi > 1 || lo > hi {
+			t.Errorf("%s: proportionCI(%d, %d) = (%v, %v) is outside [0, 1]", tc.name, tc.k, tc.n, lo, hi)
+		}
+	}
+}
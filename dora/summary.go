@@ -0,0 +1,205 @@
+package dora
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// 機械可読な集計結果（API サーバー・JSON 出力用）
+type reportSummary struct {
+	Owner         string                    `json:"owner"`
+	From          string                    `json:"from"`
+	To            string                    `json:"to"`
+	DeploySource  string                    `json:"deploy_source"`
+	CFRDefinition string                    `json:"cfr_definition"`
+	LeadTimeDef   string                    `json:"lead_time_definition"`
+	MTTRDef       string                    `json:"mttr_definition"`
+	Definitions   reportDefinitions         `json:"definitions"`
+	Overall       statsSummary              `json:"overall"`
+	Repos         map[string]statsSummary   `json:"repos"`
+	Members       map[string]statsSummary   `json:"members"`
+	MembersRel    map[string]memberRelative `json:"members_relative,omitempty"` // --member-view relative（members は空になる）
+	Teams         map[string]statsSummary   `json:"teams,omitempty"`
+	Duplicates    int                       `json:"duplicates,omitempty"`
+	Carryover     *carryoverSummary         `json:"carryover,omitempty"`
+	PullRequests  *prLinks                  `json:"pull_requests,omitempty"` // 指標に効いた PR（タイトルと URL）
+	Insights      []insight                 `json:"insights,omitempty"`      // --insights
+	Shadow        *shadowSummary            `json:"shadow,omitempty"`        // --shadow-definitions
+}
+
+type statsSummary struct {
+	MergedPRs              int                `json:"merged_prs"`
+	FeaturePRs             int                `json:"feature_prs"`
+	FailurePRs             int                `json:"failure_prs"`
+	AvgLeadTimeHours       float64            `json:"avg_lead_time_hours"`
+	MedianLeadTimeHours    float64            `json:"median_lead_time_hours"`
+	P90LeadTimeHours       float64            `json:"p90_lead_time_hours"`
+	LeadTimePercentiles    map[string]float64 `json:"lead_time_percentiles_hours,omitempty"`    // --percentiles（"p75" など）
+	FirstReviewPercentiles map[string]float64 `json:"first_review_percentiles_hours,omitempty"` // 作成→最初のレビュー。レビューを取得した場合のみ
+	TrimmedLeadTimeHours   float64            `json:"trimmed_mean_lead_time_hours"`             // 上下 10% を除いた平均
+	LeadTimeCIHours        *[2]float64        `json:"lead_time_ci_hours,omitempty"`             // 平均の 95% 信頼区間（--max-prs の抽出時）
+	CFRCIPercent           *[2]float64        `json:"cfr_ci_percent,omitempty"`
+	CFRPercent             float64            `json:"cfr_percent"`
+	AvgAdditions           float64            `json:"avg_additions"`
+	Deployments            int                `json:"deployments"`
+	DeploymentsPerDay      float64            `json:"deployments_per_day"`
+	FailedDeployments      int                `json:"failed_deployments"`
+	Rollbacks              int                `json:"rollbacks"`
+	QuickRevertPercent     float64            `json:"quick_revert_percent"`
+	UnreviewedPercent      float64            `json:"unreviewed_percent"`
+	SelfMergedPercent      float64            `json:"self_merged_percent"`
+	AvgHygieneScore        float64            `json:"avg_hygiene_score,omitempty"`
+	AfterHoursMergeCount   int                `json:"after_hours_merges,omitempty"`
+	WeekendMergeCount      int                `json:"weekend_merges,omitempty"`
+	Population             int                `json:"population,omitempty"`
+	Incidents              int                `json:"incidents,omitempty"`
+	MTTRHours              float64            `json:"mttr_hours,omitempty"`
+	MedianTTRHours         float64            `json:"median_ttr_hours,omitempty"`
+	ClockSkewedPRs         int                `json:"clock_skewed_prs,omitempty"`
+	DeployFrequency        string             `json:"deploy_frequency_bucket,omitempty"`
+	DeployGapMedianHours   float64            `json:"deploy_gap_median_hours,omitempty"`
+	DeployGapP90Hours      float64            `json:"deploy_gap_p90_hours,omitempty"`
+	LongestDeployGap       *deployGapSummary  `json:"longest_deploy_gap,omitempty"`
+	Labels                 map[string]int     `json:"labels,omitempty"`
+	UnlabeledPRs           int                `json:"unlabeled_prs,omitempty"`
+	MissingLabelPRs        int                `json:"missing_required_labels_prs,omitempty"`
+	LeadTimeHistogram      []histogramBucket  `json:"lead_time_histogram,omitempty"`    // --histogram
+	FirstReviewHistogram   []histogramBucket  `json:"first_review_histogram,omitempty"` // --histogram（レビューを取得した場合のみ）
+	Tiers                  *tierSummary       `json:"tiers,omitempty"`                  // --benchmark
+}
+
+// 最長のデプロイ間隔と、その前後のデプロイ日時
+type deployGapSummary struct {
+	Hours float64   `json:"hours"`
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+}
+
+// merged が true ならマージをデプロイとみなす（デプロイソース無し）
+func summarizeStats(s *Stats, from, to string, merged bool) statsSummary {
+	days := periodDays(from, to)
+	out := statsSummary{
+		MergedPRs:              s.TotalPRs,
+		FeaturePRs:             s.FeaturePRs,
+		FailurePRs:             s.BugFixPRs,
+		AvgLeadTimeHours:       s.AvgLeadTimeHours(),
+		MedianLeadTimeHours:    s.LeadTimeQuantile(0.5),
+		P90LeadTimeHours:       s.LeadTimeQuantile(0.9),
+		TrimmedLeadTimeHours:   s.TrimmedLeadTimeHours(),
+		LeadTimePercentiles:    digestPercentiles(s.LeadTimes),
+		FirstReviewPercentiles: digestPercentiles(s.Pickups),
+		CFRPercent:             s.CFR(),
+		Deployments:            s.Deployments,
+		DeploymentsPerDay:      float64(s.Deployments) / days,
+		FailedDeployments:      s.FailedDeployments,
+		Rollbacks:              s.Rollbacks,
+		QuickRevertPercent:     s.QuickRevertRate(),
+		UnreviewedPercent:      s.UnreviewedRate(),
+		SelfMergedPercent:      s.SelfMergeRate(),
+		AvgHygieneScore:        s.AvgHygiene(),
+		AfterHoursMergeCount:   s.AfterHoursMerges,
+		WeekendMergeCount:      s.WeekendMerges,
+		Population:             s.Population,
+		Incidents:              s.Incidents,
+		MTTRHours:              s.MTTRHours(),
+		MedianTTRHours:         s.MedianTTRHours(),
+		ClockSkewedPRs:         s.ClockSkewedPRs,
+		DeployFrequency:        deployFrequencyBucket(s, from, to),
+		Labels:                 s.LabelCounts,
+		UnlabeledPRs:           s.UnlabeledPRs,
+		MissingLabelPRs:        s.MissingLabelPRs,
+	}
+	if g := s.deployGapStats(); g.Count > 0 {
+		out.DeployGapMedianHours = g.Median.Hours()
+		out.DeployGapP90Hours = g.P90.Hours()
+		out.LongestDeployGap = &deployGapSummary{Hours: g.Longest.Hours(), From: g.LongestFrom, To: g.LongestTo}
+	}
+	if merged {
+		out.Deployments = s.MergeDeploys()
+		out.DeploymentsPerDay = float64(s.MergeDeploys()) / days
+	}
+	if s.TotalPRs > 0 {
+		out.AvgAdditions = float64(s.TotalAdditions) / float64(s.TotalPRs)
+	}
+	// 抽出した場合だけ、母集団の値の信頼区間を付ける
+	if s.Population > s.TotalPRs {
+		lo, hi := leadTimeCI(s)
+		out.LeadTimeCIHours = &[2]float64{lo, hi}
+		lo, hi = cfrCI(s)
+		out.CFRCIPercent = &[2]float64{lo, hi}
+	}
+	return out
+}
+
+func summarize(a *analyzer) reportSummary {
+	out := reportSummary{
+		Owner:         a.owner,
+		From:          a.from,
+		To:            a.to,
+		DeploySource:  "merge",
+		CFRDefinition: cfrDefinition(a.team),
+		LeadTimeDef:   a.leadTimeDefinition(),
+		MTTRDef:       mttrDefinition(),
+		Definitions:   a.definitions(),
+		Overall:       summarizeStats(a.team, a.from, a.to, a.deploys == nil),
+		Repos:         make(map[string]statsSummary),
+		Members:       make(map[string]statsSummary),
+		Duplicates:    a.duplicates,
+		Carryover:     a.summarizeCarryover(),
+		PullRequests:  a.prLinks(),
+	}
+	if a.deploys != nil {
+		out.DeploySource = a.deploys.Name()
+	}
+	if a.insights {
+		out.Insights = buildInsights(a)
+	}
+	out.Shadow = summarizeShadow(a)
+	for name, s := range a.repos {
+		out.Repos[name] = summarizeStats(s, a.from, a.to, a.deploys == nil)
+	}
+	if a.histogram {
+		out.Overall.LeadTimeHistogram, out.Overall.FirstReviewHistogram = digestHistogram(a.team.LeadTimes), digestHistogram(a.team.Pickups)
+		for name, s := range a.repos {
+			rs := out.Repos[name]
+			rs.LeadTimeHistogram, rs.FirstReviewHistogram = digestHistogram(s.LeadTimes), digestHistogram(s.Pickups)
+			out.Repos[name] = rs
+		}
+	}
+	if a.benchmark {
+		out.Overall.Tiers = classifyTiers(a.team, out.Overall)
+		for name, s := range a.repos {
+			rs := out.Repos[name]
+			rs.Tiers = classifyTiers(s, rs)
+			out.Repos[name] = rs
+		}
+	}
+	for name, s := range a.users {
+		if a.memberView == memberViewRelative {
+			if out.MembersRel == nil {
+				out.MembersRel = make(map[string]memberRelative)
+			}
+			out.MembersRel[name] = summarizeRelative(s, a.team)
+			continue
+		}
+		out.Members[name] = summarizeStats(s, a.from, a.to, a.deploys == nil)
+	}
+	if len(a.teamStats) > 0 {
+		out.Teams = make(map[string]statsSummary)
+		for name, s := range a.teamStats {
+			ts := summarizeStats(s, a.from, a.to, a.deploys == nil)
+			ts.Deployments = teamDeployments(s, a.deploys == nil)
+			ts.DeploymentsPerDay = float64(ts.Deployments) / periodDays(a.from, a.to)
+			out.Teams[name] = ts
+		}
+	}
+	return out
+}
+
+// --format json 用。API サーバーの /api/v1/metrics と同じ構造
+func writeJSONReport(w io.Writer, a *analyzer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(summarize(a))
+}
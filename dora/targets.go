@@ -0,0 +1,166 @@
+package dora
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SLO 目標値（未設定の項目は評価しない）
+type targets struct {
+	MaxAvgLeadTimeHours    *float64 `yaml:"max_avg_lead_time_hours"`
+	MaxMedianLeadTimeHours *float64 `yaml:"max_median_lead_time_hours"`
+	MaxCFRPercent          *float64 `yaml:"max_cfr_percent"`
+	MinDeploysPerWeek      *float64 `yaml:"min_deploys_per_week"`
+}
+
+// チーム個別の目標で全体の目標を上書きする
+func (t targets) merge(over targets) targets {
+	if over.MaxAvgLeadTimeHours != nil {
+		t.MaxAvgLeadTimeHours = over.MaxAvgLeadTimeHours
+	}
+	if over.MaxMedianLeadTimeHours != nil {
+		t.MaxMedianLeadTimeHours = over.MaxMedianLeadTimeHours
+	}
+	if over.MaxCFRPercent != nil {
+		t.MaxCFRPercent = over.MaxCFRPercent
+	}
+	if over.MinDeploysPerWeek != nil {
+		t.MinDeploysPerWeek = over.MinDeploysPerWeek
+	}
+	return t
+}
+
+func (t targets) empty() bool {
+	return t == targets{}
+}
+
+type targetResult struct {
+	Metric string
+	Target float64
+	Actual float64
+	Max    bool // true: 上限（Actual <= Target で合格）、false: 下限
+	Pass   bool
+}
+
+func (r targetResult) String() string {
+	op := "≥"
+	if r.Max {
+		op = "≤"
+	}
+	status := "✅ PASS"
+	if !r.Pass {
+		status = "❌ FAIL"
+	}
+	return fmt.Sprintf("%s  %-22s %8.1f (target %s %.1f)", status, r.Metric, r.Actual, op, r.Target)
+}
+
+// 目標に対する評価結果を返す。deploys はその単位でのデプロイ数
+func evaluateTargets(s *Stats, t targets, deploys int, days float64) []targetResult {
+	var results []targetResult
+	check := func(metric string, target *float64, actual float64, max bool) {
+		if target == nil {
+			return
+		}
+		pass := actual >= *target
+		if max {
+			pass = actual <= *target
+		}
+		results = append(results, targetResult{Metric: metric, Target: *target, Actual: actual, Max: max, Pass: pass})
+	}
+	check("Avg lead time (h)", t.MaxAvgLeadTimeHours, s.AvgLeadTimeHours(), true)
+	check("Median lead time (h)", t.MaxMedianLeadTimeHours, s.LeadTimeQuantile(0.5), true)
+	check("CFR (%)", t.MaxCFRPercent, s.CFR(), true)
+	check("Deploys per week", t.MinDeploysPerWeek, float64(deploys)/days*7, false)
+	return results
+}
+
+func failedTargets(results []targetResult) int {
+	n := 0
+	for _, r := range results {
+		if !r.Pass {
+			n++
+		}
+	}
+	return n
+}
+
+// 単位（全体・リポジトリ・チーム）ごとの評価結果
+type targetReport struct {
+	Kind    string // "overall" / "repo" / "team"
+	Name    string
+	Webhook string
+	Results []targetResult
+}
+
+func printTargetSummary(reports []targetReport) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🎯 Targets\n%s\n", line, line)
+	sort.SliceStable(reports, func(i, j int) bool { return kindOrder(reports[i].Kind) < kindOrder(reports[j].Kind) })
+	for _, r := range reports {
+		if len(r.Results) == 0 {
+			continue
+		}
+		fmt.Printf("[%s] %s\n", r.Kind, r.Name)
+		for _, res := range r.Results {
+			fmt.Printf("   %s\n", res)
+		}
+	}
+}
+
+func kindOrder(kind string) int {
+	switch kind {
+	case "overall":
+		return 0
+	case "repo":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// 全体・リポジトリは全体の目標、チームは全体の目標をチーム個別の目標で上書きして評価する
+func buildTargetReports(a *analyzer, f *teamsFile, days float64) []targetReport {
+	// デプロイソースが無い場合はマージ数をデプロイ数とみなす
+	deploys := func(s *Stats, team bool) int {
+		switch {
+		case a.deploys == nil:
+			return s.MergeDeploys()
+		case team:
+			return s.LeadTimeCount // チームのデプロイ数は「デプロイに載った PR 数」
+		default:
+			return s.Deployments
+		}
+	}
+
+	var reports []targetReport
+	if !f.Targets.empty() {
+		reports = append(reports, targetReport{
+			Kind: "overall", Name: "OVERALL TEAM", Webhook: f.SlackWebhook,
+			Results: evaluateTargets(a.team, f.Targets, deploys(a.team, false), days),
+		})
+		for _, name := range sortedKeys(a.repos) {
+			s := a.repos[name]
+			reports = append(reports, targetReport{
+				Kind: "repo", Name: name, Webhook: f.SlackWebhook,
+				Results: evaluateTargets(s, f.Targets, deploys(s, false), days),
+			})
+		}
+	}
+	for _, t := range f.Teams {
+		tg := f.Targets.merge(t.Targets)
+		s := a.teamStats[t.Name]
+		if s == nil || tg.empty() {
+			continue
+		}
+		webhook := t.SlackWebhook
+		if webhook == "" {
+			webhook = f.SlackWebhook
+		}
+		reports = append(reports, targetReport{
+			Kind: "team", Name: t.Name, Webhook: webhook,
+			Results: evaluateTargets(s, tg, deploys(s, true), days),
+		})
+	}
+	return reports
+}
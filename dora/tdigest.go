@@ -0,0 +1,156 @@
+package dora
+
+import (
+	"math"
+	"sort"
+)
+
+// 分位点をオンラインで近似する merging t-digest
+// 全サンプルを保持せずに中央値や p90 を求めるために使う
+type tdigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min, max    float64
+}
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+const defaultCompression = 100
+
+func newTDigest() *tdigest {
+	return &tdigest{compression: defaultCompression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+func (d *tdigest) Add(x float64) {
+	d.addWeighted(x, 1)
+}
+
+func (d *tdigest) addWeighted(x, w float64) {
+	d.buffer = append(d.buffer, centroid{mean: x, weight: w})
+	d.count += w
+	d.min = math.Min(d.min, x)
+	d.max = math.Max(d.max, x)
+	if len(d.buffer) >= int(d.compression)*5 {
+		d.compress()
+	}
+}
+
+// Merge は別の digest の内容を取り込む（リポジトリ横断の集計用）
+func (d *tdigest) Merge(o *tdigest) {
+	if o == nil {
+		return
+	}
+	o.compress()
+	for _, c := range o.centroids {
+		d.buffer = append(d.buffer, c)
+		d.count += c.weight
+	}
+	if o.count > 0 {
+		d.min = math.Min(d.min, o.min)
+		d.max = math.Max(d.max, o.max)
+	}
+	d.compress()
+}
+
+func (d *tdigest) Count() int {
+	return int(d.count)
+}
+
+func (d *tdigest) compress() {
+	if len(d.buffer) == 0 {
+		return
+	}
+	all := append(d.centroids, d.buffer...)
+	d.buffer = d.buffer[:0]
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(all))
+	cur := all[0]
+	soFar := 0.0
+	for _, c := range all[1:] {
+		proposed := cur.weight + c.weight
+		q := (soFar + proposed/2) / d.count
+		limit := 4 * d.count * q * (1 - q) / d.compression
+		if proposed <= math.Max(1, limit) {
+			cur.mean += (c.mean - cur.mean) * c.weight / proposed
+			cur.weight = proposed
+			continue
+		}
+		merged = append(merged, cur)
+		soFar += cur.weight
+		cur = c
+	}
+	d.centroids = append(merged, cur)
+}
+
+func (d *tdigest) Quantile(q float64) float64 {
+	d.compress()
+	if len(d.centroids) == 0 {
+		return 0
+	}
+	if len(d.centroids) == 1 || q <= 0 {
+		if q <= 0 {
+			return d.min
+		}
+		return d.centroids[0].mean
+	}
+	if q >= 1 {
+		return d.max
+	}
+
+	target := q * d.count
+	first := d.centroids[0]
+	if target < first.weight/2 {
+		return d.min + (first.mean-d.min)*target/(first.weight/2)
+	}
+	cum := 0.0
+	for i := 0; i < len(d.centroids)-1; i++ {
+		a, b := d.centroids[i], d.centroids[i+1]
+		left := cum + a.weight/2
+		right := cum + a.weight + b.weight/2
+		if target < right {
+			return a.mean + (b.mean-a.mean)*(target-left)/(right-left)
+		}
+		cum += a.weight
+	}
+	last := d.centroids[len(d.centroids)-1]
+	tail := d.count - last.weight/2
+	if d.count == tail {
+		return last.mean
+	}
+	return last.mean + (d.max-last.mean)*(target-tail)/(d.count-tail)
+}
+
+// 分位点 lo〜hi の間にあるサンプルの平均（切り詰め平均）。境界にかかる centroid は重なる分だけ数える
+func (d *tdigest) TrimmedMean(lo, hi float64) float64 {
+	d.compress()
+	from, to := lo*d.count, hi*d.count
+	sum, weight, cum := 0.0, 0.0, 0.0
+	for _, c := range d.centroids {
+		if w := math.Min(cum+c.weight, to) - math.Max(cum, from); w > 0 {
+			sum += c.mean * w
+			weight += w
+		}
+		cum += c.weight
+	}
+	if weight == 0 {
+		return 0
+	}
+	return sum / weight
+}
+
+// bounds で区切った区間ごとの重み（HTML のヒストグラム用）。centroid 単位なので境界付近は近似
+func (d *tdigest) histogram(bounds []float64) []float64 {
+	d.compress()
+	out := make([]float64, len(bounds)+1)
+	for _, c := range d.centroids {
+		// 境界ちょうどの値は上の区間（"<1h" に 1h を含めない）
+		out[sort.Search(len(bounds), func(i int) bool { return bounds[i] > c.mean })] += c.weight
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package dora
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// リードタイムに近い右に裾の長い分布（対数正規、中央値 24 時間）を固定のシードで作る
+func seededDurations(n int) []float64 {
+	rng := rand.New(rand.NewSource(1))
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = 24 * math.Exp(rng.NormFloat64())
+	}
+	return out
+}
+
+// 整列済みの列の分位点（sortedQuantile と同じ最近接順位法）
+func exactQuantile(sorted []float64, q float64) float64 {
+	i := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// 整列済みの列で値 x 以下の割合
+func rankOf(sorted []float64, x float64) float64 {
+	return float64(sort.SearchFloat64s(sorted, x)) / float64(len(sorted))
+}
+
+func TestTDigestQuantile(t *testing.T) {
+	values := seededDurations(10000)
+	d := newTDigest()
+	for _, v := range values {
+		d.Add(v)
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	if got := d.Count(); got != len(values) {
+		t.Fatalf("Count() = %d, want %d", got, len(values))
+	}
+	for _, tc := range []struct {
+		q       float64
+		rankTol float64 // 推定値の順位と q の差の許容範囲
+	}{
+		{0.01, 0.002},
+		{0.1, 0.005},
+		{0.25, 0.01},
+		{0.5, 0.01},
+		{0.75, 0.01},
+		{0.9, 0.005},
+		{0.95, 0.003},
+		{0.99, 0.002},
+	} {
+		got := d.Quantile(tc.q)
+		exact := exactQuantile(sorted, tc.q)
+		if r := rankOf(sorted, got); math.Abs(r-tc.q) > tc.rankTol {
+			t.Errorf("Quantile(%v) = %.3f (rank %.4f), exact %.3f", tc.q, got, r, exact)
+		}
+		if math.Abs(got-exact)/exact > 0.05 {
+			t.Errorf("Quantile(%v) = %.3f, more than 5%% from exact %.3f", tc.q, got, exact)
+		}
+	}
+	if got := d.Quantile(0); got != sorted[0] {
+		t.Errorf("Quantile(0) = %v, want min %v", got, sorted[0])
+	}
+	if got := d.Quantile(1); got != sorted[len(sorted)-1] {
+		t.Errorf("Quantile(1) = %v, want max %v", got, sorted[len(sorted)-1])
+	}
+}
+
+func TestTDigestTrimmedMean(t *testing.T) {
+	values := seededDurations(10000)
+	d := newTDigest()
+	for _, v := range values {
+		d.Add(v)
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	for _, tc := range []struct{ lo, hi float64 }{
+		{0, 1},
+		{trimFraction, 1 - trimFraction},
+		{0.25, 0.75},
+	} {
+		from, to := int(tc.lo*float64(len(sorted))), int(tc.hi*float64(len(sorted)))
+		exact := 0.0
+		for _, v := range sorted[from:to] {
+			exact += v
+		}
+		exact /= float64(to - from)
+		if got := d.TrimmedMean(tc.lo, tc.hi); math.Abs(got-exact)/exact > 0.01 {
+			t.Errorf("TrimmedMean(%v, %v) = %.3f, want %.3f ± 1%%", tc.lo, tc.hi, got, exact)
+		}
+	}
+}
+
+func TestTDigestSmall(t *testing.T) {
+	empty := newTDigest()
+	if got := empty.Quantile(0.5); got != 0 {
+		t.Errorf("empty Quantile(0.5) = %v, want 0", got)
+	}
+	if got := empty.TrimmedMean(0.1, 0.9); got != 0 {
+		t.Errorf("empty TrimmedMean = %v, want 0", got)
+	}
+
+	one := newTDigest()
+	one.Add(7)
+	for _, q := range []float64{0, 0.5, 0.9, 1} {
+		if got := one.Quantile(q); got != 7 {
+			t.Errorf("single value Quantile(%v) = %v, want 7", q, got)
+		}
+	}
+
+	// 件数が圧縮の上限より少なければ centroid は 1 件ずつなので中央値は正確
+	d := newTDigest()
+	for _, v := range []float64{5, 1, 4, 2, 3} {
+		d.Add(v)
+	}
+	if got := d.Quantile(0.5); got != 3 {
+		t.Errorf("Quantile(0.5) of 1..5 = %v, want 3", got)
+	}
+	if got := d.TrimmedMean(0.2, 0.8); got != 3 {
+		t.Errorf("TrimmedMean(0.2, 0.8) of 1..5 = %v, want 3", got)
+	}
+}
+
+func TestTDigestMerge(t *testing.T) {
+	values := seededDurations(10000)
+	whole, a, b := newTDigest(), newTDigest(), newTDigest()
+	for i, v := range values {
+		whole.Add(v)
+		if i%2 == 0 {
+			a.Add(v)
+		} else {
+			b.Add(v)
+		}
+	}
+	a.Merge(b)
+	a.Merge(nil)
+	if a.Count() != whole.Count() {
+		t.Fatalf("merged Count() = %d, want %d", a.Count(), whole.Count())
+	}
+	for _, q := range []float64{0.5, 0.9} {
+		if got, want := a.Quantile(q), whole.Quantile(q); math.Abs(got-want)/want > 0.02 {
+			t.Errorf("merged Quantile(%v) = %.3f, want %.3f ± 2%%", q, got, want)
+		}
+	}
+}
+
+func TestTDigestHistogram(t *testing.T) {
+	d := newTDigest()
+	for _, v := range []float64{0.5, 1, 3, 4, 100} {
+		d.Add(v)
+	}
+	got := d.histogram([]float64{1, 4})
+	want := []float64{1, 2, 2} // 境界ちょうどの値は上の区間
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("histogram = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package dora
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// チーム定義ファイル（メンバー・目標値・通知先）
+//
+//	targets:            # 全体・リポジトリ単位の目標（任意）
+//	  max_cfr_percent: 15
+//	teams:
+//	  - name: backend
+//	    members: [alice, bob]
+//	    slack_webhook: ${BACKEND_SLACK_WEBHOOK}
+//	    targets:
+//	      max_median_lead_time_hours: 24
+type teamsFile struct {
+	Targets      targets      `yaml:"targets"`
+	SlackWebhook string       `yaml:"slack_webhook"`
+	Teams        []teamConfig `yaml:"teams"`
+}
+
+type teamConfig struct {
+	Name         string   `yaml:"name"`
+	Members      []string `yaml:"members"`
+	SlackWebhook string   `yaml:"slack_webhook"`
+	Targets      targets  `yaml:"targets"`
+}
+
+func loadTeamsFile(path string) (*teamsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseTeamsCSV(path, data)
+	}
+	var f teamsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	// Webhook URL は秘密情報なので環境変数で渡せるようにする
+	f.SlackWebhook = os.ExpandEnv(f.SlackWebhook)
+	for i := range f.Teams {
+		t := &f.Teams[i]
+		if t.Name == "" {
+			return nil, fmt.Errorf("%s: team #%d has no name", path, i+1)
+		}
+		t.SlackWebhook = os.ExpandEnv(t.SlackWebhook)
+		for j, m := range t.Members {
+			t.Members[j] = strings.TrimSpace(m)
+		}
+	}
+	return &f, nil
+}
+
+// CSV のチーム割り当て（1 行に "ユーザー名,チーム名"、複数のチームに属する場合は行を分ける）
+// 先頭行が username,team などの見出しなら読み飛ばす。目標値・通知先は YAML でのみ指定できる
+func parseTeamsCSV(path string, data []byte) (*teamsFile, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	f := &teamsFile{}
+	index := make(map[string]int)
+	for i, row := range rows {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("%s:%d: want username,team", path, i+1)
+		}
+		user, team := strings.TrimSpace(row[0]), strings.TrimSpace(row[1])
+		if i == 0 && strings.EqualFold(team, "team") {
+			continue
+		}
+		if user == "" || team == "" {
+			return nil, fmt.Errorf("%s:%d: empty username or team", path, i+1)
+		}
+		j, ok := index[team]
+		if !ok {
+			j = len(f.Teams)
+			index[team] = j
+			f.Teams = append(f.Teams, teamConfig{Name: team})
+		}
+		f.Teams[j].Members = append(f.Teams[j].Members, user)
+	}
+	return f, nil
+}
+
+// チームのデプロイ数。チームはデプロイを持たないので、デプロイに載った PR 数（マージをデプロイとみなす場合はマージ数）
+func teamDeployments(s *Stats, merged bool) int {
+	if merged {
+		return s.MergeDeploys()
+	}
+	return s.LeadTimeCount
+}
+
+// メンバー -> 所属チーム（複数可）
+func (f *teamsFile) membership() map[string][]string {
+	m := make(map[string][]string)
+	for _, t := range f.Teams {
+		for _, member := range t.Members {
+			m[member] = append(m[member], t.Name)
+		}
+	}
+	return m
+}
+
+func printTeamSummary(teams map[string]*Stats, merged bool) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n👥 Teams\n%s\n", line, line)
+	fmt.Printf("%-25s | %-8s | %-10s | %-10s | %-10s | %-10s | %-10s | %-10s | %-8s\n", "TEAM", "PRs", "AvgLT", "MedianLT", "P90LT", "CFR", "MTTR", "AvgSize", "Deploys")
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := teams[name]
+		avgAdd := 0
+		if s.TotalPRs > 0 {
+			avgAdd = s.TotalAdditions / s.TotalPRs
+		}
+		fmt.Printf("%-25s | %8d | %8.1fh | %8.1fh | %8.1fh | %8.1f%% | %8.1fh | %-10s | %8d\n",
+			name, s.TotalPRs, s.AvgLeadTimeHours(), s.LeadTimeQuantile(0.5), s.LeadTimeQuantile(0.9), s.CFR(), s.MTTRHours(), fmt.Sprintf("+%d", avgAdd), teamDeployments(s, merged))
+	}
+}
@@ -0,0 +1,136 @@
+package dora
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API 呼び出しの集計（リクエスト数・待ち時間・キャッシュヒット・レート制限消費）
+type apiTelemetry struct {
+	mu        sync.Mutex
+	started   time.Time
+	Requests  int
+	Errors    int
+	CacheHits int
+	WaitTime  time.Duration
+	RateLimit map[string]*rateLimitUsage // resource ("core", "search" ...) ごと
+}
+
+type rateLimitUsage struct {
+	Consumed  int `json:"consumed"`
+	Remaining int `json:"remaining"`
+	Limit     int `json:"limit"`
+}
+
+func newAPITelemetry() *apiTelemetry {
+	return &apiTelemetry{started: time.Now(), RateLimit: make(map[string]*rateLimitUsage)}
+}
+
+func (t *apiTelemetry) Middleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		t.record(resp, err, time.Since(start))
+		return resp, err
+	})
+}
+
+func (t *apiTelemetry) record(resp *http.Response, err error, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Requests++
+	t.WaitTime += elapsed
+	if err != nil {
+		t.Errors++
+		return
+	}
+	if resp.StatusCode >= 400 {
+		t.Errors++
+	}
+	// 304 はレート制限を消費しない
+	if resp.StatusCode == http.StatusNotModified || resp.Header.Get("X-From-Cache") != "" {
+		t.CacheHits++
+		return
+	}
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		return
+	}
+	u := t.RateLimit[resource]
+	if u == nil {
+		u = &rateLimitUsage{}
+		t.RateLimit[resource] = u
+	}
+	u.Consumed++
+	u.Remaining, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	u.Limit, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+}
+
+type telemetrySummary struct {
+	Requests      int                        `json:"requests"`
+	Errors        int                        `json:"errors"`
+	CacheHits     int                        `json:"cache_hits"`
+	CacheHitRatio float64                    `json:"cache_hit_ratio"`
+	WaitSeconds   float64                    `json:"wait_seconds"`
+	WallSeconds   float64                    `json:"wall_seconds"`
+	RateLimit     map[string]*rateLimitUsage `json:"rate_limit"`
+}
+
+func (t *apiTelemetry) Summary() telemetrySummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := telemetrySummary{
+		Requests:    t.Requests,
+		Errors:      t.Errors,
+		CacheHits:   t.CacheHits,
+		WaitSeconds: t.WaitTime.Seconds(),
+		WallSeconds: time.Since(t.started).Seconds(),
+		RateLimit:   make(map[string]*rateLimitUsage),
+	}
+	if t.Requests > 0 {
+		s.CacheHitRatio = float64(t.CacheHits) / float64(t.Requests)
+	}
+	for k, v := range t.RateLimit {
+		u := *v
+		s.RateLimit[k] = &u
+	}
+	return s
+}
+
+func (t *apiTelemetry) Print() {
+	s := t.Summary()
+	line := strings.Repeat("-", 100)
+	fmt.Printf("\n%s\n📡 API Telemetry\n%s\n", line, line)
+	fmt.Printf("Requests: %d (errors: %d)\n", s.Requests, s.Errors)
+	fmt.Printf("Time waiting on GitHub: %.1fs (wall clock: %.1fs)\n", s.WaitSeconds, s.WallSeconds)
+	fmt.Printf("Cache hits: %d (%.1f%%)\n", s.CacheHits, s.CacheHitRatio*100)
+
+	resources := make([]string, 0, len(s.RateLimit))
+	for r := range s.RateLimit {
+		resources = append(resources, r)
+	}
+	sort.Strings(resources)
+	for _, r := range resources {
+		u := s.RateLimit[r]
+		fmt.Printf("Rate limit [%s]: consumed %d, remaining %d/%d\n", r, u.Consumed, u.Remaining, u.Limit)
+	}
+}
+
+func (t *apiTelemetry) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(t.Summary(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -0,0 +1,130 @@
+package dora
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 収集中はロックの mtime を lockHeartbeat ごとに更新する
+// staleLockAge のあいだ更新が止まったロックは、落ちたプロセスの残骸とみなして取り直す
+const (
+	lockHeartbeat = time.Minute
+	staleLockAge  = 5 * lockHeartbeat
+)
+
+// テナントの保存先にロックファイルを作る。戻り値はロックを外す関数
+// ロックには持ち主（ホスト・PID・取得時刻）を書き、ハートビートと解除では自分のロックかを確かめる
+func lockTenantStore(dir string) (func(), error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, ".refresh.lock")
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s pid=%d at=%s\n", host, os.Getpid(), time.Now().UTC().Format(time.RFC3339Nano))
+	for range 2 {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = f.WriteString(owner)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return holdLock(path, owner), nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if err := removeStaleLock(path); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("another process is refreshing this tenant (%s)", path)
+}
+
+// ハートビートの止まったロックを退避する。ロックがまだ生きていればエラー
+// 削除してから作り直すと、同時に取り直した 2 つのプロセスが両方ともロックを得られてしまう
+// rename は 1 つのプロセスしか成功しないので、退避したプロセスだけが作り直しに進む
+func removeStaleLock(path string) error {
+	holder, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	busy := fmt.Errorf("another process is refreshing this tenant (%s, held by %s)", path, strings.TrimSpace(string(holder)))
+	if time.Since(info.ModTime()) < staleLockAge {
+		return busy
+	}
+	aside := fmt.Sprintf("%s.stale-%d-%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, aside); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// 別のプロセスが先に退避した
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(aside)
+	if moved, err := os.ReadFile(aside); err != nil || !bytes.Equal(moved, holder) {
+		// 読んでから退避するまでに別のプロセスが取り直していた。そのロックは戻す（既にあれば戻さない）
+		os.Link(aside, path)
+		return busy
+	}
+	log.Printf("⚠️  Took over a stale refresh lock %s (held by %s, no heartbeat since %s)", path, strings.TrimSpace(string(holder)), info.ModTime().UTC().Format(time.RFC3339))
+	return nil
+}
+
+// ロックを持っている間、mtime を更新し続ける。戻り値はハートビートを止めてロックを外す関数
+func holdLock(path, owner string) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(lockHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if !ownsLock(path, owner) {
+					log.Printf("⚠️  Lost the refresh lock %s to another process", path)
+					return
+				}
+				if err := os.Chtimes(path, now, now); err != nil {
+					log.Printf("⚠️  Refresh lock heartbeat failed: %v", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		// 取り直されたロックは外さない
+		if ownsLock(path, owner) {
+			os.Remove(path)
+		}
+	}
+}
+
+func ownsLock(path, owner string) bool {
+	b, err := os.ReadFile(path)
+	return err == nil && string(b) == owner
+}
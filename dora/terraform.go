@@ -0,0 +1,186 @@
+package dora
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Terraform Cloud / Enterprise の apply をデプロイとして扱う
+// インフラリポジトリでは「terraform apply されたこと」がデプロイに当たる
+type terraformSource struct {
+	client     *http.Client
+	address    string
+	token      string
+	org        string
+	workspaces map[string]string // リポジトリ名 -> ワークスペース名
+	from       time.Time
+}
+
+func newTerraformSource(transport http.RoundTripper, address, token, org, mapping string, from time.Time) (*terraformSource, error) {
+	if token == "" || org == "" {
+		return nil, fmt.Errorf("TFC_TOKEN and --tfc-org are required for the terraform deploy source")
+	}
+	workspaces := make(map[string]string)
+	for _, pair := range strings.Split(mapping, ",") {
+		repo, ws, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		workspaces[strings.TrimSpace(repo)] = strings.TrimSpace(ws)
+	}
+	return &terraformSource{
+		client:     &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		address:    strings.TrimRight(address, "/"),
+		token:      token,
+		org:        org,
+		workspaces: workspaces,
+		from:       from,
+	}, nil
+}
+
+func (t *terraformSource) Name() string {
+	return "terraform:" + t.org
+}
+
+// JSON:API のレスポンス（必要な項目のみ）
+type tfcDocument struct {
+	Data     json.RawMessage `json:"data"`
+	Included []tfcResource   `json:"included"`
+	Meta     struct {
+		Pagination struct {
+			NextPage int `json:"next-page"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+type tfcResource struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Attributes    json.RawMessage `json:"attributes"`
+	Relationships map[string]struct {
+		Data *struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"data"`
+	} `json:"relationships"`
+}
+
+type tfcRunAttributes struct {
+	Status           string               `json:"status"`
+	CreatedAt        time.Time            `json:"created-at"`
+	StatusTimestamps map[string]time.Time `json:"status-timestamps"`
+}
+
+func (t *terraformSource) Deployments(ctx context.Context, repo string) ([]deployment, error) {
+	ws, ok := t.workspaces[repo]
+	if !ok {
+		ws = repo // マッピングが無ければリポジトリ名と同名のワークスペース
+	}
+
+	var workspace tfcDocument
+	if err := t.get(ctx, fmt.Sprintf("/api/v2/organizations/%s/workspaces/%s", url.PathEscape(t.org), url.PathEscape(ws)), &workspace); err != nil {
+		return nil, fmt.Errorf("workspace %s: %w", ws, err)
+	}
+	var wsData tfcResource
+	if err := json.Unmarshal(workspace.Data, &wsData); err != nil {
+		return nil, err
+	}
+
+	var out []deployment
+	for page := 1; page != 0; {
+		path := fmt.Sprintf("/api/v2/workspaces/%s/runs?page%%5Bsize%%5D=100&page%%5Bnumber%%5D=%d&include=configuration_version.ingress_attributes", wsData.ID, page)
+		var doc tfcDocument
+		if err := t.get(ctx, path, &doc); err != nil {
+			return out, err
+		}
+		var runs []tfcResource
+		if err := json.Unmarshal(doc.Data, &runs); err != nil {
+			return out, err
+		}
+
+		shas := ingressCommitSHAs(doc.Included)
+		reachedStart := false
+		for _, run := range runs {
+			var attrs tfcRunAttributes
+			if err := json.Unmarshal(run.Attributes, &attrs); err != nil {
+				return out, err
+			}
+			// runs は新しい順に返る
+			if attrs.CreatedAt.Before(t.from) {
+				reachedStart = true
+				break
+			}
+			d := deployment{Ref: run.ID, SHA: shas[relationshipID(run, "configuration-version")]}
+			switch {
+			case attrs.Status == "applied":
+				d.Time = attrs.StatusTimestamps["applied-at"]
+			case attrs.Status == "errored" && !attrs.StatusTimestamps["applying-at"].IsZero():
+				// apply 中のエラーのみ失敗デプロイとして数える（plan 失敗は除外）
+				d.Time = attrs.StatusTimestamps["errored-at"]
+				d.Failed = true
+			default:
+				continue
+			}
+			out = append(out, d)
+		}
+		if reachedStart {
+			break
+		}
+		page = doc.Meta.Pagination.NextPage
+	}
+	return out, nil
+}
+
+func relationshipID(r tfcResource, name string) string {
+	rel, ok := r.Relationships[name]
+	if !ok || rel.Data == nil {
+		return ""
+	}
+	return rel.Data.ID
+}
+
+// configuration-version ID -> VCS のコミット SHA
+func ingressCommitSHAs(included []tfcResource) map[string]string {
+	ingress := make(map[string]string)
+	for _, r := range included {
+		if r.Type != "ingress-attributes" {
+			continue
+		}
+		var attrs struct {
+			CommitSHA string `json:"commit-sha"`
+		}
+		if json.Unmarshal(r.Attributes, &attrs) == nil {
+			ingress[r.ID] = attrs.CommitSHA
+		}
+	}
+	shas := make(map[string]string)
+	for _, r := range included {
+		if r.Type == "configuration-versions" {
+			shas[r.ID] = ingress[relationshipID(r, "ingress-attributes")]
+		}
+	}
+	return shas
+}
+
+func (t *terraformSource) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.address+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("terraform API %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
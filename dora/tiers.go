@@ -0,0 +1,140 @@
+package dora
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DORA のパフォーマンス区分（Elite / High / Medium / Low）の境界
+// Bound は各区分の上限（低いほど良い指標）または下限（高いほど良い指標）
+type tierBand struct {
+	Tier  string  `json:"tier"`
+	Bound float64 `json:"bound"`
+}
+
+type metricTiers struct {
+	Metric       string     `json:"metric"`
+	HigherBetter bool       `json:"higher_better"`
+	Bands        []tierBand `json:"bands"` // Elite から順。最後の区分（Low）は境界なし
+}
+
+var doraTiers = []metricTiers{
+	{Metric: "deployments_per_day", HigherBetter: true, Bands: []tierBand{
+		{"elite", 1},      // 1 日 1 回以上
+		{"high", 1.0 / 7}, // 週 1 回以上
+		{"medium", 1.0 / 30},
+		{"low", 0},
+	}},
+	{Metric: "median_lead_time_hours", Bands: []tierBand{
+		{"elite", 24},
+		{"high", 24 * 7},
+		{"medium", 24 * 182}, // 半年
+		{"low", 0},
+	}},
+	{Metric: "cfr_percent", Bands: []tierBand{
+		{"elite", 15},
+		{"high", 30},
+		{"medium", 45},
+		{"low", 0},
+	}},
+	{Metric: "mttr_hours", Bands: []tierBand{
+		{"elite", 1},
+		{"high", 24},
+		{"medium", 24 * 7},
+		{"low", 0},
+	}},
+}
+
+// 区分の並び（良い順）
+var tierRank = map[string]int{"elite": 0, "high": 1, "medium": 2, "low": 3}
+
+// 値が属する区分（指標が定義されていなければ空）
+func tierOf(metric string, v float64) string {
+	for _, t := range doraTiers {
+		if t.Metric != metric {
+			continue
+		}
+		for _, b := range t.Bands[:len(t.Bands)-1] {
+			if (t.HigherBetter && v >= b.Bound) || (!t.HigherBetter && v <= b.Bound) {
+				return b.Tier
+			}
+		}
+		return t.Bands[len(t.Bands)-1].Tier
+	}
+	return ""
+}
+
+// --benchmark の区分。値が求まらない指標（リードタイムの無い PR だけ、復旧の無い期間など）は空
+// Overall は求まった指標のうち最も低い区分
+type tierSummary struct {
+	DeploymentFrequency string `json:"deployment_frequency"`
+	LeadTime            string `json:"lead_time,omitempty"`
+	ChangeFailureRate   string `json:"change_failure_rate,omitempty"`
+	TimeToRestore       string `json:"time_to_restore,omitempty"`
+	Overall             string `json:"overall"`
+}
+
+func classifyTiers(s *Stats, sum statsSummary) *tierSummary {
+	out := &tierSummary{DeploymentFrequency: tierOf("deployments_per_day", sum.DeploymentsPerDay)}
+	if s.LeadTimeCount > 0 {
+		out.LeadTime = tierOf("median_lead_time_hours", sum.MedianLeadTimeHours)
+	}
+	if sum.Deployments > 0 || sum.MergedPRs > 0 {
+		out.ChangeFailureRate = tierOf("cfr_percent", sum.CFRPercent)
+	}
+	restored := s.FixRestores
+	if incidentMTTR {
+		restored = s.Incidents - s.OpenIncidents
+	}
+	if restored > 0 {
+		out.TimeToRestore = tierOf("mttr_hours", sum.MTTRHours)
+	}
+	out.Overall = out.DeploymentFrequency
+	for _, t := range []string{out.LeadTime, out.ChangeFailureRate, out.TimeToRestore} {
+		if t != "" && tierRank[t] > tierRank[out.Overall] {
+			out.Overall = t
+		}
+	}
+	return out
+}
+
+// "elite" → "Elite"（求まらなければ "-"）
+func tierName(tier string) string {
+	if tier == "" {
+		return "-"
+	}
+	return strings.ToUpper(tier[:1]) + tier[1:]
+}
+
+// Markdown 用に色の印を付ける
+func tierLabel(tier string) string {
+	icons := map[string]string{"elite": "🟢 ", "high": "🔵 ", "medium": "🟡 ", "low": "🔴 "}
+	return icons[tier] + tierName(tier)
+}
+
+func printTierSummary(sum reportSummary) {
+	line := strings.Repeat("-", 100)
+	fmt.Printf("%s\n🏆 DORA Performance Tiers\n%s\n", line, line)
+	fmt.Printf("%-25s | %-10s | %-10s | %-10s | %-10s | %-10s\n", "ENTITY", "Overall", "DeployFreq", "LeadTime", "CFR", "MTTR")
+	row := func(name string, t *tierSummary) {
+		fmt.Printf("%-25s | %-10s | %-10s | %-10s | %-10s | %-10s\n", name,
+			tierName(t.Overall), tierName(t.DeploymentFrequency), tierName(t.LeadTime), tierName(t.ChangeFailureRate), tierName(t.TimeToRestore))
+	}
+	row("OVERALL TEAM", sum.Overall.Tiers)
+	for _, name := range sortedKeys(sum.Repos) {
+		row(name, sum.Repos[name].Tiers)
+	}
+	fmt.Println("Thresholds follow the DORA research: deploys/day ≥1 / ≥1 per week / ≥1 per month, median lead time ≤1d / ≤1w / ≤6mo, CFR ≤15% / ≤30% / ≤45%, MTTR ≤1h / ≤1d / ≤1w")
+}
+
+func writeMarkdownTiers(b *strings.Builder, sum reportSummary) {
+	b.WriteString("\n## 🏆 DORA Performance Tiers\n\n| Entity | Overall | Deploy frequency | Lead time | CFR | MTTR |\n|---|---|---|---|---|---|\n")
+	row := func(name string, t *tierSummary) {
+		fmt.Fprintf(b, "| %s | **%s** | %s | %s | %s | %s |\n", mdEscape(name),
+			tierLabel(t.Overall), tierLabel(t.DeploymentFrequency), tierLabel(t.LeadTime), tierLabel(t.ChangeFailureRate), tierLabel(t.TimeToRestore))
+	}
+	row("Overall", sum.Overall.Tiers)
+	for _, name := range sortedKeys(sum.Repos) {
+		row(name, sum.Repos[name].Tiers)
+	}
+}
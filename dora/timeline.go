@@ -0,0 +1,69 @@
+package dora
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// TimelineEvent は PR のタイムラインのイベント（レビュー依頼、Ready for review、ラベルの変更、force push など）
+// collect で保存しておき、後から追加する指標を API から取り直さずに求められるようにする
+type TimelineEvent struct {
+	Event    string    `json:"event"`
+	At       time.Time `json:"at"`
+	Actor    string    `json:"actor,omitempty"`
+	Label    string    `json:"label,omitempty"`
+	Reviewer string    `json:"reviewer,omitempty"` // review_requested など（チームは "@slug"）
+	State    string    `json:"state,omitempty"`    // reviewed の結果
+	SHA      string    `json:"sha,omitempty"`      // committed・force push のコミット
+}
+
+// PR のタイムライン（API の返す順、コメント本文は含めない）
+func (a *analyzer) fetchTimeline(ctx context.Context, repoName string, num int) ([]TimelineEvent, error) {
+	var events []TimelineEvent
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := a.client.Issues.ListIssueTimeline(ctx, a.owner, repoName, num, opts)
+		if err != nil {
+			return events, err
+		}
+		for _, t := range page {
+			events = append(events, newTimelineEvent(t))
+		}
+		if resp.NextPage == 0 {
+			return events, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// イベントの種類によって日時と実行者の入る場所が違う（reviewed は submitted_at と user、committed は author）
+func newTimelineEvent(t *github.Timeline) TimelineEvent {
+	e := TimelineEvent{
+		Event:    t.GetEvent(),
+		Actor:    t.GetActor().GetLogin(),
+		Label:    t.GetLabel().GetName(),
+		Reviewer: t.GetReviewer().GetLogin(),
+		State:    t.GetState(),
+		SHA:      t.GetCommitID(),
+	}
+	switch {
+	case t.CreatedAt != nil:
+		e.At = t.GetCreatedAt().Time
+	case t.SubmittedAt != nil:
+		e.At = t.GetSubmittedAt().Time
+	case t.Author != nil:
+		e.At = t.GetAuthor().GetDate().Time
+	}
+	if e.Actor == "" {
+		e.Actor = t.GetUser().GetLogin()
+	}
+	if t.RequestedTeam != nil {
+		e.Reviewer = "@" + t.GetRequestedTeam().GetSlug()
+	}
+	if e.SHA == "" {
+		e.SHA = t.GetSHA()
+	}
+	return e
+}
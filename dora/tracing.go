@@ -0,0 +1,292 @@
+package dora
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OTLP/HTTP (JSON) で送る最小限のトレーサー
+// tracer が nil の場合は何もしない
+// 溜まったスパンはバックグラウンドの 1 つの goroutine が送る。送信が詰まっている間のスパンは捨てる
+type tracer struct {
+	endpoint string
+	service  string
+	headers  map[string]string
+	client   *http.Client
+
+	mu    sync.Mutex
+	spans []*span
+
+	batches chan traceBatch
+	dropped atomic.Int64 // 送信待ちが一杯で捨てたスパン数
+}
+
+// 送信するスパン。done があれば、それまでの送信が終わったところでエラーを返す（Flush の待ち合わせ）
+type traceBatch struct {
+	spans []*span
+	done  chan error
+}
+
+type span struct {
+	tracer   *tracer
+	name     string
+	kind     int
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	start    time.Time
+	end      time.Time
+	attrs    map[string]any
+	err      error
+}
+
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+
+	traceBatchSize = 512
+	traceQueueSize = 4 // 送信待ちにできるバッチ数
+)
+
+type spanContextKey struct{}
+
+func newTracer(endpoint, service, headers string, transport http.RoundTripper) *tracer {
+	if endpoint == "" {
+		return nil
+	}
+	t := &tracer{
+		endpoint: strings.TrimRight(endpoint, "/") + "/v1/traces",
+		service:  service,
+		headers:  parseHeaderList(headers),
+		client:   &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		batches:  make(chan traceBatch, traceQueueSize),
+	}
+	go t.export()
+	return t
+}
+
+// バッチを順に送る。失敗は Flush でまとめて返す
+func (t *tracer) export() {
+	var errs []error
+	for b := range t.batches {
+		if len(b.spans) > 0 {
+			if err := t.send(context.Background(), b.spans); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if b.done != nil {
+			if n := t.dropped.Swap(0); n > 0 {
+				errs = append(errs, fmt.Errorf("dropped %d spans because the OTLP exporter fell behind", n))
+			}
+			b.done <- errors.Join(errs...)
+			errs = nil
+		}
+	}
+}
+
+// OTEL_EXPORTER_OTLP_HEADERS 形式 (k1=v1,k2=v2)
+func parseHeaderList(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return headers
+}
+
+func (t *tracer) Start(ctx context.Context, name string, attrs map[string]any) (context.Context, *span) {
+	return t.start(ctx, name, spanKindInternal, attrs)
+}
+
+func (t *tracer) start(ctx context.Context, name string, kind int, attrs map[string]any) (context.Context, *span) {
+	if t == nil {
+		return ctx, nil
+	}
+	s := &span{tracer: t, name: name, kind: kind, start: time.Now(), attrs: attrs}
+	if parent, ok := ctx.Value(spanContextKey{}).(*span); ok && parent != nil {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else {
+		_, _ = rand.Read(s.traceID[:])
+	}
+	_, _ = rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanContextKey{}, s), s
+}
+
+func (s *span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+}
+
+func (s *span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.err = err
+}
+
+func (s *span) End() {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	t := s.tracer
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	var batch []*span
+	if len(t.spans) >= traceBatchSize {
+		batch, t.spans = t.spans, nil
+	}
+	t.mu.Unlock()
+	if batch == nil {
+		return
+	}
+	// 計測している処理を送信で待たせない
+	select {
+	case t.batches <- traceBatch{spans: batch}:
+	default:
+		t.dropped.Add(int64(len(batch)))
+	}
+}
+
+// Middleware は HTTP リクエストごとに client span を作る
+func (t *tracer) Middleware(next http.RoundTripper) http.RoundTripper {
+	if t == nil {
+		return next
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		_, s := t.start(req.Context(), "HTTP "+req.Method, spanKindClient, map[string]any{
+			"http.request.method": req.Method,
+			"url.full":            req.URL.String(),
+		})
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			s.SetError(err)
+		} else {
+			s.SetAttr("http.response.status_code", resp.StatusCode)
+			if resp.StatusCode >= 400 {
+				s.SetError(fmt.Errorf("HTTP %d", resp.StatusCode))
+			}
+		}
+		s.End()
+		return resp, err
+	})
+}
+
+// Flush は残りのスパンを送り、送信待ちのバッチがすべて送られるまで待つ
+func (t *tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	batch := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+
+	done := make(chan error, 1)
+	select {
+	case t.batches <- traceBatch{spans: batch, done: done}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *tracer) send(ctx context.Context, batch []*span) error {
+	body, err := json.Marshal(t.payload(batch))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP export failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// OTLP JSON エンコーディング（trace/span ID は hex、時刻はナノ秒の文字列）
+func (t *tracer) payload(batch []*span) map[string]any {
+	spans := make([]map[string]any, 0, len(batch))
+	for _, s := range batch {
+		o := map[string]any{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.attrs),
+		}
+		if s.parentID != [8]byte{} {
+			o["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != nil {
+			o["status"] = map[string]any{"code": 2, "message": s.err.Error()}
+		}
+		spans = append(spans, o)
+	}
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": t.service}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "dora-metrics"},
+				"spans": spans,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	out := make([]map[string]any, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]any
+		switch x := v.(type) {
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(x)}
+		case bool:
+			value = map[string]any{"boolValue": x}
+		case float64:
+			value = map[string]any{"doubleValue": x}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(x)}
+		}
+		out = append(out, map[string]any{"key": k, "value": value})
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package dora
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// GitHub Actions のデプロイ用ワークフロー（deploy.yml など）の実行をデプロイとして扱う
+// 成功した実行がデプロイ。失敗した実行は countFailures のときだけ失敗したデプロイとして残す
+type workflowSource struct {
+	client        *github.Client
+	owner         string
+	workflow      string // ファイル名（deploy.yml）または表示名（Deploy）
+	countFailures bool
+	from          time.Time
+}
+
+func newWorkflowSource(client *github.Client, owner, workflow string, countFailures bool, from time.Time) *workflowSource {
+	return &workflowSource{client: client, owner: owner, workflow: workflow, countFailures: countFailures, from: from}
+}
+
+func (w *workflowSource) Name() string {
+	return "workflow:" + w.workflow
+}
+
+func (w *workflowSource) Deployments(ctx context.Context, repo string) ([]deployment, error) {
+	id, err := w.workflowID(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	var out []deployment
+	opts := &github.ListWorkflowRunsOptions{Status: "completed", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		runs, resp, err := w.client.Actions.ListWorkflowRunsByID(ctx, w.owner, repo, id, opts)
+		if err != nil {
+			return out, err
+		}
+		older := false
+		for _, run := range runs.WorkflowRuns {
+			d := deployment{SHA: run.GetHeadSHA(), Time: run.GetUpdatedAt().Time, Ref: run.GetHeadBranch()}
+			switch run.GetConclusion() {
+			case "success":
+				out = append(out, d)
+			case "failure", "timed_out":
+				if w.countFailures {
+					d.Failed = true
+					out = append(out, d)
+				}
+			}
+			// 一覧は新しい順。期間前の実行もロールバック判定の履歴として 1 ページ分は残す
+			if run.GetCreatedAt().Before(w.from) {
+				older = true
+			}
+		}
+		if older || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	markRollbacks(out)
+	return out, nil
+}
+
+// ファイル名でも表示名でも指定できるよう、リポジトリのワークフロー一覧から ID を引く
+func (w *workflowSource) workflowID(ctx context.Context, repo string) (int64, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := w.client.Actions.ListWorkflows(ctx, w.owner, repo, opts)
+		if err != nil {
+			return 0, err
+		}
+		for _, wf := range page.Workflows {
+			file := wf.GetPath()[strings.LastIndex(wf.GetPath(), "/")+1:]
+			if file == w.workflow || wf.GetPath() == w.workflow || wf.GetName() == w.workflow {
+				return wf.GetID(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return 0, fmt.Errorf("%s/%s: workflow %q not found", w.owner, repo, w.workflow)
+		}
+		opts.Page = resp.NextPage
+	}
+}
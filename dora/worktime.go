@@ -0,0 +1,237 @@
+package dora
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 営業時間（タイムゾーンはチームの所在地に合わせる）
+type businessHours struct {
+	loc        *time.Location
+	start, end time.Duration // 0:00 からの経過時間
+	days       [7]bool       // 営業日（time.Weekday で引く）
+}
+
+// --business-hours を指定しなかった場合の営業時間（--after-hours・--review-sla 用）
+const defaultBusinessHours = "Mon-Fri 09:00-18:00"
+
+// --business-hours を指定した場合、リードタイム・最初のレビューまでの時間を営業時間だけで数える（起動時に設定）
+var workingHours *businessHours
+
+// 営業時間を考慮した start〜end の長さ（--business-hours が無ければそのままの差）
+func elapsed(start, end time.Time) time.Duration {
+	if workingHours == nil {
+		return end.Sub(start)
+	}
+	return workingHours.Duration(start, end)
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// "[Mon-Fri] 09:00-18:00 [Asia/Tokyo]" 形式の営業時間を解釈する
+// 曜日を省くと月〜金、タイムゾーンを省くと tz（空ならローカル）
+func parseBusinessHours(spec, tz string) (*businessHours, error) {
+	invalid := fmt.Errorf("invalid business hours %q (want e.g. \"Mon-Fri 09:00-18:00 Asia/Tokyo\")", spec)
+	b := &businessHours{loc: time.Local}
+	fields := strings.Fields(spec)
+	if len(fields) > 0 && !strings.Contains(fields[0], ":") {
+		if err := b.parseDays(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid business hours %q: %w", spec, err)
+		}
+		fields = fields[1:]
+	} else {
+		for wd := time.Monday; wd <= time.Friday; wd++ {
+			b.days[wd] = true
+		}
+	}
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, invalid
+	}
+	clock := fields[0]
+	if len(fields) == 2 {
+		tz = fields[1]
+	}
+	if tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+		b.loc = l
+	}
+	from, to, ok := strings.Cut(clock, "-")
+	if !ok {
+		return nil, invalid
+	}
+	start, err1 := parseClock(from)
+	end, err2 := parseClock(to)
+	if err1 != nil || err2 != nil || end <= start {
+		return nil, invalid
+	}
+	b.start, b.end = start, end
+	return b, nil
+}
+
+// "Mon-Fri" / "Sun-Thu" / "Mon,Wed,Fri"
+func (b *businessHours) parseDays(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(strings.ToLower(item), "-")
+		first, ok1 := weekdayNames[from]
+		last, ok2 := first, true
+		if isRange {
+			last, ok2 = weekdayNames[to]
+		}
+		if !ok1 || !ok2 {
+			return fmt.Errorf("unknown weekday in %q (want e.g. Mon-Fri)", item)
+		}
+		for wd := first; ; wd = (wd + 1) % 7 {
+			b.days[wd] = true
+			if wd == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// "Mon-Fri" / "Sun-Thu" / "Mon,Wed,Fri"（表示用）
+func (b *businessHours) dayLabel() string {
+	var parts []string
+	// 日曜から始めると Sun-Thu のような並びが途中で切れないよう、営業日でない曜日の次から数える
+	origin := time.Sunday
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if !b.days[wd] {
+			origin = (wd + 1) % 7
+			break
+		}
+	}
+	for i := 0; i < 7; i++ {
+		wd := (origin + time.Weekday(i)) % 7
+		if !b.days[wd] {
+			continue
+		}
+		j := i
+		for j+1 < 7 && b.days[(origin+time.Weekday(j+1))%7] {
+			j++
+		}
+		label := wd.String()[:3]
+		if j > i {
+			label += "-" + ((origin + time.Weekday(j)) % 7).String()[:3]
+		}
+		parts = append(parts, label)
+		i = j
+	}
+	return strings.Join(parts, ",")
+}
+
+// "Mon-Fri 09:00-18:00 Asia/Tokyo"
+func (b *businessHours) String() string {
+	return fmt.Sprintf("%s %s-%s %s", b.dayLabel(), fmtClock(b.start), fmtClock(b.end), b.loc)
+}
+
+// 営業日（祝日は除く）か
+func (b *businessHours) isWorkday(t time.Time) bool {
+	return b.days[t.Weekday()] && !holidays.isHoliday(t)
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// 週末（営業日以外の曜日・祝日）か、営業日の営業時間外かを返す（週末は営業時間外に数えない）
+func (b *businessHours) classify(t time.Time) (afterHours, weekend bool) {
+	t = t.In(b.loc)
+	if !b.isWorkday(t) {
+		return false, true
+	}
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return clock < b.start || clock >= b.end, false
+}
+
+// start〜end のうち営業時間に当たる長さ（週末・祝日は数えない）
+func (b *businessHours) Duration(start, end time.Time) time.Duration {
+	var total time.Duration
+	start, end = start.In(b.loc), end.In(b.loc)
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, b.loc); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !b.isWorkday(day) {
+			continue
+		}
+		opensAt, closesAt := day.Add(b.start), day.Add(b.end)
+		if start.After(opensAt) {
+			opensAt = start
+		}
+		if end.Before(closesAt) {
+			closesAt = end
+		}
+		if closesAt.After(opensAt) {
+			total += closesAt.Sub(opensAt)
+		}
+	}
+	return total
+}
+
+// 期間内の成功したデプロイのうち、営業時間外・週末のもの
+func (x *deployIndex) CountOffHours(from, to time.Time, b *businessHours) (afterHours, weekend int) {
+	for _, d := range x.ok {
+		if d.Time.Before(from) || !d.Time.Before(to) {
+			continue
+		}
+		ah, we := b.classify(d.Time)
+		if ah {
+			afterHours++
+		}
+		if we {
+			weekend++
+		}
+	}
+	return afterHours, weekend
+}
+
+// 営業時間外・週末のマージ／デプロイの割合（持続可能性の指標）
+func printOffHoursSummary(b *businessHours, team *Stats, repos map[string]*Stats, users map[string]*Stats, merged bool) {
+	line := strings.Repeat("-", 100)
+	days := b.dayLabel()
+	if len(holidays) > 0 {
+		days += " except holidays; holidays count as weekend"
+	}
+	fmt.Printf("%s\n🌙 After-hours & Weekend (%s-%s %s, %s)\n%s\n", line, fmtClock(b.start), fmtClock(b.end), b.loc, days, line)
+	fmt.Printf("%-25s | %-8s | %-11s | %-9s | %-8s | %-11s | %-9s\n", "ENTITY", "Merges", "AfterHours%", "Weekend%", "Deploys", "AfterHours%", "Weekend%")
+	pct := func(n, total int) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(n) / float64(total) * 100
+	}
+	printOffRow := func(name string, s *Stats, withDeploys bool) {
+		fmt.Printf("%-25s | %8d | %10.1f%% | %8.1f%%", name, s.TotalPRs, pct(s.AfterHoursMerges, s.TotalPRs), pct(s.WeekendMerges, s.TotalPRs))
+		deploys, ah, we := s.Deployments, s.AfterHoursDeploys, s.WeekendDeploys
+		// デプロイソースが無い場合はマージをデプロイとみなす
+		if merged {
+			deploys, ah, we = s.TotalPRs, s.AfterHoursMerges, s.WeekendMerges
+		}
+		if !withDeploys {
+			fmt.Printf(" | %8s | %11s | %9s\n", "-", "-", "-")
+			return
+		}
+		fmt.Printf(" | %8d | %10.1f%% | %8.1f%%\n", deploys, pct(ah, deploys), pct(we, deploys))
+	}
+	printOffRow("OVERALL TEAM", team, true)
+	for _, name := range sortedKeys(repos) {
+		printOffRow(name, repos[name], true)
+	}
+	fmt.Println(line)
+	for _, name := range sortedKeys(users) {
+		printOffRow(name, users[name], false)
+	}
+}
+
+func fmtClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -1,13 +1,12 @@
-module dora-metrics
+module github.com/kkeeth/get-DORA-4keys-metrics
 
 go 1.24.0
 
 require (
 	github.com/google/go-github/v60 v60.0.0
+	github.com/joho/godotenv v1.5.1
 	golang.org/x/oauth2 v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	github.com/google/go-querystring v1.1.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-)
+require github.com/google/go-querystring v1.1.0 // indirect